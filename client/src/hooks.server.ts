@@ -11,7 +11,8 @@ const publicRoutes = [
 	'/signup/company',
 	'/callback',
 	'/unverified',
-	'/banned'
+	'/banned',
+	'/unsubscribe'
 ];
 
 type JWTPayload = {
@@ -50,8 +51,42 @@ function handleUserAuth(decoded: JWTPayload, event: any, path: string) {
 	}
 }
 
+// Helper function to ask the backend for new tokens on behalf of the browser.
+// The backend records the browser's device in the session, so pass it on.
+function fetchRefresh(event: any, refreshToken: string) {
+	const backendUrl = import.meta.env.VITE_BACKEND || 'http://localhost:8080';
+	return fetch(`${backendUrl}/auth/refresh`, {
+		method: 'POST',
+		headers: {
+			'Cookie': `refresh_token=${refreshToken}`,
+			'User-Agent': event.request.headers.get('user-agent') || '',
+			'X-Forwarded-For': event.getClientAddress()
+		},
+		credentials: 'include'
+	});
+}
+
+// Helper function to store the rotated refresh token, the old one no longer works.
+// A refresh which raced another one gets none, the browser keeps the one of the other.
+function storeRefreshToken(event: any, refreshToken?: string) {
+	if (!refreshToken) {
+		return;
+	}
+	const decoded = jwtDecode<JWTPayload>(refreshToken);
+	const expiresIn = decoded.exp ? Math.max(0, decoded.exp - Math.floor(Date.now() / 1000)) : 60 * 60 * 24 * 7;
+	event.cookies.set('refresh_token', refreshToken, {
+		path: '/',
+		httpOnly: true,
+		secure: import.meta.env.MODE === 'production',
+		sameSite: 'lax',
+		maxAge: expiresIn
+	});
+}
+
 export const handle: Handle = async ({ event, resolve }) => {
 	const path = event.url.pathname;
+	// where to come back to after logging in, with the query string, e.g. the token of an invitation link
+	const returnPath = path + event.url.search;
 	
 	const isPublicRoute = publicRoutes.some(route => 
 		path === route || path.startsWith(route + '/')
@@ -85,17 +120,11 @@ export const handle: Handle = async ({ event, resolve }) => {
 			if (isExpired && refreshToken && !skipRefresh) {
 				// Try to refresh the token
 				try {
-					const backendUrl = import.meta.env.VITE_BACKEND || 'http://localhost:8080';
-					const refreshResponse = await fetch(`${backendUrl}/auth/refresh`, {
-						method: 'POST',
-						headers: {
-							'Cookie': `refresh_token=${refreshToken}`
-						},
-						credentials: 'include'
-					});
+					const refreshResponse = await fetchRefresh(event, refreshToken);
 
 					if (refreshResponse.ok) {
 						const data = await refreshResponse.json();
+						storeRefreshToken(event, data.refresh_token);
 						const newDecoded = jwtDecode<JWTPayload>(data.access_token);
 						
 						// Check if newly refreshed token shows user is banned
@@ -128,7 +157,7 @@ export const handle: Handle = async ({ event, resolve }) => {
 						event.cookies.delete('refresh_token', { path: '/' });
 						
 						if (!isPublicRoute) {
-							throw redirect(303, `/login?returnUrl=${encodeURIComponent(path)}`);
+							throw redirect(303, `/login?returnUrl=${encodeURIComponent(returnPath)}`);
 						}
 					}
 				} catch (error) {
@@ -137,7 +166,7 @@ export const handle: Handle = async ({ event, resolve }) => {
 					event.cookies.delete('refresh_token', { path: '/' });
 					
 					if (!isPublicRoute) {
-						throw redirect(303, `/login?returnUrl=${encodeURIComponent(path)}`);
+						throw redirect(303, `/login?returnUrl=${encodeURIComponent(returnPath)}`);
 					}
 				}
 			} else if (!isExpired) {
@@ -151,7 +180,7 @@ export const handle: Handle = async ({ event, resolve }) => {
 				event.cookies.delete('access_token', { path: '/' });
 				
 				if (!isPublicRoute) {
-					throw redirect(303, `/login?returnUrl=${encodeURIComponent(path)}`);
+					throw redirect(303, `/login?returnUrl=${encodeURIComponent(returnPath)}`);
 				}
 			}
 		}
@@ -160,17 +189,11 @@ export const handle: Handle = async ({ event, resolve }) => {
 		if (refreshToken) {
 			// Try to get new access token with refresh token
 			try {
-				const backendUrl = import.meta.env.VITE_BACKEND || 'http://localhost:8080';
-				const refreshResponse = await fetch(`${backendUrl}/auth/refresh`, {
-					method: 'POST',
-					headers: {
-						'Cookie': `refresh_token=${refreshToken}`
-					},
-					credentials: 'include'
-				});
+				const refreshResponse = await fetchRefresh(event, refreshToken);
 
 				if (refreshResponse.ok) {
 					const data = await refreshResponse.json();
+					storeRefreshToken(event, data.refresh_token);
 					const newDecoded = jwtDecode<JWTPayload>(data.access_token);
 					
 					// Check if user is banned
@@ -199,16 +222,16 @@ export const handle: Handle = async ({ event, resolve }) => {
 					}
 
 					event.cookies.delete('refresh_token', { path: '/' });
-					throw redirect(303, `/login?returnUrl=${encodeURIComponent(path)}`);
+					throw redirect(303, `/login?returnUrl=${encodeURIComponent(returnPath)}`);
 				}
 			} catch (error) {
 				console.error('Token refresh failed:', error);
 				event.cookies.delete('refresh_token', { path: '/' });
-				throw redirect(303, `/login?returnUrl=${encodeURIComponent(path)}`);
+				throw redirect(303, `/login?returnUrl=${encodeURIComponent(returnPath)}`);
 			}
 		} else {
 			// No tokens at all
-			throw redirect(303, `/login?returnUrl=${encodeURIComponent(path)}`);
+			throw redirect(303, `/login?returnUrl=${encodeURIComponent(returnPath)}`);
 		}
 	}
 
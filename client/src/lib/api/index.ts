@@ -8,6 +8,8 @@ import { UserApi } from './userApi';
 import { JobApplicationApi } from './jobApplicationApi';
 import { FileApi } from './fileApi';
 import { NoteApi } from './noteApi';
+import { OrganizationApi } from './organizationApi';
+import { SavedSearchApi } from './savedSearchApi';
 
 // Create shared API client instance
 export const apiClient = new ApiClient();
@@ -18,9 +20,11 @@ export const userApi = new UserApi(apiClient);
 export const jobApplicationApi = new JobApplicationApi(apiClient);
 export const fileApi = new FileApi(apiClient);
 export const noteApi = new NoteApi(apiClient);
+export const organizationApi = new OrganizationApi(apiClient);
+export const savedSearchApi = new SavedSearchApi(apiClient);
 
 // Export classes for testing or custom instances
-export { ApiClient, JobApi, UserApi, JobApplicationApi, FileApi, NoteApi };
+export { ApiClient, JobApi, UserApi, JobApplicationApi, FileApi, NoteApi, OrganizationApi, SavedSearchApi };
 
 // Re-export types
 export type { ApiResponse, ApiError } from '$lib/types';
@@ -3,7 +3,7 @@
  */
 
 import { ApiClient } from './client';
-import type { Job, JobFilters, DeleteJobRequest, JobFormData } from '$lib/types';
+import type { Paginated, Job, JobFilters, DeleteJobRequest, JobFormData, JobStatus } from '$lib/types';
 
 export class JobApi {
   constructor(private client: ApiClient) {}
@@ -12,16 +12,16 @@ export class JobApi {
    * Query jobs with filters - GET /jobs/query
    */
   async query(filters?: JobFilters): Promise<Job[]> {
-    const response = await this.client.get<Job[]>('/jobs/query', filters);
-    return response.data;
+    const response = await this.client.get<Paginated<Job>>('/jobs/query', filters);
+    return response.data.data;
   }
 
   /**
    * Get all jobs - GET /jobs/
    */
   async getAll(): Promise<Job[]> {
-    const response = await this.client.get<Job[]>('/jobs/');
-    return response.data;
+    const response = await this.client.get<Paginated<Job>>('/jobs/');
+    return response.data.data;
   }
 
   /**
@@ -48,6 +48,14 @@ export class JobApi {
     return response.data;
   }
 
+  /**
+   * Publish, close, reopen or archive a job - PUT /jobs/:id/status
+   */
+  async changeStatus(id: string, status: JobStatus): Promise<Job> {
+    const response = await this.client.put<Job>(`/jobs/${id}/status`, { status });
+    return response.data;
+  }
+
   /**
    * Delete a job with reason - DELETE /jobs/:id
    */
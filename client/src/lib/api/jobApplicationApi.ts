@@ -3,7 +3,7 @@
  */
 
 import { ApiClient } from './client';
-import type { JobApplication, JobApplicationFilters, JobApplicationWithApplicant } from '$lib/types';
+import type { Paginated, JobApplication, JobApplicationFilters, JobApplicationWithApplicant } from '$lib/types';
 
 export class JobApplicationApi {
   constructor(private client: ApiClient) {}
@@ -12,16 +12,16 @@ export class JobApplicationApi {
    * Query job applications with filters - GET /apply/query
    */
   async query(filters?: JobApplicationFilters): Promise<JobApplication[]> {
-    const response = await this.client.get<JobApplication[]>('/apply/query', filters);
-    return response.data;
+    const response = await this.client.get<Paginated<JobApplication>>('/apply/query', filters);
+    return response.data.data;
   }
 
   /**
    * Get all job applications - GET /apply/
    */
   async getAll(): Promise<JobApplication[]> {
-    const response = await this.client.get<JobApplication[]>('/apply/');
-    return response.data;
+    const response = await this.client.get<Paginated<JobApplication>>('/apply/');
+    return response.data.data;
   }
 
   /**
@@ -0,0 +1,28 @@
+/**
+ * Organization API layer - handles all organization-related HTTP requests
+ */
+
+import { ApiClient } from './client';
+import type { Organization } from '$lib/types';
+
+export class OrganizationApi {
+  constructor(private client: ApiClient) {}
+
+  /**
+   * Get the organizations of the authenticated company account - GET /organizations/mine
+   * Includes its personal organization, whose ID is the account's own
+   */
+  async mine(): Promise<Organization[]> {
+    const response = await this.client.get<Organization[]>('/organizations/mine');
+    return response.data || [];
+  }
+
+  /**
+   * Join the organization of an invitation - POST /organizations/invitations/accept
+   * The token comes from the link in the invitation email
+   */
+  async acceptInvitation(token: string): Promise<Organization> {
+    const response = await this.client.post<Organization>('/organizations/invitations/accept', { token });
+    return response.data;
+  }
+}
@@ -0,0 +1,17 @@
+/**
+ * Saved search API layer - handles all saved-search-related HTTP requests
+ */
+
+import { ApiClient } from './client';
+
+export class SavedSearchApi {
+  constructor(private client: ApiClient) {}
+
+  /**
+   * Stop the email digest of a saved search - POST /saved-searches/unsubscribe
+   * The token comes from the link in the digest email, so no login is needed
+   */
+  async unsubscribe(token: string): Promise<void> {
+    await this.client.post(`/saved-searches/unsubscribe?token=${encodeURIComponent(token)}`);
+  }
+}
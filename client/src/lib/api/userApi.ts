@@ -3,7 +3,7 @@
  */
 
 import { ApiClient } from './client';
-import type { User, UserFilters, UpdateUserPayload } from '$lib/types';
+import type { Paginated, User, UserFilters, UpdateUserPayload } from '$lib/types';
 
 export class UserApi {
   constructor(private client: ApiClient) {}
@@ -12,16 +12,16 @@ export class UserApi {
    * Get all users - GET /users/
    */
   async getAll(): Promise<User[]> {
-    const response = await this.client.get<User[]>('/users/');
-    return response.data;
+    const response = await this.client.get<Paginated<User>>('/users/');
+    return response.data.data;
   }
 
   /**
    * Query users with filters - GET /users/query
    */
   async query(filters?: UserFilters): Promise<User[]> {
-    const response = await this.client.get<User[]>('/users/query', filters);
-    return response.data;
+    const response = await this.client.get<Paginated<User>>('/users/query', filters);
+    return response.data.data;
   }
 
   /**
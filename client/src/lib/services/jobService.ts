@@ -19,10 +19,11 @@ export class JobService {
    */
   static async queryJobs(filters?: JobFilters): Promise<Job[]> {
     try {
-      // Add default sorting to newest first if no sort is specified
+      // Add default sorting to newest first if no sort is specified,
+      // searches are sorted by relevance on the server
       const filtersWithSort = {
         ...filters,
-        sort: filters?.sort || 'dateDesc'
+        sort: filters?.sort || (filters?.q ? 'relevance' : 'dateDesc')
       };
       return await jobApi.query(filtersWithSort);
     } catch (error) {
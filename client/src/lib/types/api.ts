@@ -14,3 +14,12 @@ export interface ApiError {
   code?: string;
 }
 
+
+/**
+ * One page of a cursor-paginated list endpoint
+ */
+export interface Paginated<T> {
+  data: T[];
+  nextCursor?: string;
+  hasMore: boolean;
+}
@@ -5,7 +5,8 @@
 // API types
 export type {
   ApiResponse,
-  ApiError
+  ApiError,
+  Paginated
 } from './api';
 
 // Job types
@@ -13,9 +14,11 @@ export type {
   Job,
   JobDisplay,
   JobUI,
+  SalaryBand,
   JobFilters,
   DeleteJobRequest,
   JobFormData,
+  JobStatus,
   ValidationState
 } from './job';
 
@@ -50,6 +53,14 @@ export type {
   NoteFilters
 } from './note';
 
+// Organization types
+export type {
+  Organization,
+  OrganizationMember,
+  OrganizationInvitation,
+  OrganizationRole
+} from './organization';
+
 // Re-export utility functions
 export { 
   transformToBackendFormat, 
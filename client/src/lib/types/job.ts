@@ -19,13 +19,76 @@ export interface Job {
   experienceLevel: string;
   education: string;
   niceToHave?: string;
-  questions?: string;
+  // older jobs and the job form send free text, one question per line
+  questions?: ScreeningQuestion[] | string;
   postOpenDate: string;
   applicationDeadline: string;
   numberOfPositions: number;
   visibility: string;
   emailNotifications: boolean;
   autoReject: boolean;
+  // applications failing any rule are rejected when autoReject is on
+  knockoutRules?: KnockoutRule[];
+  rejectionEmail?: RejectionEmail;
+  // new jobs are published unless sent as drafts, see PUT /jobs/:id/status
+  status?: JobStatus;
+  closedAt?: string;
+  closedReason?: 'deadline' | 'filled' | 'company';
+  archivedAt?: string;
+  // only set by GET /jobs/query
+  score?: number;
+  highlights?: Record<string, string[]>;
+  convertedSalary?: SalaryBand;
+}
+
+export type JobStatus = 'draft' | 'scheduled' | 'published' | 'closed' | 'archived';
+
+export type QuestionType = 'shortText' | 'longText' | 'singleChoice' | 'multiChoice' | 'yesNo' | 'number';
+
+export interface ScreeningQuestion {
+  id?: string;
+  prompt: string;
+  type: QuestionType;
+  required: boolean;
+  options?: string[];
+  minLength?: number;
+  maxLength?: number;
+  min?: number;
+  max?: number;
+}
+
+export interface ScreeningAnswer {
+  questionID: string;
+  // copied from the question when the application was sent
+  prompt?: string;
+  type?: QuestionType;
+  text?: string;
+  choices?: string[];
+  yes?: boolean;
+  number?: number;
+}
+
+export type KnockoutRuleType = 'answer' | 'minExperience' | 'location' | 'workAuthorization' | 'resume';
+
+export interface KnockoutRule {
+  type: KnockoutRuleType;
+  questionID?: string;
+  // accepted answers, locations or country codes
+  values?: string[];
+  // lowest accepted number answer or years of experience
+  min?: number;
+}
+
+export interface RejectionEmail {
+  send: boolean;
+  delayHours: number;
+  message?: string;
+}
+
+export interface SalaryBand {
+  currency: string;
+  minSalary: number;
+  maxSalary: number;
 }
 
 // Frontend-enhanced job (for display purposes)
@@ -58,16 +121,19 @@ export interface JobUI {
 // Query filters (matches backend query params)
 export interface JobFilters {
   id?: string;
+  q?: string; // full-text search, results come most relevant first
   title?: string;
   companyID?: string;
   location?: string;
   minSalary?: number;
   maxSalary?: number;
+  currency?: string; // currency of minSalary/maxSalary, other currencies are converted
   workType?: string;
   workArrangement?: string;
   postOpenDate?: string; // "1d" | "6w"
   latest?: boolean;
-  sort?: "dateAsc" | "dateDesc" | "title";
+  sort?: "relevance" | "dateAsc" | "dateDesc" | "title";
+  status?: JobStatus; // other than published only for members of the company in companyID
 }
 
 export interface DeleteJobRequest {
@@ -99,7 +165,7 @@ export interface JobFormData {
   // Post Settings
   postingOpenDate?: string;
   postingCloseDate?: string;
-  screeningQuestions?: string;
+  screeningQuestions?: ScreeningQuestion[] | string;
   emailNotifications?: boolean;
   
   // Application Requirements
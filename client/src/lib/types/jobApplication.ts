@@ -2,16 +2,34 @@
  * Job Application-related type definitions
  */
 
+import type { KnockoutRule } from './job';
+
 export interface JobApplication {
   id: string;
   applicantID: string;
   jobID: string;
   companyID?: string;
   status: string;
+  // outcome of the knockout rules, when the job auto-rejects
+  knockout?: KnockoutResult;
   createdAt: string;
   // Add other application fields as needed
 }
 
+export interface KnockoutCheck {
+  rule: KnockoutRule;
+  passed: boolean;
+  // machine readable, e.g. "missing_resume"
+  reason?: string;
+  detail: string;
+}
+
+export interface KnockoutResult {
+  passed: boolean;
+  checks: KnockoutCheck[];
+  evaluatedAt: string;
+}
+
 // Type for the nested response structure that includes user data
 export interface JobApplicationWithApplicant {
   jobApplication: JobApplication;
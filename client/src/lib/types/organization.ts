@@ -0,0 +1,28 @@
+/**
+ * Organization-related types matching backend schema
+ */
+
+export type OrganizationRole = 'owner' | 'recruiter' | 'viewer';
+
+export interface OrganizationMember {
+  userID: string;
+  role: OrganizationRole;
+  joinedAt: string;
+}
+
+export interface OrganizationInvitation {
+  id: string;
+  email: string;
+  role: OrganizationRole;
+  invitedBy: string;
+  createdAt: string;
+  expiresAt: string;
+}
+
+export interface Organization {
+  id: string;
+  name: string;
+  members: OrganizationMember[];
+  invitations: OrganizationInvitation[];
+  createdAt: string;
+}
@@ -12,6 +12,8 @@ export interface User {
   avatarURL: string;
   role: string;
   verified: boolean;
+  // language of the emails sent to this user, English when unset
+  locale?: 'en' | 'th';
   updatedAt: string;
   createdAt: string;
   userInfo?: JobSeekerInfo | CompanyInfo;
@@ -29,6 +31,34 @@ export interface JobSeekerInfo {
   portfolio?: string;
   github?: string;
   skills?: string | string[];
+  education?: Education[];
+  workHistory?: WorkExperience[];
+  expectedSalary?: ExpectedSalary;
+  // ISO 3166 alpha-2 codes of the countries they may work in
+  workAuthorization?: string[];
+}
+
+export interface Education {
+  institution: string;
+  degree?: string;
+  fieldOfStudy?: string;
+  startYear?: number;
+  endYear?: number;
+  gpa?: string;
+}
+
+export interface WorkExperience {
+  company: string;
+  title: string;
+  startDate: string; // YYYY-MM
+  endDate?: string; // YYYY-MM, empty while current
+  description?: string;
+}
+
+export interface ExpectedSalary {
+  currency: string;
+  min: number;
+  max?: number;
 }
 
 export interface CompanyInfo {
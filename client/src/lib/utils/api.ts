@@ -9,3 +9,27 @@ export async function apiFetch(url: string, options: RequestInit = {}) {
 
 	return res;
 }
+
+/**
+ * Fetch every page of a paginated endpoint, which answers with { data, nextCursor, hasMore },
+ * by following nextCursor until there are no more pages.
+ * `res` is the last response, so callers can check `res.ok` and `res.status` as with apiFetch.
+ */
+export async function apiFetchAll<T = any>(
+	url: string,
+	options: RequestInit = {}
+): Promise<{ res: Response; data: T[] }> {
+	const data: T[] = [];
+	const separator = url.includes('?') ? '&' : '?';
+	let cursor = '';
+	for (;;) {
+		const page = cursor ? `&cursor=${encodeURIComponent(cursor)}` : '';
+		const res = await apiFetch(`${url}${separator}limit=100${page}`, options);
+		if (!res.ok) return { res, data };
+
+		const body = await res.json();
+		if (Array.isArray(body.data)) data.push(...body.data);
+		if (!body.hasMore || !body.nextCursor) return { res, data };
+		cursor = body.nextCursor;
+	}
+}
@@ -1,3 +1,5 @@
+import { apiFetchAll } from './api';
+
 interface CompanyStats {
 	activeJobs: number;
 	totalApplicants: number;
@@ -14,11 +16,8 @@ interface CompanyStats {
 export async function getCompanyAnalytics(companyID: string): Promise<CompanyStats> {
 	if (!companyID) throw new Error('Invalid companyID');
 
-	const jobsRes = await fetch(`/jobs/query?companyID=${companyID}`, {
-		credentials: 'include'
-	});
+	const { res: jobsRes, data: jobs } = await apiFetchAll(`/jobs/query?companyID=${companyID}`);
 	if (!jobsRes.ok) throw new Error('Failed to fetch company jobs');
-	const jobs = await jobsRes.json();
 
 	if (!Array.isArray(jobs) || jobs.length === 0) {
 		return emptyStats();
@@ -53,18 +52,11 @@ export async function getCompanyAnalytics(companyID: string): Promise<CompanySta
 		const jobID = job.id || job._id;
 		if (!jobID) continue;
 
-		const applyRes = await fetch(`/apply/query?jobID=${jobID}`, {
-			credentials: 'include'
-		});
+		const { res: applyRes, data: applications } = await apiFetchAll(`/apply/query?jobID=${jobID}`);
 		if (!applyRes.ok) {
 			console.warn(`Failed to fetch applications for job ${jobID}: ${applyRes.status}`);
 			continue;
 		}
-		const applications = await applyRes.json();
-		if (!Array.isArray(applications)) {
-			console.warn(`Invalid applications response for job ${jobID}`);
-			continue;
-		}
 
 		for (const app of applications) {
 			const createdAt = new Date(app.jobApplication?.createdAt || app.createdAt);
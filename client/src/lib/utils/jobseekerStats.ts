@@ -1,3 +1,5 @@
+import { apiFetchAll } from './api';
+
 export interface JobseekerStats {
 	totalApplications: number;
 	inReview: number;
@@ -34,9 +36,7 @@ export async function getJobseekerStats(userID: string): Promise<JobseekerStats>
 	}
 
 	try {
-		const res = await fetch(`/apply/query?applicantID=${userID}`, {
-			credentials: 'include'
-		});
+		const { res, data: applications } = await apiFetchAll(`/apply/query?applicantID=${userID}`);
 		
 		if (!res.ok) {
 			// Handle authentication errors specifically
@@ -53,13 +53,6 @@ export async function getJobseekerStats(userID: string): Promise<JobseekerStats>
 			throw new Error(`Failed to fetch applications: ${res.status}`);
 		}
 
-		const applications = await res.json();
-		
-		if (!Array.isArray(applications)) {
-			console.error('Expected array but got:', typeof applications);
-			return emptyStats();
-		}
-		
 		if (applications.length === 0) {
 			return emptyStats();
 		}
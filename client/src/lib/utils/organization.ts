@@ -0,0 +1,30 @@
+import { organizationApi } from '$lib/api';
+
+let cached: { userID: string; organizationID: string } | null = null;
+
+/**
+ * The ID of the organization a company account works for, which jobs are posted and listed under.
+ * It is the organization the account joined through an invitation, otherwise its personal one,
+ * whose ID is the account's own.
+ */
+export async function getOrganizationID(userID: string): Promise<string> {
+	if (cached?.userID === userID) return cached.organizationID;
+
+	let organizationID = userID;
+	try {
+		const organizations = await organizationApi.mine();
+		const joined = organizations.find((org) => org.id !== userID);
+		if (joined) organizationID = joined.id;
+	} catch (err) {
+		console.warn('Failed to load organizations, using the personal one', err);
+	}
+	cached = { userID, organizationID };
+	return organizationID;
+}
+
+/**
+ * Forget the organization found by getOrganizationID, e.g. after joining another one.
+ */
+export function forgetOrganization() {
+	cached = null;
+}
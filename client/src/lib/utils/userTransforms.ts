@@ -193,7 +193,9 @@ function buildSeekerInfo(userData: Record<string, unknown>): JobSeekerInfo {
     dateOfBirth: (userData.dateOfBirth as string) || '',
     portfolio: (userData.portfolio as string) || '',
     github: (userData.github as string) || '',
-    skills: Array.isArray(userData.skills) ? userData.skills.join(', ') : (userData.skills as string) || ''
+    skills: Array.isArray(userData.skills)
+      ? (userData.skills as string[])
+      : ((userData.skills as string) || '').split(',').map((s) => s.trim()).filter(Boolean)
   };
 }
 
@@ -102,6 +102,19 @@ export const load: PageServerLoad = async ({ cookies }) => {
             sameSite: 'lax',
             maxAge: jwtExpiresIn
           });
+          // the refresh rotated the refresh token, the old one no longer works
+          if (data.refresh_token) {
+            const refreshDecoded = jwtDecode<JWTPayload>(data.refresh_token);
+            cookies.set('refresh_token', data.refresh_token, {
+              path: '/',
+              httpOnly: true,
+              secure: import.meta.env.MODE === 'production',
+              sameSite: 'lax',
+              maxAge: refreshDecoded.exp
+                ? Math.max(0, refreshDecoded.exp - Math.floor(Date.now() / 1000))
+                : 60 * 60 * 24 * 7
+            });
+          }
           
           const role = newDecoded.role?.toLowerCase();
           if (role === 'company') {
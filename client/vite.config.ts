@@ -28,6 +28,8 @@ export default defineConfig(({ mode }) => {
 				'/users': { target: env.VITE_BACKEND, changeOrigin: true, secure: false },
 				'/files': { target: env.VITE_BACKEND, changeOrigin: true, secure: false },
 				'/notes': { target: env.VITE_BACKEND, changeOrigin: true, secure: false },
+				'/saved-searches': { target: env.VITE_BACKEND, changeOrigin: true, secure: false },
+				'/organizations': { target: env.VITE_BACKEND, changeOrigin: true, secure: false },
 			}
 		},
 		test: {
@@ -20,8 +20,13 @@ func LoadEnv(env string) string {
 	return os.Getenv(env)
 }
 
+// LoadCallbackURI returns the OAuth redirect URL of provider.
+// Google uses OAUTH_REDIRECT_URL, the others are served under SERVER_URL.
 func LoadCallbackURI(protocol string, provider string) string {
-	return LoadEnv("OAUTH_REDIRECT_URL")
+	if provider == "google" {
+		return LoadEnv("OAUTH_REDIRECT_URL")
+	}
+	return LoadEnv("SERVER_URL") + "/auth/" + provider + "/callback"
 }
 
 func LoadBoolean(env string) bool {
@@ -6,6 +6,7 @@ require (
 	github.com/JGLTechnologies/gin-rate-limit v1.5.6
 	github.com/gin-contrib/cors v1.7.6
 	github.com/gin-gonic/gin v1.10.1
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/golang-jwt/jwt/v5 v5.2.2
 	github.com/gorilla/sessions v1.4.0
 	github.com/joho/godotenv v1.5.1
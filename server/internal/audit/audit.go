@@ -0,0 +1,95 @@
+// Package audit records privileged and security-relevant actions in the append-only audit_events collection,
+// so that admins can tell who did what to whom, from where and when.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// requestIDKey is where middleware.RequestID stores the ID of the request in the gin context.
+// It is repeated here, as the middleware package imports auth, which records events.
+const requestIDKey = "requestID"
+
+// ignoredFields change with every update, so they are left out of diffs.
+var ignoredFields = map[string]bool{"updatedAt": true}
+
+// Record saves event, which happened during the request in c, right after the action was done.
+// The actor is the signed-in user unless event names one, e.g. for logins.
+// Saving is not allowed to fail the request, which already changed things, so a failure is only logged.
+func Record(c *gin.Context, event schema.AuditEvent) {
+	if event.ActorID == nil {
+		if id, err := primitive.ObjectIDFromHex(c.GetString("userID")); err == nil {
+			event.ActorID = &id
+			event.ActorRole = c.GetString("role")
+		}
+	}
+	event.ID = primitive.NilObjectID
+	event.IP = c.ClientIP()
+	event.RequestID = c.GetString(requestIDKey)
+	event.CreatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := repository.InsertOne(ctx, event); err != nil {
+		slog.Error(fmt.Sprintf("Could not record audit event %s of %s %s: %s",
+			event.Action, event.TargetType, event.TargetID.Hex(), err))
+	}
+}
+
+// Diff lists the fields whose values differ between before and after, which are the same kind of document.
+// They are compared as clients see them, so fields which are not sent to clients, like secrets, never end up in the log.
+func Diff(before, after any) []schema.AuditChange {
+	old, err := asFields(before)
+	if err != nil {
+		return nil
+	}
+	updated, err := asFields(after)
+	if err != nil {
+		return nil
+	}
+
+	fields := make([]string, 0, len(old)+len(updated))
+	for field := range old {
+		fields = append(fields, field)
+	}
+	for field := range updated {
+		if _, ok := old[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	var changes []schema.AuditChange
+	for _, field := range fields {
+		if ignoredFields[field] || reflect.DeepEqual(old[field], updated[field]) {
+			continue
+		}
+		changes = append(changes, schema.AuditChange{Field: field, Before: old[field], After: updated[field]})
+	}
+	return changes
+}
+
+// asFields turns a document into its JSON fields, nil into none.
+func asFields(doc any) (map[string]any, error) {
+	fields := map[string]any{}
+	if doc == nil {
+		return fields, nil
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
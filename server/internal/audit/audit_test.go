@@ -0,0 +1,38 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDiff(t *testing.T) {
+	before := schema.User{
+		ID:        primitive.NewObjectID(),
+		Name:      "Somchai",
+		Role:      schema.RoleJobSeeker,
+		UpdatedAt: time.Now().Add(-time.Hour),
+		Identities: []schema.Identity{
+			{Provider: "google", Subject: "old-subject"},
+		},
+	}
+	after := before
+	after.Role = schema.RoleCompany
+	after.Banned = true
+	after.UpdatedAt = time.Now()
+	after.Identities = []schema.Identity{{Provider: "google", Subject: "new-subject"}}
+
+	assert.Equal(t, []schema.AuditChange{
+		{Field: "banned", Before: nil, After: true},
+		{Field: "role", Before: schema.RoleJobSeeker, After: schema.RoleCompany},
+	}, Diff(before, after))
+}
+
+func TestDiffOfNewDocument(t *testing.T) {
+	changes := Diff(nil, map[string]any{"status": "closed"})
+	assert.Equal(t, []schema.AuditChange{{Field: "status", Before: nil, After: "closed"}}, changes)
+	assert.Empty(t, Diff(map[string]any{"a": 1}, map[string]any{"a": 1}))
+}
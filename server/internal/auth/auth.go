@@ -1,32 +1,28 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/sessions"
 	"github.com/lnwdevelopers007/job-applier-3000/server/config"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/audit"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/dto"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
 	"github.com/markbates/goth"
 	"github.com/markbates/goth/gothic"
-	"github.com/markbates/goth/providers/google"
 )
 
 func init() {
-	googleAuth := google.New(
-		config.LoadEnv("CLIENT_ID"),
-		config.LoadEnv("CLIENT_SECRET"),
-		config.LoadCallbackURI("http", "google"),
-		"email",
-		"profile",
-	)
-
-	goth.UseProviders(googleAuth)
+	goth.UseProviders(newProviders()...)
 	maxAgeSeconds := 86400 * config.LoadInt("MAX_COOKIE_AGE_DAYS")
 	store := sessions.NewCookieStore(
 		[]byte(config.LoadEnv("SESSION_HASH_KEY")),
@@ -45,6 +41,10 @@ func init() {
 func OAuthCallback(c *gin.Context) {
 	addProvider(c)
 	role := c.Query("state")
+	if strings.HasPrefix(role, linkStatePrefix) {
+		completeLink(c)
+		return
+	}
 	user, err := gothic.CompleteUserAuth(c.Writer, c.Request)
 	if err != nil {
 		msg := "cannot complete user authentication"
@@ -65,6 +65,12 @@ func OAuthCallback(c *gin.Context) {
 		return
 	}
 
+	completeLogin(c, dbUser, isNewUser, "oauth:"+user.Provider)
+}
+
+// completeLogin starts a session for dbUser, who just proved who they are,
+// and redirects them to the frontend with the cookies of its tokens.
+func completeLogin(c *gin.Context, dbUser schema.User, isNewUser bool, method string) {
 	// Normal flow for non-banned users
 	refreshTokenUser := dto.RefreshTokenUser{
 		Email:     dbUser.Email,
@@ -75,41 +81,31 @@ func OAuthCallback(c *gin.Context) {
 		Verified:  dbUser.Verified,
 		Banned:    dbUser.Banned,
 	}
-	accessToken, refreshToken, err := generateTokens(refreshTokenUser)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tokens, err := startSession(ctx, c, refreshTokenUser)
 	if err != nil {
 		msg := "cannot generate token"
 		slog.Error(msg + err.Error())
 		c.AbortWithError(http.StatusInternalServerError, errors.New(msg))
 		return
 	}
-
-	refreshTokenAge := config.LoadInt("REFRESH_TOKEN_AGE_DAYS") * 24 * 3600
-	// Set refresh token as HttpOnly cookie
-	c.SetCookie(
-		"refresh_token",
-		refreshToken,
-		refreshTokenAge,
-		"/", "",
-		false,
-		true,
-	)
-
-	// Set access token as HttpOnly cookie (more secure than localStorage)
-	// Access tokens typically have shorter lifespan (e.g., 1 hour = 3600 seconds)
-	c.SetCookie(
-		"access_token",
-		accessToken,
-		3600, // 1 hour
-		"/", "",
-		false,
-		true,
-	)
+	accessToken := tokens.Access
 
 	slog.Info("User logged in",
 		slog.String("userID", fmt.Sprint(dbUser.ID.Hex())),
 		slog.String("role", fmt.Sprint(dbUser.Role)),
+		slog.String("method", method),
 		slog.String("ip", c.ClientIP()),
 	)
+	audit.Record(c, schema.AuditEvent{
+		ActorID:    &dbUser.ID,
+		ActorRole:  dbUser.Role,
+		Action:     schema.AuditLogin,
+		TargetType: dbUser.GetCollectionName(),
+		TargetID:   dbUser.ID,
+		Detail:     method,
+	})
 
 	if dbUser.Banned {
 		// Now redirect to banned page WITH cookies
@@ -147,22 +143,22 @@ func Logout(c *gin.Context) {
 
 	// Parse claims for logging
 	var userID, role string
-	if decodedClaims, _ := ParseJWT(accessToken); decodedClaims != nil {
+	if decodedClaims, _ := ParseToken(accessToken, TokenAccess); decodedClaims != nil {
 		userID = decodedClaims.UserID
 		role = decodedClaims.Role
-		
-		// Add access token to blacklist
-		if decodedClaims.ExpiresAt != nil {
-			AddToBlacklist(accessToken, decodedClaims.ExpiresAt.Time)
-		}
 	}
-	
-	// Add refresh token to blacklist
-	if refreshToken != "" {
-		if refreshClaims, _ := ParseJWT(refreshToken); refreshClaims != nil && refreshClaims.ExpiresAt != nil {
-			AddToBlacklist(refreshToken, refreshClaims.ExpiresAt.Time)
+
+	// Revoke both tokens, so that they cannot be used on any server until they expire
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	for _, token := range []string{accessToken, refreshToken} {
+		if err := RevokeToken(ctx, token); err != nil {
+			slog.Error("Could not revoke token on logout: " + err.Error())
 		}
 	}
+	if err := endSessionOfToken(ctx, refreshToken); err != nil {
+		slog.Error("Could not end session on logout: " + err.Error())
+	}
 
 	slog.Info("User logged out",
 		slog.String("userID", userID),
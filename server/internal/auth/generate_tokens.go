@@ -9,24 +9,34 @@ import (
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/dto"
 )
 
-var jwtSecret = []byte(config.LoadEnv("JWT_SECRET"))
+// accessTokenAge is how long an access token is valid.
+const accessTokenAge = 15 * time.Minute
 
-func generateTokens(user dto.RefreshTokenUser) (accessToken, refreshToken string, err error) {
-	// Access token (15m)
-	accessClaims := jwt.MapClaims{
-		"email":     user.Email,
-		"name":      user.Name,
-		"avatarURL": user.AvatarURL,
-		"userID":    user.ID,
-		"exp":       time.Now().Add(15 * time.Minute).Unix(),
-		"role":      user.Role,
-		"verified":  user.Verified,
-		"banned":    user.Banned,
+// tokenPair is a freshly signed access and refresh token of one session.
+type tokenPair struct {
+	Access        string
+	AccessJTI     string
+	AccessExpiry  time.Time
+	Refresh       string
+	RefreshJTI    string
+	RefreshExpiry time.Time
+}
+
+func generateTokens(user dto.RefreshTokenUser, sessionID string) (tokens tokenPair, err error) {
+	// every token gets its own jti, so that it can be revoked on its own
+	if tokens.AccessJTI, err = newJTI(); err != nil {
+		return
+	}
+	if tokens.RefreshJTI, err = newJTI(); err != nil {
+		return
 	}
-	accessToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims).SignedString(jwtSecret)
+
+	// Access token (15m)
+	tokens.AccessExpiry = time.Now().Add(accessTokenAge)
+	tokens.Access, err = signAccessToken(user, sessionID, tokens.AccessJTI, tokens.AccessExpiry)
 
 	if !config.LoadBoolean("IS_PROD") {
-		fmt.Println(accessToken)
+		fmt.Println(tokens.Access)
 	}
 
 	if err != nil {
@@ -35,18 +45,38 @@ func generateTokens(user dto.RefreshTokenUser) (accessToken, refreshToken string
 
 	expDays := time.Duration(config.LoadInt("REFRESH_TOKEN_AGE_DAYS"))
 	// Refresh token (7d)
+	tokens.RefreshExpiry = time.Now().Add(expDays * 24 * time.Hour)
 	refreshClaims := jwt.MapClaims{
+		"jti":       tokens.RefreshJTI,
+		"sid":       sessionID,
 		"email":     user.Email,
 		"name":      user.Name,
 		"avatarURL": user.AvatarURL,
 		"userID":    user.ID,
-		"type":      "refresh",
-		"exp":       time.Now().Add(expDays * 24 * time.Hour).Unix(),
+		"type":      TokenRefresh,
+		"exp":       tokens.RefreshExpiry.Unix(),
 		"role":      user.Role,
 		"verified":  user.Verified,
 		"banned":    user.Banned,
 	}
-	refreshToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString(jwtSecret)
+	tokens.Refresh, err = GetKeyManager().Sign(refreshClaims)
 
 	return
 }
+
+// signAccessToken signs an access token of user with the given jti and expiry.
+func signAccessToken(user dto.RefreshTokenUser, sessionID, jti string, expiry time.Time) (string, error) {
+	return GetKeyManager().Sign(jwt.MapClaims{
+		"jti":       jti,
+		"type":      TokenAccess,
+		"sid":       sessionID,
+		"email":     user.Email,
+		"name":      user.Name,
+		"avatarURL": user.AvatarURL,
+		"userID":    user.ID,
+		"exp":       expiry.Unix(),
+		"role":      user.Role,
+		"verified":  user.Verified,
+		"banned":    user.Banned,
+	})
+}
@@ -0,0 +1,317 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/config"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/audit"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothic"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// linkStatePrefix marks the OAuth state of a login which links a provider
+// to the logged in user, instead of logging in.
+const linkStatePrefix = "link:"
+
+// linkUserKey is where LinkProvider keeps the user to link to, in the gothic session.
+const linkUserKey = "link_user"
+
+var (
+	errIdentityTaken  = errors.New("this account is already linked to another user")
+	errProviderLinked = errors.New("a different account of this provider is already linked")
+	errLastIdentity   = errors.New("cannot unlink the only provider you can log in with")
+	errUserDeleted    = errors.New("this account was deleted, contact support to restore it")
+)
+
+func newIdentity(gUser goth.User) schema.Identity {
+	return schema.Identity{
+		Provider:      gUser.Provider,
+		Subject:       gUser.UserID,
+		Email:         gUser.Email,
+		EmailVerified: emailVerified(gUser),
+		LinkedAt:      time.Now(),
+	}
+}
+
+// identities returns the identities of user, including the one of users
+// registered before identities existed.
+func identities(user schema.User) []schema.Identity {
+	if len(user.Identities) == 0 && user.UserID != "" {
+		return []schema.Identity{{
+			Provider: user.Provider,
+			Subject:  user.UserID,
+			Email:    user.Email,
+			LinkedAt: user.CreatedAt,
+		}}
+	}
+	return user.Identities
+}
+
+// hasVerifiedEmail reports whether a provider vouched for the email of user,
+// that is whether one of its identities has that email and verified it.
+// Only then the email can stand for the user, e.g. to link another provider or send a login link.
+func hasVerifiedEmail(user schema.User) bool {
+	if user.Email == "" {
+		return false
+	}
+	for _, identity := range identities(user) {
+		if identity.EmailVerified && strings.EqualFold(identity.Email, user.Email) {
+			return true
+		}
+	}
+	return false
+}
+
+// findUserByIdentity returns the user an OAuth account is linked to.
+func findUserByIdentity(ctx context.Context, provider, subject string) (schema.User, error) {
+	return findUser(ctx, bson.M{"$or": bson.A{
+		bson.M{"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}}},
+		// users registered before identities existed
+		bson.M{"userID": subject, "provider": provider, "identities": bson.M{"$exists": false}},
+	}})
+}
+
+// findUserByEmail returns the user with address as their email, ignoring case.
+func findUserByEmail(ctx context.Context, address string) (schema.User, error) {
+	return findUser(ctx, bson.M{
+		"email": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(address) + "$", Options: "i"},
+	})
+}
+
+// findUser finds deleted users too, as their OAuth accounts and email stay taken until they are purged.
+func findUser(ctx context.Context, filter bson.M) (schema.User, error) {
+	var user schema.User
+	err := database.GetDatabase().Collection(user.GetCollectionName()).FindOne(ctx, filter).Decode(&user)
+	return user, err
+}
+
+// linkIdentity adds identity to user, who may have at most one identity per provider.
+func linkIdentity(ctx context.Context, user schema.User, identity schema.Identity) error {
+	toAdd := []schema.Identity{identity}
+	for _, linked := range identities(user) {
+		if linked.Provider == identity.Provider {
+			if linked.Subject == identity.Subject {
+				return nil
+			}
+			return errProviderLinked
+		}
+	}
+	if len(user.Identities) == 0 {
+		toAdd = append(identities(user), identity)
+	}
+
+	res, err := repository.UpdateOne[schema.User](ctx,
+		// someone may have linked this provider since we read the user
+		bson.M{"_id": user.ID, "identities.provider": bson.M{"$ne": identity.Provider}},
+		bson.M{
+			"$push": bson.M{"identities": bson.M{"$each": toAdd}},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return errIdentityTaken
+	}
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errProviderLinked
+	}
+	return nil
+}
+
+// LinkProvider godoc
+// @Summary      Link an OAuth provider
+// @Description  Redirect to the provider's login. Once it succeeds, the provider's account is linked to the authenticated user and they are redirected to their settings.
+// @Tags         Auth
+// @Param        provider  path  string  true  "Provider"  Enums(google, github, microsoft, oidc)
+// @Success      307
+// @Failure      401  {object}  map[string]string
+// @Router       /auth/{provider}/link [get]
+func LinkProvider(c *gin.Context) {
+	userID, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+	addProvider(c)
+
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not start linking"})
+		return
+	}
+	if err := gothic.StoreInSession(linkUserKey, userID.Hex(), c.Request, c.Writer); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not start linking"})
+		return
+	}
+
+	// a random state, so that nobody can complete this link with their own account
+	q := c.Request.URL.Query()
+	q.Set("state", linkStatePrefix+base64.RawURLEncoding.EncodeToString(nonce))
+	c.Request.URL.RawQuery = q.Encode()
+	gothic.BeginAuthHandler(c.Writer, c.Request)
+}
+
+// completeLink finishes LinkProvider once the provider redirected back to OAuthCallback.
+func completeLink(c *gin.Context) {
+	// read it before completing the login, which clears the session
+	linkUser, err := gothic.GetFromSession(linkUserKey, c.Request)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, errors.New("no provider is being linked"))
+		return
+	}
+	userID, err := primitive.ObjectIDFromHex(linkUser)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, errors.New("no provider is being linked"))
+		return
+	}
+
+	gUser, err := gothic.CompleteUserAuth(c.Writer, c.Request)
+	if err != nil {
+		msg := "cannot complete user authentication"
+		slog.Error(msg + ": " + err.Error())
+		c.AbortWithError(http.StatusInternalServerError, errors.New(msg))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := repository.FindOne[schema.User](ctx, userID)
+	if err != nil {
+		c.AbortWithError(http.StatusNotFound, errors.New("user not found"))
+		return
+	}
+
+	identity := newIdentity(gUser)
+	owner, err := findUserByIdentity(ctx, identity.Provider, identity.Subject)
+	switch {
+	case err == nil && owner.ID != user.ID:
+		err = errIdentityTaken
+	case err == nil || errors.Is(err, mongo.ErrNoDocuments):
+		err = linkIdentity(ctx, user, identity)
+	}
+
+	query := url.Values{}
+	if err != nil {
+		if !errors.Is(err, errIdentityTaken) && !errors.Is(err, errProviderLinked) {
+			slog.Error("Link provider failed: " + err.Error())
+			err = errors.New("could not link provider")
+		}
+		query.Set("linkError", err.Error())
+	} else {
+		query.Set("linked", identity.Provider)
+		slog.Info("Provider linked",
+			slog.String("userID", user.ID.Hex()),
+			slog.String("provider", identity.Provider),
+		)
+		// the callback is not signed in, the user is known from the state of the login
+		audit.Record(c, schema.AuditEvent{
+			ActorID:    &user.ID,
+			ActorRole:  user.Role,
+			Action:     schema.AuditLinkIdentity,
+			TargetType: user.GetCollectionName(),
+			TargetID:   user.ID,
+			Detail:     identity.Provider,
+		})
+	}
+	c.Redirect(http.StatusFound, config.LoadEnv("FRONTEND")+settingsPath(user.Role)+"?"+query.Encode())
+}
+
+// settingsPath returns the frontend settings page of role.
+func settingsPath(role string) string {
+	if role == "company" {
+		return "/company/settings"
+	}
+	return "/app/settings"
+}
+
+// UnlinkProvider godoc
+// @Summary      Unlink an OAuth provider
+// @Description  Remove a provider from the authenticated user's logins. The last provider cannot be unlinked.
+// @Tags         Auth
+// @Produce      json
+// @Param        provider  path      string  true  "Provider"
+// @Success      200  {array}   schema.Identity
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /auth/identities/{provider} [delete]
+func UnlinkProvider(c *gin.Context) {
+	userID, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+	provider := strings.ToLower(c.Param("provider"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := repository.FindOne[schema.User](ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	remaining := make([]schema.Identity, 0, len(identities(user)))
+	for _, identity := range identities(user) {
+		if identity.Provider != provider {
+			remaining = append(remaining, identity)
+		}
+	}
+	if len(remaining) == len(identities(user)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Provider is not linked"})
+		return
+	}
+	if len(remaining) == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": errLastIdentity.Error()})
+		return
+	}
+
+	res, err := repository.UpdateOne[schema.User](ctx,
+		// the provider must still be linked, next to at least one other
+		bson.M{"_id": user.ID, "identities.provider": provider, "identities.1": bson.M{"$exists": true}},
+		bson.M{
+			"$pull": bson.M{"identities": bson.M{"provider": provider}},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		slog.Error("Unlink provider failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unlink provider failed"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": errLastIdentity.Error()})
+		return
+	}
+
+	slog.Info("Provider unlinked",
+		slog.String("userID", user.ID.Hex()),
+		slog.String("provider", provider),
+	)
+	audit.Record(c, schema.AuditEvent{
+		Action:     schema.AuditUnlinkIdentity,
+		TargetType: user.GetCollectionName(),
+		TargetID:   user.ID,
+		Detail:     provider,
+	})
+	c.JSON(http.StatusOK, remaining)
+}
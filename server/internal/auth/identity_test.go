@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/markbates/goth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailVerified(t *testing.T) {
+	tests := []struct {
+		name string
+		user goth.User
+		want bool
+	}{
+		{"google verified", goth.User{Provider: ProviderGoogle, Email: "a@example.com", RawData: map[string]any{"verified_email": true}}, true},
+		{"google unverified", goth.User{Provider: ProviderGoogle, Email: "a@example.com", RawData: map[string]any{"verified_email": false}}, false},
+		{"github", goth.User{Provider: ProviderGitHub, Email: "a@example.com"}, true},
+		{"oidc verified", goth.User{Provider: ProviderOIDC, Email: "a@example.com", RawData: map[string]any{"email_verified": true}}, true},
+		{"oidc without claim", goth.User{Provider: ProviderOIDC, Email: "a@example.com", RawData: map[string]any{}}, false},
+		{"microsoft", goth.User{Provider: ProviderMicrosoft, Email: "a@example.com"}, false},
+		{"no email", goth.User{Provider: ProviderGitHub}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, emailVerified(tt.user))
+		})
+	}
+}
+
+func TestIdentitiesOfLegacyUser(t *testing.T) {
+	legacy := schema.User{UserID: "1234", Provider: ProviderGoogle, Email: "a@example.com"}
+	assert.Equal(t, []schema.Identity{{Provider: ProviderGoogle, Subject: "1234", Email: "a@example.com"}}, identities(legacy))
+
+	linked := schema.User{
+		UserID:     "1234",
+		Provider:   ProviderGoogle,
+		Identities: []schema.Identity{{Provider: ProviderGitHub, Subject: "99"}},
+	}
+	assert.Equal(t, linked.Identities, identities(linked))
+
+	assert.Empty(t, identities(schema.User{}))
+}
+
+func TestHasVerifiedEmail(t *testing.T) {
+	verified := schema.User{
+		Email:      "a@example.com",
+		Identities: []schema.Identity{{Provider: ProviderGoogle, Email: "A@example.com", EmailVerified: true}},
+	}
+	assert.True(t, hasVerifiedEmail(verified))
+
+	unverified := schema.User{
+		Email:      "a@example.com",
+		Identities: []schema.Identity{{Provider: ProviderMicrosoft, Email: "a@example.com"}},
+	}
+	assert.False(t, hasVerifiedEmail(unverified))
+
+	// the verified email is not the one the user has now
+	changed := schema.User{
+		Email:      "b@example.com",
+		Identities: []schema.Identity{{Provider: ProviderGoogle, Email: "a@example.com", EmailVerified: true}},
+	}
+	assert.False(t, hasVerifiedEmail(changed))
+
+	legacy := schema.User{UserID: "1234", Provider: ProviderGoogle, Email: "a@example.com"}
+	assert.False(t, hasVerifiedEmail(legacy))
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns every key tokens of this server may be signed with.
+func (m *KeyManager) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for kid, key := range m.publicKeys() {
+		jwk := JWK{KeyID: kid, Use: "sig"}
+		switch key := key.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.Algorithm = "RS256"
+			jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Algorithm = "EdDSA"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(key)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KeyID < set.Keys[j].KeyID })
+	return set
+}
+
+// JWKS godoc
+// @Summary      Token verification keys
+// @Description  The public keys of every kid this server signs tokens with, so that other services can verify them.
+// @Tags         Auth
+// @Produce      json
+// @Success      200  {object}  JWKSet
+// @Router       /.well-known/jwks.json [get]
+func JWKS(c *gin.Context) {
+	// short enough that a newly added key is picked up before it starts signing
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, GetKeyManager().JWKS())
+}
@@ -2,49 +2,59 @@ package auth
 
 import (
 	"errors"
-	"os"
-	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Types of the tokens this server signs, in their "type" claim.
+const (
+	TokenAccess    = "access"
+	TokenRefresh   = "refresh"
+	TokenMagicLink = "magic_link"
+)
+
 // Claims struct for your JWT
 type Claims struct {
-	UserID string `json:"userID"`
-	Role   string `json:"role"`
-	Email  string `json:"email"`
-	Name   string `json:"name"`
+	UserID    string `json:"userID"`
+	Role      string `json:"role"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatarURL"`
+	Verified  bool   `json:"verified"`
+	Banned    bool   `json:"banned"`
+	// Type is one of TokenAccess, TokenRefresh or TokenMagicLink.
+	Type string `json:"type"`
+	// SessionID is the schema.Session which issued the token.
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-// ParseJWT unencrypts JWT token
-func ParseJWT(tokenStr string) (*Claims, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return nil, errors.New("JWT secret not set in environment")
+// ParseToken verifies a token signed by this server and returns its claims.
+// The token must be of tokenType, so that e.g. a refresh token cannot be used as an access token.
+func ParseToken(tokenStr string, tokenType string) (*Claims, error) {
+	claims, err := parseAnyToken(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	// access tokens issued before tokens had a type have none
+	if claims.Type != tokenType && !(tokenType == TokenAccess && claims.Type == "") {
+		return nil, errors.New("wrong token type")
 	}
+	return claims, nil
+}
 
+// parseAnyToken verifies a token signed by this server, whatever its type.
+func parseAnyToken(tokenStr string) (*Claims, error) {
 	claims := &Claims{}
-
-	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		// optionally check signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(secret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenStr, claims, GetKeyManager().keyFunc,
+		jwt.WithValidMethods([]string{"RS256", "EdDSA", "HS256"}),
+		jwt.WithExpirationRequired(),
+	)
 	if err != nil {
 		return nil, err
 	}
-
 	if !token.Valid {
 		return nil, errors.New("invalid token")
 	}
-
-	// optional: check expiration
-	if claims.ExpiresAt != nil && claims.ExpiresAt.Before(time.Now()) {
-		return nil, errors.New("token expired")
-	}
-
 	return claims, nil
 }
@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lnwdevelopers007/job-applier-3000/server/config"
+)
+
+// keyReloadInterval is how often RunKeyReloader picks up added and removed keys.
+const keyReloadInterval = time.Minute
+
+// minRSABits is the smallest RSA key we sign with.
+const minRSABits = 2048
+
+// KeyManager signs the tokens of this server and verifies tokens by their kid header.
+//
+// Keys are PEM files named <kid>.pem in one directory. Every key in it is accepted,
+// but only the one named by the signing kid signs. To rotate without logging anyone out:
+//  1. add the new key file on every server and wait for a reload,
+//  2. switch the signing kid to the new key,
+//  3. remove the old key file once the last refresh token it signed has expired.
+//
+// Private key files may be RSA (RS256) or Ed25519 (EdDSA). Public key files are
+// only used to verify, e.g. the old key once its private half was destroyed.
+type KeyManager struct {
+	dir        string
+	signingKID string
+
+	mu     sync.RWMutex
+	signer crypto.Signer
+	method jwt.SigningMethod
+	public map[string]crypto.PublicKey
+
+	// legacySecret verifies HS256 tokens without a kid, which were issued before
+	// asymmetric keys. It never signs. Unset JWT_SECRET once they have all expired.
+	legacySecret []byte
+}
+
+var (
+	keyManager     *KeyManager
+	keyManagerOnce sync.Once
+)
+
+// GetKeyManager returns the key manager of JWT_KEYS_DIR, signing with JWT_SIGNING_KEY_ID.
+// Without JWT_KEYS_DIR it signs with a random key, which only suits development:
+// every restart logs everyone out.
+func GetKeyManager() *KeyManager {
+	keyManagerOnce.Do(func() {
+		var err error
+		dir := config.LoadEnv("JWT_KEYS_DIR")
+		if dir == "" || dir == "false" {
+			slog.Warn("JWT_KEYS_DIR is not set, signing tokens with a temporary key")
+			keyManager, err = NewEphemeralKeyManager()
+		} else {
+			keyManager, err = NewKeyManager(dir, config.LoadEnv("JWT_SIGNING_KEY_ID"))
+		}
+		if err != nil {
+			log.Fatal("Could not load JWT keys: " + err.Error())
+		}
+		if secret := config.LoadEnv("JWT_SECRET"); secret != "" && secret != "false" {
+			keyManager.legacySecret = []byte(secret)
+		}
+	})
+	return keyManager
+}
+
+// NewKeyManager loads the keys in dir. The key named signingKID must be a private key.
+func NewKeyManager(dir, signingKID string) (*KeyManager, error) {
+	m := &KeyManager{dir: dir, signingKID: signingKID}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// NewEphemeralKeyManager signs with a new Ed25519 key which is never saved.
+func NewEphemeralKeyManager() (*KeyManager, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	kid := "ephemeral-" + time.Now().UTC().Format("20060102T150405")
+	return &KeyManager{
+		signingKID: kid,
+		signer:     priv,
+		method:     jwt.SigningMethodEdDSA,
+		public:     map[string]crypto.PublicKey{kid: pub},
+	}, nil
+}
+
+// Reload reads the key directory again. On error the keys loaded before are kept.
+func (m *KeyManager) Reload() error {
+	if m.dir == "" {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(m.dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	public := make(map[string]crypto.PublicKey, len(files))
+	var signer crypto.Signer
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		pub, priv, err := loadKeyFile(file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		public[kid] = pub
+		if kid == m.signingKID {
+			signer = priv
+		}
+	}
+	if signer == nil {
+		return fmt.Errorf("no private key %s.pem to sign with in %s", m.signingKID, m.dir)
+	}
+	method, err := signingMethod(signer.Public())
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.signer, m.method, m.public = signer, method, public
+	return nil
+}
+
+// RunKeyReloader reloads the keys of GetKeyManager until ctx is cancelled.
+func RunKeyReloader(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(keyReloadInterval):
+		}
+		if err := GetKeyManager().Reload(); err != nil {
+			slog.Error("Reload JWT keys: " + err.Error())
+		}
+	}
+}
+
+// Sign signs claims with the signing key, naming it in the kid header.
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	m.mu.RLock()
+	signer, method, kid := m.signer, m.method, m.signingKID
+	m.mu.RUnlock()
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	return token.SignedString(signer)
+}
+
+// keyFunc returns the key which must have signed token, for jwt.Parse.
+func (m *KeyManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok && m.legacySecret != nil {
+			return m.legacySecret, nil
+		}
+		return nil, errors.New("token has no kid")
+	}
+
+	m.mu.RLock()
+	key, ok := m.public[kid]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	// the algorithm must be the key's, or a public key could pass for an HMAC secret
+	method, err := signingMethod(key)
+	if err != nil || method.Alg() != token.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key, nil
+}
+
+// publicKeys returns a copy of the verification keys by kid.
+func (m *KeyManager) publicKeys() map[string]crypto.PublicKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := make(map[string]crypto.PublicKey, len(m.public))
+	for kid, key := range m.public {
+		keys[kid] = key
+	}
+	return keys
+}
+
+func signingMethod(key crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+}
+
+// loadKeyFile reads a PEM key. priv is nil for public keys.
+func loadKeyFile(file string) (pub crypto.PublicKey, priv crypto.Signer, err error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, errors.New("not a PEM file")
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if rsaKey, ok := key.(*rsa.PrivateKey); ok && rsaKey.N.BitLen() < minRSABits {
+		return nil, nil, fmt.Errorf("RSA keys must have at least %d bits", minRSABits)
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return &key.PublicKey, key, nil
+	case ed25519.PrivateKey:
+		return key.Public(), key, nil
+	case *rsa.PublicKey, ed25519.PublicKey:
+		return key, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported key type %T", key)
+	}
+}
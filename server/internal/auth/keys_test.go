@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeKey(t *testing.T, dir, kid string, key any) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0600))
+}
+
+func signedClaims() jwt.MapClaims {
+	return jwt.MapClaims{"userID": "42", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func verify(m *KeyManager, raw string) error {
+	_, err := jwt.Parse(raw, m.keyFunc, jwt.WithValidMethods([]string{"RS256", "EdDSA", "HS256"}))
+	return err
+}
+
+func TestKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+	writeKey(t, dir, "old", oldKey)
+
+	oldManager, err := NewKeyManager(dir, "old")
+	assert.NoError(t, err)
+	oldToken, err := oldManager.Sign(signedClaims())
+	assert.NoError(t, err)
+
+	// the new key is added next to the old one, and signs from now on
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	writeKey(t, dir, "new", newKey)
+	newManager, err := NewKeyManager(dir, "new")
+	assert.NoError(t, err)
+	newToken, err := newManager.Sign(signedClaims())
+	assert.NoError(t, err)
+
+	header, _, err := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "new", header.Header["kid"])
+	assert.Equal(t, "RS256", header.Header["alg"])
+
+	// tokens of both keys are accepted until the old key is removed
+	assert.NoError(t, verify(newManager, oldToken))
+	assert.NoError(t, verify(newManager, newToken))
+
+	assert.NoError(t, os.Remove(filepath.Join(dir, "old.pem")))
+	assert.NoError(t, newManager.Reload())
+	assert.Error(t, verify(newManager, oldToken))
+	assert.NoError(t, verify(newManager, newToken))
+}
+
+func TestKeyManagerRejectsForgedTokens(t *testing.T) {
+	dir := t.TempDir()
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	writeKey(t, dir, "rsa", key)
+	m, err := NewKeyManager(dir, "rsa")
+	assert.NoError(t, err)
+
+	// the public key used as an HMAC secret
+	pub, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, signedClaims())
+	forged.Header["kid"] = "rsa"
+	raw, err := forged.SignedString(pub)
+	assert.NoError(t, err)
+	assert.Error(t, verify(m, raw))
+
+	unknown := jwt.NewWithClaims(jwt.SigningMethodRS256, signedClaims())
+	unknown.Header["kid"] = "elsewhere"
+	raw, err = unknown.SignedString(key)
+	assert.NoError(t, err)
+	assert.Error(t, verify(m, raw))
+
+	// HS256 tokens without kid are only accepted with the legacy secret
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, signedClaims()).SignedString([]byte("secret"))
+	assert.NoError(t, err)
+	assert.Error(t, verify(m, legacy))
+	m.legacySecret = []byte("secret")
+	assert.NoError(t, verify(m, legacy))
+}
+
+func TestNewKeyManagerNeedsSigningKey(t *testing.T) {
+	dir := t.TempDir()
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	writeKey(t, dir, "only", key)
+
+	_, err := NewKeyManager(dir, "missing")
+	assert.Error(t, err)
+
+	small, _ := rsa.GenerateKey(rand.Reader, 1024)
+	writeKey(t, dir, "small", small)
+	_, err = NewKeyManager(dir, "only")
+	assert.Error(t, err)
+}
+
+func TestJWKS(t *testing.T) {
+	dir := t.TempDir()
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	writeKey(t, dir, "a-ed", edKey)
+	writeKey(t, dir, "b-rsa", rsaKey)
+	m, err := NewKeyManager(dir, "a-ed")
+	assert.NoError(t, err)
+
+	set := m.JWKS()
+	assert.Len(t, set.Keys, 2)
+	assert.Equal(t, JWK{KeyType: "OKP", KeyID: "a-ed", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519",
+		X: base64.RawURLEncoding.EncodeToString(edKey.Public().(ed25519.PublicKey))}, set.Keys[0])
+	assert.Equal(t, "RSA", set.Keys[1].KeyType)
+	assert.Equal(t, "RS256", set.Keys[1].Algorithm)
+	assert.Equal(t, "AQAB", set.Keys[1].E)
+	assert.NotEmpty(t, set.Keys[1].N)
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lnwdevelopers007/job-applier-3000/server/config"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/email"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// magicLinkAge is how long a login link works.
+	magicLinkAge = 15 * time.Minute
+	// magicLinkLimit links can be requested for one email per magicLinkWindow.
+	magicLinkLimit  = 3
+	magicLinkWindow = 15 * time.Minute
+)
+
+var errMagicLinkInvalid = errors.New("this login link is invalid, expired or was already used")
+
+type magicLinkRequest struct {
+	Email string `json:"email" binding:"required,email,max=255"`
+}
+
+// RequestMagicLink godoc
+// @Summary      Request a login link
+// @Description  Email a single-use login link to the user with this email. The response is the same whether or not the user exists.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      magicLinkRequest  true  "Email to send the link to"
+// @Success      202   {object}  map[string]string
+// @Failure      400   {object}  map[string]string
+// @Failure      429   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /auth/magic-link [post]
+func RequestMagicLink(c *gin.Context) {
+	var req magicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	address := strings.ToLower(strings.TrimSpace(req.Email))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	links := database.GetDatabase().Collection(schema.MagicLink{}.GetCollectionName())
+	recent, err := links.CountDocuments(ctx, bson.M{
+		"email":     address,
+		"createdAt": bson.M{"$gt": time.Now().Add(-magicLinkWindow)},
+	})
+	if err != nil {
+		slog.Error("Count magic links failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not send login link"})
+		return
+	}
+	if recent >= magicLinkLimit {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": fmt.Sprintf("too many login links requested, please try again in %d minutes", int(magicLinkWindow.Minutes())),
+		})
+		return
+	}
+
+	jti, err := newJTI()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not send login link"})
+		return
+	}
+	now := time.Now()
+	link := schema.MagicLink{
+		ID:        jti,
+		Email:     address,
+		IP:        c.ClientIP(),
+		CreatedAt: now,
+		ExpiresAt: now.Add(magicLinkAge),
+	}
+
+	user, err := findUserByEmail(ctx, address)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		slog.Error("Find user for magic link failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not send login link"})
+		return
+	}
+	// the link must not log the owner of the mailbox into an account which only claims their email
+	if err == nil && !user.IsDeleted() && hasVerifiedEmail(user) {
+		link.UserID = &user.ID
+	}
+	// unknown emails are recorded too, so that they are rate limited the same way
+	if _, err := repository.InsertOne(ctx, link); err != nil {
+		slog.Error("Save magic link failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not send login link"})
+		return
+	}
+
+	if link.UserID != nil {
+		msg, err := composeMagicLink(user, link)
+		if err != nil {
+			slog.Error("Compose magic link failed: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not send login link"})
+			return
+		}
+		// Sent right away instead of through the outbox: the link is a credential
+		// which should not sit in the database, and is worthless by the time a retry would send it.
+		// It is sent in the background, so that the response time does not tell whether the user exists.
+		go func() {
+			if err := email.Send(msg); err != nil {
+				slog.Error("Send magic link failed: " + err.Error())
+			}
+		}()
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If an account with this email exists, a login link was sent to it"})
+}
+
+// composeMagicLink signs the token of link and renders the email which carries it.
+func composeMagicLink(user schema.User, link schema.MagicLink) (email.Message, error) {
+	token, err := GetKeyManager().Sign(jwt.MapClaims{
+		"jti":   link.ID,
+		"type":  TokenMagicLink,
+		"email": link.Email,
+		"exp":   link.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return email.Message{}, err
+	}
+
+	verifyURL := config.LoadEnv("SERVER_URL") + "/auth/magic-link/verify?token=" + url.QueryEscape(token)
+	return email.Compose(user.Email, user.Locale, email.MagicLink{
+		Name:         user.Name,
+		URL:          verifyURL,
+		ValidMinutes: int(magicLinkAge.Minutes()),
+	})
+}
+
+// VerifyMagicLink godoc
+// @Summary      Log in with a login link
+// @Description  Log in with the token of a link sent by /auth/magic-link, then redirect to the frontend like the OAuth callback. Every link works once.
+// @Tags         Auth
+// @Param        token  query  string  true  "Login link token"
+// @Success      302
+// @Router       /auth/magic-link/verify [get]
+func VerifyMagicLink(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := consumeMagicLink(ctx, c.Query("token"))
+	if err != nil {
+		if !errors.Is(err, errMagicLinkInvalid) {
+			slog.Error("Verify magic link failed: " + err.Error())
+			err = errors.New("could not log in, please try again")
+		}
+		c.Redirect(http.StatusFound, config.LoadEnv("FRONTEND")+"/login?error="+url.QueryEscape(err.Error()))
+		return
+	}
+
+	completeLogin(c, user, false, "magic-link")
+}
+
+// consumeMagicLink checks the token of a login link and marks it used.
+// It returns the user the link logs in.
+func consumeMagicLink(ctx context.Context, raw string) (schema.User, error) {
+	// its type keeps a login link from passing for an access or refresh token
+	claims, err := ParseToken(raw, TokenMagicLink)
+	if err != nil {
+		return schema.User{}, errMagicLinkInvalid
+	}
+	jti := claims.ID
+
+	// marking it used in the same step as reading it, so that two requests cannot both use it
+	now := time.Now()
+	link, err := repository.FindOneAndUpdate[schema.MagicLink](ctx,
+		bson.M{"_id": jti, "usedAt": nil, "expiresAt": bson.M{"$gt": now}},
+		bson.M{"$set": bson.M{"usedAt": now}},
+	)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		slog.Warn("Rejected used or unknown magic link", slog.String("jti", jti))
+		return schema.User{}, errMagicLinkInvalid
+	}
+	if err != nil {
+		return schema.User{}, err
+	}
+	if link.UserID == nil {
+		return schema.User{}, errMagicLinkInvalid
+	}
+
+	user, err := repository.FindOne[schema.User](ctx, *link.UserID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return user, errMagicLinkInvalid
+	}
+	return user, err
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"log/slog"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/config"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/azureadv2"
+	"github.com/markbates/goth/providers/github"
+	"github.com/markbates/goth/providers/google"
+	"github.com/markbates/goth/providers/openidConnect"
+)
+
+// Names of the OAuth providers, as used in /auth/:provider routes and schema.Identity.
+const (
+	ProviderGoogle    = "google"
+	ProviderGitHub    = "github"
+	ProviderMicrosoft = "microsoft"
+	ProviderOIDC      = "oidc"
+)
+
+// newProviders returns Google, and every other provider whose client ID is set in the env.
+func newProviders() []goth.Provider {
+	providers := []goth.Provider{
+		google.New(
+			config.LoadEnv("CLIENT_ID"),
+			config.LoadEnv("CLIENT_SECRET"),
+			config.LoadCallbackURI("http", ProviderGoogle),
+			"email",
+			"profile",
+		),
+	}
+
+	if clientID := providerEnv("GITHUB_CLIENT_ID"); clientID != "" {
+		gh := github.New(
+			clientID,
+			config.LoadEnv("GITHUB_CLIENT_SECRET"),
+			config.LoadCallbackURI("http", ProviderGitHub),
+			"read:user",
+			"user:email",
+		)
+		gh.SetName(ProviderGitHub)
+		providers = append(providers, gh)
+	}
+
+	if clientID := providerEnv("MICROSOFT_CLIENT_ID"); clientID != "" {
+		tenant := providerEnv("MICROSOFT_TENANT")
+		if tenant == "" {
+			tenant = string(azureadv2.CommonTenant)
+		}
+		ms := azureadv2.New(
+			clientID,
+			config.LoadEnv("MICROSOFT_CLIENT_SECRET"),
+			config.LoadCallbackURI("http", ProviderMicrosoft),
+			azureadv2.ProviderOptions{Tenant: azureadv2.TenantType(tenant)},
+		)
+		ms.SetName(ProviderMicrosoft)
+		providers = append(providers, ms)
+	}
+
+	if clientID := providerEnv("OIDC_CLIENT_ID"); clientID != "" {
+		// the discovery document is fetched now, so a provider which is down
+		// only disables its own login, not the whole server
+		oidc, err := openidConnect.New(
+			clientID,
+			config.LoadEnv("OIDC_CLIENT_SECRET"),
+			config.LoadCallbackURI("http", ProviderOIDC),
+			config.LoadEnv("OIDC_DISCOVERY_URL"),
+			"openid",
+			"email",
+			"profile",
+		)
+		if err != nil {
+			slog.Error("OpenID Connect login disabled: " + err.Error())
+		} else {
+			oidc.SetName(ProviderOIDC)
+			providers = append(providers, oidc)
+		}
+	}
+
+	return providers
+}
+
+// providerEnv returns an optional env, which is unset when it is empty or "false".
+func providerEnv(env string) string {
+	value := config.LoadEnv(env)
+	if value == "false" {
+		return ""
+	}
+	return value
+}
+
+// emailVerified reports whether the provider vouches that the user owns their email.
+// Only verified emails are used to link a login to an existing account.
+func emailVerified(user goth.User) bool {
+	if user.Email == "" {
+		return false
+	}
+	switch user.Provider {
+	case ProviderGoogle:
+		verified, _ := user.RawData["verified_email"].(bool)
+		return verified
+	case ProviderGitHub:
+		// GitHub only gives out emails the user has verified
+		return true
+	case ProviderOIDC:
+		verified, _ := user.RawData["email_verified"].(bool)
+		return verified
+	default:
+		// e.g. Azure AD lets tenant admins set any email on their users
+		return false
+	}
+}
@@ -2,20 +2,22 @@ package auth
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/dto"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// RefreshRefreshToken refreshes the refresh token
+// RefreshRefreshToken exchanges the refresh token for a new access token and a new refresh token.
+// The old refresh token stops working: using it again revokes the whole session.
 // SECURITY FIX: Now checks fresh ban status from database
 func RefreshRefreshToken(c *gin.Context) {
 	refreshToken, err := c.Cookie("refresh_token")
@@ -24,49 +26,38 @@ func RefreshRefreshToken(c *gin.Context) {
 		return
 	}
 
-	// Check if refresh token is blacklisted
-	if IsBlacklisted(refreshToken) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token has been revoked"})
-		return
-	}
-
-	token, err := jwt.Parse(refreshToken, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return jwtSecret, nil
-	})
-	if err != nil || !token.Valid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
-		return
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || claims["type"] != "refresh" || !token.Valid {
+	claims, err := ParseToken(refreshToken, TokenRefresh)
+	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
 		return
 	}
 
-	userID, ok := claims["userID"].(string)
-	if !ok {
+	// Issue new access token
+	oid, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid userID"})
 		return
 	}
 
-	// Issue new access token
-	oid, err := primitive.ObjectIDFromHex(userID)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// Check if refresh token was revoked, e.g. by logging out on another server
+	revoked, err := GetRevocationStore().IsRevoked(ctx, TokenID(claims.ID, refreshToken))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid userID"})
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "could not check refresh token"})
+		return
+	}
+	if revoked {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token has been revoked"})
 		return
 	}
 
 	// SECURITY: Check fresh ban status from database
 	db := database.GetDatabase()
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
 
 	var dbUser schema.User
-	err = db.Collection("users").FindOne(ctx, bson.M{"_id": oid}).Decode(&dbUser)
+	err = db.Collection("users").FindOne(ctx, bson.M{"_id": oid, "deletedAt": nil}).Decode(&dbUser)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
 		return
@@ -92,11 +83,44 @@ func RefreshRefreshToken(c *gin.Context) {
 		Banned:    dbUser.Banned,
 	}
 
-	accessToken, _, err := generateTokens(refreshTokenUser)
+	tokens, err := refreshSession(ctx, c, claims, refreshToken, refreshTokenUser)
+	if errors.Is(err, errSessionEnded) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token has been revoked"})
+		return
+	}
 	if err != nil {
+		slog.Error("could not refresh session: " + err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"access_token": accessToken})
+	// the frontend server refreshes on behalf of the browser, so it needs both tokens to pass on.
+	// A refresh which raced another one gets no refresh token, it must keep the one of the other.
+	res := gin.H{"access_token": tokens.Access}
+	if tokens.Refresh != "" {
+		res["refresh_token"] = tokens.Refresh
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// refreshSession rotates the session of a valid refresh token.
+func refreshSession(ctx context.Context, c *gin.Context, claims *Claims, refreshToken string, user dto.RefreshTokenUser) (tokenPair, error) {
+	if claims.SessionID == "" {
+		// Refresh tokens issued before sessions existed get a session now,
+		// and cannot be used again.
+		if err := GetRevocationStore().Revoke(ctx, TokenID("", refreshToken), claims.ExpiresAt.Time); err != nil {
+			return tokenPair{}, err
+		}
+		return startSession(ctx, c, user)
+	}
+
+	sessionID, err := primitive.ObjectIDFromHex(claims.SessionID)
+	if err != nil {
+		return tokenPair{}, errSessionEnded
+	}
+	session, err := repository.FindOne[schema.Session](ctx, sessionID)
+	if err != nil || session.UserID != user.ID {
+		return tokenPair{}, errSessionEnded
+	}
+	return rotateSession(ctx, c, session, claims.ID, user)
 }
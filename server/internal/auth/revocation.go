@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+)
+
+// RevocationStore remembers revoked tokens by their jti until they expire.
+type RevocationStore interface {
+	// Revoke rejects the token with jti from now until expiresAt.
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	// IsRevoked reports whether the token with jti was revoked.
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+var (
+	revocationStore RevocationStore
+	revocationOnce  sync.Once
+)
+
+// GetRevocationStore returns the store shared by every server instance,
+// with a cache of this instance's known revocations in front of it.
+func GetRevocationStore() RevocationStore {
+	revocationOnce.Do(func() {
+		revocationStore = NewCachedRevocationStore(NewMongoRevocationStore(database.GetDatabase()))
+	})
+	return revocationStore
+}
+
+// TokenID returns the key under which a token is revoked: its jti.
+// Tokens issued before we added jti are keyed by a hash of the whole token instead.
+func TokenID(jti, rawToken string) string {
+	if jti != "" {
+		return jti
+	}
+	sum := sha256.Sum256([]byte(rawToken))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// SessionTokenID returns the key under which all access tokens of a session are revoked at once.
+// A session ID cannot clash with a jti, which is hex without a prefix.
+func SessionTokenID(sessionID string) string {
+	return "session:" + sessionID
+}
+
+// RevokeToken revokes a raw token until it expires.
+// Tokens which cannot be parsed are not accepted anyway, so they are ignored.
+func RevokeToken(ctx context.Context, rawToken string) error {
+	claims, err := parseAnyToken(rawToken)
+	if err != nil || claims.ExpiresAt == nil {
+		return nil
+	}
+	return GetRevocationStore().Revoke(ctx, TokenID(claims.ID, rawToken), claims.ExpiresAt.Time)
+}
+
+func newJTI() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
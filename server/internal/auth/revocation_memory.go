@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRevocationStore keeps revocations in this process only.
+type MemoryRevocationStore struct {
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+}
+
+// NewMemoryRevocationStore returns an empty store which forgets tokens once they expire.
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	s := &MemoryRevocationStore{revoked: make(map[string]time.Time)}
+	go s.cleanupExpired(5 * time.Minute)
+	return s
+}
+
+func (s *MemoryRevocationStore) Revoke(_ context.Context, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[jti] = expiresAt
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsRevoked(_ context.Context, jti string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	expiresAt, exists := s.revoked[jti]
+	// an expired token is rejected anyway, no need to block it
+	return exists && time.Now().Before(expiresAt), nil
+}
+
+func (s *MemoryRevocationStore) cleanupExpired(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		now := time.Now()
+		for jti, expiresAt := range s.revoked {
+			if now.After(expiresAt) {
+				delete(s.revoked, jti)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// CachedRevocationStore answers from memory for tokens it already knows are revoked
+// and asks the shared store about the rest.
+// Only revocations are cached: a token which is valid now may be revoked by another instance.
+type CachedRevocationStore struct {
+	cache  *MemoryRevocationStore
+	shared RevocationStore
+}
+
+func NewCachedRevocationStore(shared RevocationStore) CachedRevocationStore {
+	return CachedRevocationStore{cache: NewMemoryRevocationStore(), shared: shared}
+}
+
+func (s CachedRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	if err := s.shared.Revoke(ctx, jti, expiresAt); err != nil {
+		return err
+	}
+	return s.cache.Revoke(ctx, jti, expiresAt)
+}
+
+func (s CachedRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	if revoked, _ := s.cache.IsRevoked(ctx, jti); revoked {
+		return true, nil
+	}
+	return s.shared.IsRevoked(ctx, jti)
+}
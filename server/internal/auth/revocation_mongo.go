@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoRevocationStore keeps revocations in the revoked_tokens collection,
+// which a TTL index on expiresAt keeps from growing forever.
+type MongoRevocationStore struct {
+	collection *mongo.Collection
+}
+
+func NewMongoRevocationStore(db *mongo.Database) MongoRevocationStore {
+	return MongoRevocationStore{collection: db.Collection(schema.RevokedToken{}.GetCollectionName())}
+}
+
+func (s MongoRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := s.collection.UpdateOne(ctx,
+		bson.M{"_id": jti},
+		bson.M{"$set": bson.M{"expiresAt": expiresAt, "revokedAt": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (s MongoRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked schema.RevokedToken
+	err := s.collection.FindOne(ctx, bson.M{"_id": jti}).Decode(&revoked)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	// the TTL monitor only runs every minute
+	return time.Now().Before(revoked.ExpiresAt), nil
+}
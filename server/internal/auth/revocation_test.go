@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingStore is a shared store which counts how often it is asked.
+type countingStore struct {
+	*MemoryRevocationStore
+	lookups int
+}
+
+func (s *countingStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	s.lookups++
+	return s.MemoryRevocationStore.IsRevoked(ctx, jti)
+}
+
+func TestMemoryRevocationStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryRevocationStore()
+
+	assert.NoError(t, store.Revoke(ctx, "revoked", time.Now().Add(time.Hour)))
+	assert.NoError(t, store.Revoke(ctx, "expired", time.Now().Add(-time.Second)))
+
+	revoked, _ := store.IsRevoked(ctx, "revoked")
+	assert.True(t, revoked)
+	revoked, _ = store.IsRevoked(ctx, "expired")
+	assert.False(t, revoked, "an expired token does not need to be blocked")
+	revoked, _ = store.IsRevoked(ctx, "unknown")
+	assert.False(t, revoked)
+}
+
+func TestCachedRevocationStore(t *testing.T) {
+	ctx := context.Background()
+	shared := &countingStore{MemoryRevocationStore: NewMemoryRevocationStore()}
+	store := NewCachedRevocationStore(shared)
+
+	// revoked here: answered from the cache
+	assert.NoError(t, store.Revoke(ctx, "local", time.Now().Add(time.Hour)))
+	revoked, err := store.IsRevoked(ctx, "local")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	assert.Equal(t, 0, shared.lookups)
+
+	// revoked by another instance: only the shared store knows
+	assert.NoError(t, shared.Revoke(ctx, "remote", time.Now().Add(time.Hour)))
+	revoked, err = store.IsRevoked(ctx, "remote")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	assert.Equal(t, 1, shared.lookups)
+
+	revoked, err = store.IsRevoked(ctx, "valid")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestTokenID(t *testing.T) {
+	assert.Equal(t, "abc", TokenID("abc", "header.payload.signature"))
+
+	legacy := TokenID("", "header.payload.signature")
+	assert.Contains(t, legacy, "sha256:")
+	assert.NotContains(t, legacy, "payload", "the raw token must not be stored")
+	assert.Equal(t, legacy, TokenID("", "header.payload.signature"))
+	assert.NotEqual(t, legacy, TokenID("", "other.payload.signature"))
+}
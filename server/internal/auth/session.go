@@ -0,0 +1,297 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/config"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/audit"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/dto"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// refreshGracePeriod is how long after a rotation the refresh token it replaced is still answered.
+// Pages which load several things at once send the same expired token in parallel,
+// and all but the first of those refreshes would otherwise look like a reuse.
+const refreshGracePeriod = 30 * time.Second
+
+// errSessionEnded is returned when a refresh token belongs to a revoked session,
+// or is not the latest refresh token of its session.
+var errSessionEnded = errors.New("session has ended")
+
+// startSession records a new login of user from the device that sent c,
+// and sets the auth cookies of its first tokens.
+func startSession(ctx context.Context, c *gin.Context, user dto.RefreshTokenUser) (tokenPair, error) {
+	sessionID := primitive.NewObjectID()
+	tokens, err := generateTokens(user, sessionID.Hex())
+	if err != nil {
+		return tokens, err
+	}
+
+	now := time.Now()
+	session := schema.Session{
+		ID:              sessionID,
+		UserID:          user.ID,
+		UserAgent:       c.Request.UserAgent(),
+		IP:              c.ClientIP(),
+		CreatedAt:       now,
+		LastUsedAt:      now,
+		ExpiresAt:       tokens.RefreshExpiry,
+		RefreshJTI:      tokens.RefreshJTI,
+		AccessJTI:       tokens.AccessJTI,
+		AccessExpiresAt: tokens.AccessExpiry,
+	}
+	if _, err := repository.InsertOne(ctx, session); err != nil {
+		return tokens, err
+	}
+
+	setAuthCookies(c, tokens)
+	return tokens, nil
+}
+
+// rotateSession replaces the refresh token refreshJTI of a session with new tokens.
+// A refresh token which is not the latest one of its session was stolen or replayed,
+// so the whole session is revoked and errSessionEnded returned,
+// unless it was replaced less than refreshGracePeriod ago, see graceTokens.
+func rotateSession(ctx context.Context, c *gin.Context, session schema.Session, refreshJTI string, user dto.RefreshTokenUser) (tokenPair, error) {
+	if session.RevokedAt != nil {
+		return tokenPair{}, errSessionEnded
+	}
+	if inGracePeriod(session, refreshJTI) {
+		return graceTokens(c, session, user)
+	}
+	if refreshJTI != session.RefreshJTI {
+		slog.Warn("Refresh token reuse detected, revoking session",
+			slog.String("sessionID", session.ID.Hex()),
+			slog.String("userID", session.UserID.Hex()),
+			slog.String("ip", c.ClientIP()),
+		)
+		if err := revokeSession(ctx, session.ID); err != nil {
+			return tokenPair{}, err
+		}
+		return tokenPair{}, errSessionEnded
+	}
+
+	tokens, err := generateTokens(user, session.ID.Hex())
+	if err != nil {
+		return tokens, err
+	}
+	// only rotate if nobody rotated since we read the session
+	now := time.Now()
+	res, err := repository.UpdateOne[schema.Session](ctx,
+		bson.M{"_id": session.ID, "refreshJTI": refreshJTI, "revokedAt": nil},
+		bson.M{"$set": bson.M{
+			"refreshJTI":         tokens.RefreshJTI,
+			"previousRefreshJTI": refreshJTI,
+			"rotatedAt":          now,
+			"accessJTI":          tokens.AccessJTI,
+			"accessExpiresAt":    tokens.AccessExpiry,
+			"expiresAt":          tokens.RefreshExpiry,
+			"lastUsedAt":         now,
+			"userAgent":          c.Request.UserAgent(),
+			"ip":                 c.ClientIP(),
+		}},
+	)
+	if err != nil {
+		return tokens, err
+	}
+	if res.MatchedCount == 0 {
+		// a parallel request with the same refresh token rotated the session since we read it
+		latest, err := repository.FindOne[schema.Session](ctx, session.ID)
+		if err == nil && latest.RevokedAt == nil && inGracePeriod(latest, refreshJTI) {
+			return graceTokens(c, latest, user)
+		}
+		if err := revokeSession(ctx, session.ID); err != nil {
+			return tokenPair{}, err
+		}
+		return tokenPair{}, errSessionEnded
+	}
+
+	setAuthCookies(c, tokens)
+	return tokens, nil
+}
+
+// inGracePeriod reports whether refreshJTI is the refresh token which session replaced
+// less than refreshGracePeriod ago.
+func inGracePeriod(session schema.Session, refreshJTI string) bool {
+	return refreshJTI != "" &&
+		refreshJTI == session.PreviousRefreshJTI &&
+		session.RotatedAt != nil &&
+		time.Since(*session.RotatedAt) < refreshGracePeriod
+}
+
+// graceTokens answers a refresh which raced the one that rotated session with the access token of that rotation,
+// signed again with the same jti and expiry so that revoking the session revokes it as well.
+// It gets no refresh token: the one handed out by the rotation stays the only one which works.
+func graceTokens(c *gin.Context, session schema.Session, user dto.RefreshTokenUser) (tokenPair, error) {
+	tokens := tokenPair{AccessJTI: session.AccessJTI, AccessExpiry: session.AccessExpiresAt}
+	var err error
+	tokens.Access, err = signAccessToken(user, session.ID.Hex(), tokens.AccessJTI, tokens.AccessExpiry)
+	if err != nil {
+		return tokens, err
+	}
+	setAccessCookie(c, tokens)
+	return tokens, nil
+}
+
+// revokeSession ends a session and revokes every access token it issued, see SessionTokenID.
+// They expire by the expiry of the latest one, which is read along with the revocation,
+// as a rotation may have issued a newer one since the caller read the session.
+func revokeSession(ctx context.Context, sessionID primitive.ObjectID) error {
+	latest, err := repository.FindOneAndUpdate[schema.Session](ctx,
+		bson.M{"_id": sessionID},
+		bson.M{"$set": bson.M{"revokedAt": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	// sessions started before their access expiry was recorded
+	expiresAt := latest.AccessExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = time.Now().Add(accessTokenAge)
+	}
+	return GetRevocationStore().Revoke(ctx, SessionTokenID(latest.ID.Hex()), expiresAt)
+}
+
+// endSessionOfToken revokes the session which issued rawToken, if any.
+func endSessionOfToken(ctx context.Context, rawToken string) error {
+	claims, err := ParseToken(rawToken, TokenRefresh)
+	if err != nil {
+		return nil
+	}
+	sessionID, err := primitive.ObjectIDFromHex(claims.SessionID)
+	if err != nil {
+		return nil
+	}
+	session, err := repository.FindOne[schema.Session](ctx, sessionID)
+	if err != nil {
+		return nil
+	}
+	return revokeSession(ctx, session.ID)
+}
+
+func setAuthCookies(c *gin.Context, tokens tokenPair) {
+	refreshTokenAge := config.LoadInt("REFRESH_TOKEN_AGE_DAYS") * 24 * 3600
+	// Set refresh token as HttpOnly cookie
+	c.SetCookie(
+		"refresh_token",
+		tokens.Refresh,
+		refreshTokenAge,
+		"/", "",
+		false,
+		true,
+	)
+	setAccessCookie(c, tokens)
+}
+
+func setAccessCookie(c *gin.Context, tokens tokenPair) {
+	// Set access token as HttpOnly cookie (more secure than localStorage)
+	// Access tokens typically have shorter lifespan (e.g., 1 hour = 3600 seconds)
+	c.SetCookie(
+		"access_token",
+		tokens.Access,
+		3600, // 1 hour
+		"/", "",
+		false,
+		true,
+	)
+}
+
+// ListSessions godoc
+// @Summary      List my sessions
+// @Description  List the devices the authenticated user is logged in on. The session of this request has current set.
+// @Tags         Auth
+// @Produce      json
+// @Success      200  {array}   dto.Session
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /auth/sessions [get]
+func ListSessions(c *gin.Context) {
+	userID, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sessions, err := repository.FindAll[schema.Session](ctx, bson.M{
+		"userID":    userID,
+		"revokedAt": nil,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Retrieve Sessions failed"})
+		return
+	}
+
+	current := c.GetString("sessionID")
+	res := make([]dto.Session, 0, len(sessions))
+	for _, session := range sessions {
+		res = append(res, dto.Session{Session: session, Current: session.ID.Hex() == current})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// DeleteSession godoc
+// @Summary      Log out a session
+// @Description  End one of the authenticated user's sessions. Its tokens stop working immediately.
+// @Tags         Auth
+// @Produce      json
+// @Param        id   path      string  true  "Session ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /auth/sessions/{id} [delete]
+func DeleteSession(c *gin.Context) {
+	userID, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+	sessionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := repository.FindOne[schema.Session](ctx, sessionID)
+	// someone else's session is reported as missing, to not leak that it exists
+	if err != nil || session.UserID != userID || session.RevokedAt != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	if err := revokeSession(ctx, session.ID); err != nil {
+		slog.Error("Delete Session failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Delete Session failed"})
+		return
+	}
+
+	slog.Info("Session revoked",
+		slog.String("sessionID", session.ID.Hex()),
+		slog.String("userID", userID.Hex()),
+	)
+	audit.Record(c, schema.AuditEvent{Action: schema.AuditRevokeSession, TargetType: session.GetCollectionName(), TargetID: session.ID})
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// sessionUser returns the user set by AuthMiddleware, or responds 401.
+func sessionUser(c *gin.Context) (primitive.ObjectID, bool) {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return userID, false
+	}
+	return userID, true
+}
@@ -2,7 +2,9 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"slices"
 	"time"
@@ -12,8 +14,8 @@ import (
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
 	"github.com/markbates/goth"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func getAllowedRoles() []string {
@@ -33,65 +35,98 @@ func addProvider(c *gin.Context) {
 	c.Request.URL.RawQuery = q.Encode()
 }
 
-// upsertUser update or insert user into the database.
+// upsertUser finds the user of an OAuth login, or registers them with role.
 // "role" can be valid role and login.
-// Due to me being too lazy to catch every edge cases,
+// A login from a provider which is not linked yet is linked to the user with the same email,
+// when both the provider and one of the user's identities verified it.
 func upsertUser(gUser goth.User, role string) (dbUser schema.User, isNewUser bool, err error) {
 	db := database.GetDatabase()
 	usersCollection := db.Collection("users")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{"userID": gUser.UserID}
-
-	var existingUser schema.User
-	err = usersCollection.FindOne(ctx, filter).Decode(&existingUser)
+	identity := newIdentity(gUser)
+	existingUser, err := findUserByIdentity(ctx, identity.Provider, identity.Subject)
 	// If there's error when querying user
 	if err != nil && err != mongo.ErrNoDocuments {
 		return existingUser, false, fmt.Errorf("failed to query user: %w", err)
 	}
+	if err == nil && existingUser.IsDeleted() {
+		return existingUser, false, errUserDeleted
+	}
+	if err == nil {
+		update := bson.M{"$set": bson.M{
+			"avatarURL": gUser.AvatarURL,
+			"updatedAt": time.Now(),
+		}}
+		if len(existingUser.Identities) == 0 {
+			// users registered before identities existed get theirs on their next login
+			update["$push"] = bson.M{"identities": identity}
+		}
+		if _, err := usersCollection.UpdateOne(ctx, bson.M{"_id": existingUser.ID}, update); err != nil {
+			return existingUser, false, fmt.Errorf("failed to update user: %w", err)
+		}
+		return existingUser, false, nil
+	}
+
+	if gUser.Email != "" {
+		existingUser, err = findUserByEmail(ctx, gUser.Email)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return existingUser, false, fmt.Errorf("failed to query user: %w", err)
+		}
+		if err == nil && existingUser.IsDeleted() {
+			return existingUser, false, errUserDeleted
+		}
+		if err == nil {
+			if !identity.EmailVerified || !hasVerifiedEmail(existingUser) {
+				// anyone could claim this email at a provider which does not verify it,
+				// so the login or the account may belong to someone else than the mailbox
+				return existingUser, true, errors.New("an account with this email already exists, log in and link this provider from your settings")
+			}
+			if err := linkIdentity(ctx, existingUser, identity); err != nil {
+				return existingUser, false, err
+			}
+			slog.Info("Linked provider by verified email",
+				slog.String("userID", existingUser.ID.Hex()),
+				slog.String("provider", identity.Provider),
+			)
+			return existingUser, false, nil
+		}
+	}
 
 	// If user tries to log in BUT the user does not exist in the database.
-	if role == "login" && err == mongo.ErrNoDocuments {
+	if role == "login" {
 		return existingUser, true, fmt.Errorf("please register first before using our service")
 	}
 
 	// If user tries to register (role != login) but the role is invalid
-	if role != "login" && !slices.Contains(getAllowedRoles(), role) {
+	if !slices.Contains(getAllowedRoles(), role) {
 		return existingUser, false, fmt.Errorf("role is not valid")
 	}
 
-	// notice that role is setOnInsert meaning that there's no way a user will have role "login".
-	update := bson.M{
-		"$set": bson.M{
-			"provider":  gUser.Provider,
-			"email":     gUser.Email,
-			"avatarURL": gUser.AvatarURL,
-			"updatedAt": time.Now(),
-		},
-		"$setOnInsert": bson.M{
-			"createdAt": time.Now(),
-			"name":      gUser.Name,
-			"role":      role, // unchanged behavior
-			"verified":  false,
-		},
+	// an email no provider vouched for could be anyone's, so it is only kept on the identity
+	address := ""
+	if identity.EmailVerified {
+		address = gUser.Email
 	}
-
-	opts := options.Update().SetUpsert(true)
-
-	res, err := usersCollection.UpdateOne(ctx, filter, update, opts)
-	if err != nil {
-		return existingUser, false, fmt.Errorf("failed to upsert user: %w", err)
+	now := time.Now()
+	newUser := schema.User{
+		UserID:     gUser.UserID,
+		Provider:   gUser.Provider,
+		Email:      address,
+		Name:       gUser.Name,
+		AvatarURL:  gUser.AvatarURL,
+		Role:       role,
+		Verified:   false,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Identities: []schema.Identity{identity},
 	}
-
-	// check whether upsert operation went successfully
-	if res.UpsertedID != nil {
-		isNewUser = true
-		err = usersCollection.FindOne(ctx, bson.M{"_id": res.UpsertedID}).Decode(&existingUser)
-		if err != nil {
-			return existingUser, isNewUser, fmt.Errorf("failed to reload new user: %w", err)
-		}
+	res, err := usersCollection.InsertOne(ctx, newUser)
+	if err != nil {
+		return newUser, true, fmt.Errorf("failed to insert user: %w", err)
 	}
+	newUser.ID = res.InsertedID.(primitive.ObjectID)
 
-	return existingUser, isNewUser, nil
+	return newUser, true, nil
 }
@@ -0,0 +1,168 @@
+// Package authz decides who may act on which resource.
+// Every type of resource has a ResourcePolicy, which loads a resource and tells
+// whether a user may read, update or delete it. Policies only see the resource and the user,
+// so that they can be tested without a database or HTTP.
+package authz
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Kind is a type of resource with a policy.
+type Kind string
+
+const (
+	Job          Kind = "job"
+	Application  Kind = "application"
+	Note         Kind = "note"
+	File         Kind = "file"
+	User         Kind = "user"
+	SavedSearch  Kind = "savedSearch"
+	Organization Kind = "organization"
+	Cohort       Kind = "cohort"
+	Resume       Kind = "resume"
+)
+
+// Action is what a user wants to do with a resource.
+type Action string
+
+const (
+	Read   Action = "read"
+	Update Action = "update"
+	Delete Action = "delete"
+)
+
+// ActionOf returns the action of a request with method.
+func ActionOf(method string) Action {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return Read
+	case http.MethodDelete:
+		return Delete
+	default:
+		return Update
+	}
+}
+
+// Subject is the user acting on a resource.
+type Subject struct {
+	ID   primitive.ObjectID
+	Role string
+}
+
+var (
+	ErrNotFound  = errors.New("resource not found")
+	ErrForbidden = errors.New("you do not own this resource")
+)
+
+// Finder loads a resource by ID, like repository.FindOne.
+type Finder[T any] func(ctx context.Context, id primitive.ObjectID) (T, error)
+
+// ResourcePolicy decides who may act on resources of type T.
+type ResourcePolicy[T any] interface {
+	// Load returns the resource with id, together with what Authorize needs to know about it.
+	Load(ctx context.Context, id primitive.ObjectID) (T, error)
+	// Authorize returns ErrForbidden unless subject may do action to resource.
+	// Admins may do everything, and never reach Authorize.
+	Authorize(subject Subject, action Action, resource T) error
+}
+
+// check loads a resource and authorizes an action on it.
+type check func(ctx context.Context, id primitive.ObjectID, subject Subject, action Action) (any, error)
+
+var (
+	policiesMu sync.RWMutex
+	policies   = map[Kind]check{}
+)
+
+// Register sets the policy of resources of kind, replacing any earlier one.
+func Register[T any](kind Kind, policy ResourcePolicy[T]) {
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	policies[kind] = func(ctx context.Context, id primitive.ObjectID, subject Subject, action Action) (any, error) {
+		resource, err := policy.Load(ctx, id)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if subject.Role == "admin" {
+			return resource, nil
+		}
+		return resource, policy.Authorize(subject, action, resource)
+	}
+}
+
+// Registered tells whether resources of kind have a policy.
+func Registered(kind Kind) bool {
+	policiesMu.RLock()
+	defer policiesMu.RUnlock()
+	return policies[kind] != nil
+}
+
+// cached is a resource loaded for the current request.
+type cached struct {
+	id       primitive.ObjectID
+	resource any
+}
+
+func contextKey(kind Kind) string {
+	return "authz." + string(kind)
+}
+
+// Check loads the resource of kind with id and returns ErrForbidden unless subject may do action to it.
+// The resource is cached on c, so that the handler can get it with Get instead of loading it again.
+func Check(ctx context.Context, c *gin.Context, kind Kind, id primitive.ObjectID, subject Subject, action Action) error {
+	policiesMu.RLock()
+	check := policies[kind]
+	policiesMu.RUnlock()
+	if check == nil {
+		return errors.New("no policy for " + string(kind))
+	}
+
+	resource, err := check(ctx, id, subject, action)
+	if resource != nil {
+		c.Set(contextKey(kind), cached{id: id, resource: resource})
+	}
+	return err
+}
+
+// Get returns the resource of kind with id checked earlier in the request.
+func Get[T any](c *gin.Context, kind Kind, id primitive.ObjectID) (T, bool) {
+	var zero T
+	value, ok := c.Get(contextKey(kind))
+	if !ok {
+		return zero, false
+	}
+	entry, ok := value.(cached)
+	if !ok || entry.id != id {
+		return zero, false
+	}
+	resource, ok := entry.resource.(T)
+	return resource, ok
+}
+
+// Load returns the resource of kind with id checked earlier in the request,
+// or loads it with find when it was not, e.g. because auth is disabled.
+func Load[T any](ctx context.Context, c *gin.Context, kind Kind, find Finder[T], id primitive.ObjectID) (T, error) {
+	if resource, ok := Get[T](c, kind, id); ok {
+		return resource, nil
+	}
+	resource, err := find(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return resource, ErrNotFound
+	}
+	if err != nil {
+		return resource, err
+	}
+	c.Set(contextKey(kind), cached{id: id, resource: resource})
+	return resource, nil
+}
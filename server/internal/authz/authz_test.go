@@ -0,0 +1,82 @@
+package authz
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// jobStore is an in-memory Finder of jobs, which counts its lookups.
+type jobStore struct {
+	jobs  map[primitive.ObjectID]schema.Job
+	finds int
+}
+
+func (s *jobStore) find(ctx context.Context, id primitive.ObjectID) (schema.Job, error) {
+	s.finds++
+	job, ok := s.jobs[id]
+	if !ok {
+		return job, mongo.ErrNoDocuments
+	}
+	return job, nil
+}
+
+// organizations finds the personal organization of every company, like a company without members would have.
+func organizations(ctx context.Context, id primitive.ObjectID) (schema.Organization, error) {
+	return schema.PersonalOrganization(schema.User{ID: id, Role: schema.RoleCompany}), nil
+}
+
+func testContext() *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	return c
+}
+
+func TestCheckCachesResource(t *testing.T) {
+	company := Subject{ID: primitive.NewObjectID(), Role: "company"}
+	job := schema.Job{ID: primitive.NewObjectID(), CompanyID: company.ID}
+	store := &jobStore{jobs: map[primitive.ObjectID]schema.Job{job.ID: job}}
+	policy := JobPolicy{FindJob: store.find, Organizations: OrganizationPolicy{FindOrganization: organizations}}
+	Register(Job, policy)
+
+	c := testContext()
+	assert.NoError(t, Check(context.Background(), c, Job, job.ID, company, Update))
+
+	cached, ok := Get[JobResource](c, Job, job.ID)
+	assert.True(t, ok)
+	assert.Equal(t, job, cached.Job)
+	_, ok = Get[JobResource](c, Job, primitive.NewObjectID())
+	assert.False(t, ok)
+
+	// the handler gets the job checked by the middleware without loading it again
+	loaded, err := Load(context.Background(), c, Job, policy.Load, job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, cached, loaded)
+	assert.Equal(t, 1, store.finds)
+}
+
+func TestCheck(t *testing.T) {
+	company := Subject{ID: primitive.NewObjectID(), Role: "company"}
+	job := schema.Job{ID: primitive.NewObjectID(), CompanyID: primitive.NewObjectID()}
+	store := &jobStore{jobs: map[primitive.ObjectID]schema.Job{job.ID: job}}
+	Register(Job, JobPolicy{FindJob: store.find, Organizations: OrganizationPolicy{FindOrganization: organizations}})
+
+	assert.ErrorIs(t, Check(context.Background(), testContext(), Job, job.ID, company, Update), ErrForbidden)
+	assert.NoError(t, Check(context.Background(), testContext(), Job, job.ID, Subject{ID: company.ID, Role: "admin"}, Delete))
+	assert.ErrorIs(t, Check(context.Background(), testContext(), Job, primitive.NewObjectID(), company, Read), ErrNotFound)
+	assert.Error(t, Check(context.Background(), testContext(), Kind("poll"), job.ID, company, Read))
+	assert.False(t, Registered(Kind("poll")))
+}
+
+func TestActionOf(t *testing.T) {
+	assert.Equal(t, Read, ActionOf("GET"))
+	assert.Equal(t, Update, ActionOf("PUT"))
+	assert.Equal(t, Update, ActionOf("PATCH"))
+	assert.Equal(t, Delete, ActionOf("DELETE"))
+}
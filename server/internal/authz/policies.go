@@ -0,0 +1,278 @@
+package authz
+
+import (
+	"context"
+	"slices"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// orgRoleFor is the least sub-role a member of an organization needs for action:
+// viewers can look at its jobs, applicants and notes, recruiters can also change them.
+func orgRoleFor(action Action) schema.OrganizationRole {
+	if action == Read {
+		return schema.OrgViewer
+	}
+	return schema.OrgRecruiter
+}
+
+// memberMay tells whether subject works for org, with a sub-role which may do action.
+func memberMay(org schema.Organization, subject Subject, action Action) bool {
+	return subject.Role == schema.RoleCompany && org.HasRole(subject.ID, orgRoleFor(action))
+}
+
+// OrganizationPolicy lets members see their organization, and owners manage it.
+type OrganizationPolicy struct {
+	FindOrganization Finder[schema.Organization]
+}
+
+func (p OrganizationPolicy) Load(ctx context.Context, id primitive.ObjectID) (schema.Organization, error) {
+	return p.FindOrganization(ctx, id)
+}
+
+func (p OrganizationPolicy) Authorize(subject Subject, action Action, org schema.Organization) error {
+	min := schema.OrgOwner
+	if action == Read {
+		min = schema.OrgViewer
+	}
+	if subject.Role == schema.RoleCompany && org.HasRole(subject.ID, min) {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// JobResource is a job together with the organization which posted it.
+type JobResource struct {
+	Job          schema.Job
+	Organization schema.Organization
+}
+
+// JobPolicy lets the members of a company manage its jobs.
+type JobPolicy struct {
+	FindJob       Finder[schema.Job]
+	Organizations OrganizationPolicy
+}
+
+func (p JobPolicy) Load(ctx context.Context, id primitive.ObjectID) (JobResource, error) {
+	job, err := p.FindJob(ctx, id)
+	if err != nil {
+		return JobResource{}, err
+	}
+	org, err := p.Organizations.Load(ctx, job.CompanyID)
+	if err != nil {
+		return JobResource{}, err
+	}
+	return JobResource{Job: job, Organization: org}, nil
+}
+
+func (p JobPolicy) Authorize(subject Subject, action Action, resource JobResource) error {
+	if memberMay(resource.Organization, subject, action) {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// ApplicationResource is a job application together with the job it applies to.
+type ApplicationResource struct {
+	Application schema.JobApplication
+	JobResource
+}
+
+// ApplicationPolicy lets job seekers access their own applications,
+// and the members of a company the applications to its jobs.
+type ApplicationPolicy struct {
+	FindApplication Finder[schema.JobApplication]
+	Jobs            JobPolicy
+}
+
+func (p ApplicationPolicy) Load(ctx context.Context, id primitive.ObjectID) (ApplicationResource, error) {
+	application, err := p.FindApplication(ctx, id)
+	if err != nil {
+		return ApplicationResource{}, err
+	}
+	job, err := p.Jobs.Load(ctx, application.JobID)
+	if err != nil {
+		return ApplicationResource{}, err
+	}
+	return ApplicationResource{Application: application, JobResource: job}, nil
+}
+
+func (p ApplicationPolicy) Authorize(subject Subject, action Action, resource ApplicationResource) error {
+	switch subject.Role {
+	case schema.RoleJobSeeker:
+		if resource.Application.ApplicantID == subject.ID {
+			return nil
+		}
+	case schema.RoleCompany:
+		if memberMay(resource.Organization, subject, action) {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
+
+// NoteResource is a note together with the application it is about.
+type NoteResource struct {
+	Note schema.Note
+	ApplicationResource
+}
+
+// NotePolicy lets the members of a company manage the notes on applications to its jobs.
+type NotePolicy struct {
+	FindNote     Finder[schema.Note]
+	Applications ApplicationPolicy
+}
+
+func (p NotePolicy) Load(ctx context.Context, id primitive.ObjectID) (NoteResource, error) {
+	note, err := p.FindNote(ctx, id)
+	if err != nil {
+		return NoteResource{}, err
+	}
+	application, err := p.Applications.Load(ctx, note.JobApplicationID)
+	if err != nil {
+		return NoteResource{}, err
+	}
+	return NoteResource{Note: note, ApplicationResource: application}, nil
+}
+
+func (p NotePolicy) Authorize(subject Subject, action Action, resource NoteResource) error {
+	if memberMay(resource.Organization, subject, action) {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// FilePolicy lets users access their own files.
+type FilePolicy struct {
+	FindFile Finder[schema.File]
+}
+
+func (p FilePolicy) Load(ctx context.Context, id primitive.ObjectID) (schema.File, error) {
+	return p.FindFile(ctx, id)
+}
+
+func (p FilePolicy) Authorize(subject Subject, action Action, file schema.File) error {
+	if file.UserID == subject.ID {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// SavedSearchPolicy lets job seekers manage their own saved searches.
+type SavedSearchPolicy struct {
+	FindSavedSearch Finder[schema.SavedSearch]
+}
+
+func (p SavedSearchPolicy) Load(ctx context.Context, id primitive.ObjectID) (schema.SavedSearch, error) {
+	return p.FindSavedSearch(ctx, id)
+}
+
+func (p SavedSearchPolicy) Authorize(subject Subject, action Action, search schema.SavedSearch) error {
+	if search.UserID == subject.ID {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// ResumePolicy lets job seekers access their own resumes.
+// Companies see a resume through the application it was attached to.
+type ResumePolicy struct {
+	FindResume Finder[schema.Resume]
+}
+
+func (p ResumePolicy) Load(ctx context.Context, id primitive.ObjectID) (schema.Resume, error) {
+	return p.FindResume(ctx, id)
+}
+
+func (p ResumePolicy) Authorize(subject Subject, action Action, resume schema.Resume) error {
+	if resume.UserID == subject.ID {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// CohortPolicy lets faculty members manage their own cohorts.
+type CohortPolicy struct {
+	FindCohort Finder[schema.Cohort]
+}
+
+func (p CohortPolicy) Load(ctx context.Context, id primitive.ObjectID) (schema.Cohort, error) {
+	return p.FindCohort(ctx, id)
+}
+
+func (p CohortPolicy) Authorize(subject Subject, action Action, cohort schema.Cohort) error {
+	if subject.Role == "faculty" && cohort.FacultyID == subject.ID {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// UserResource is a user together with the faculty members they share their profile with.
+type UserResource struct {
+	User       schema.User
+	SharedWith []primitive.ObjectID
+}
+
+// UserPolicy lets users edit their own profile, and view the profiles CanViewUserRole allows.
+type UserPolicy struct {
+	FindUser Finder[schema.User]
+	// FindSharedWith returns the IDs of the faculty members a student consented to share their profile with.
+	FindSharedWith Finder[[]primitive.ObjectID]
+}
+
+func (p UserPolicy) Load(ctx context.Context, id primitive.ObjectID) (UserResource, error) {
+	user, err := p.FindUser(ctx, id)
+	if err != nil {
+		return UserResource{}, err
+	}
+	resource := UserResource{User: user}
+	if user.Role == schema.RoleJobSeeker && p.FindSharedWith != nil {
+		if resource.SharedWith, err = p.FindSharedWith(ctx, id); err != nil {
+			return UserResource{}, err
+		}
+	}
+	return resource, nil
+}
+
+func (p UserPolicy) Authorize(subject Subject, action Action, resource UserResource) error {
+	// Always allow accessing own profile
+	if resource.User.ID == subject.ID {
+		return nil
+	}
+	shared := slices.Contains(resource.SharedWith, subject.ID)
+	if action == Read && CanViewUserRole(subject.Role, resource.User.Role, shared) {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// CanViewUserRole checks if the viewer can see a target user's profile
+// This implements the role-based viewing matrix for user profiles.
+// shared tells whether the target consented to share their profile with the viewer.
+func CanViewUserRole(viewerRole string, targetRole string, shared bool) bool {
+	// Admin can view everyone
+	if viewerRole == "admin" {
+		return true
+	}
+
+	// Job Seeker can view: company profiles only (NOT other job seekers, faculty, or admin)
+	if viewerRole == "jobSeeker" {
+		return targetRole == "company"
+	}
+
+	// Company can view: job seeker profiles AND other company profiles (for job postings)
+	// BUT NOT faculty or admin
+	if viewerRole == "company" {
+		return targetRole == "jobSeeker" || targetRole == "company"
+	}
+
+	// Faculty can view: company profiles (for job browsing),
+	// and the job seekers of their cohorts who consented to it
+	if viewerRole == "faculty" {
+		return targetRole == "company" || (targetRole == "jobSeeker" && shared)
+	}
+
+	// Default: deny
+	return false
+}
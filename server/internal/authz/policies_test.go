@@ -0,0 +1,199 @@
+package authz
+
+import (
+	"testing"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// testOrganization is an organization with a member of every sub-role.
+func testOrganization() (schema.Organization, map[schema.OrganizationRole]Subject) {
+	members := map[schema.OrganizationRole]Subject{}
+	org := schema.Organization{ID: primitive.NewObjectID()}
+	for _, role := range []schema.OrganizationRole{schema.OrgOwner, schema.OrgRecruiter, schema.OrgViewer} {
+		member := Subject{ID: primitive.NewObjectID(), Role: "company"}
+		members[role] = member
+		org.Members = append(org.Members, schema.OrganizationMember{UserID: member.ID, Role: role})
+	}
+	return org, members
+}
+
+func TestOrganizationPolicy(t *testing.T) {
+	org, members := testOrganization()
+
+	assert.NoError(t, OrganizationPolicy{}.Authorize(members[schema.OrgViewer], Read, org))
+	assert.NoError(t, OrganizationPolicy{}.Authorize(members[schema.OrgOwner], Update, org))
+	// only owners manage the members
+	assert.ErrorIs(t, OrganizationPolicy{}.Authorize(members[schema.OrgRecruiter], Update, org), ErrForbidden)
+	assert.ErrorIs(t, OrganizationPolicy{}.Authorize(Subject{ID: primitive.NewObjectID(), Role: "company"}, Read, org), ErrForbidden)
+}
+
+func TestJobPolicy(t *testing.T) {
+	org, members := testOrganization()
+	resource := JobResource{Job: schema.Job{ID: primitive.NewObjectID(), CompanyID: org.ID}, Organization: org}
+
+	tests := []struct {
+		name    string
+		subject Subject
+		action  Action
+		allowed bool
+	}{
+		{"owner updates", members[schema.OrgOwner], Update, true},
+		{"recruiter deletes", members[schema.OrgRecruiter], Delete, true},
+		{"viewer reads", members[schema.OrgViewer], Read, true},
+		{"viewer updates", members[schema.OrgViewer], Update, false},
+		{"other company", Subject{ID: primitive.NewObjectID(), Role: "company"}, Read, false},
+		// a job seeker with the ID of a member is still not the member
+		{"job seeker with a member's ID", Subject{ID: members[schema.OrgOwner].ID, Role: "jobSeeker"}, Update, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := JobPolicy{}.Authorize(tt.subject, tt.action, resource)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrForbidden)
+			}
+		})
+	}
+}
+
+func TestApplicationPolicy(t *testing.T) {
+	applicant := Subject{ID: primitive.NewObjectID(), Role: "jobSeeker"}
+	org, members := testOrganization()
+	resource := ApplicationResource{
+		Application: schema.JobApplication{ApplicantID: applicant.ID},
+		JobResource: JobResource{Job: schema.Job{CompanyID: org.ID}, Organization: org},
+	}
+
+	tests := []struct {
+		name    string
+		subject Subject
+		action  Action
+		allowed bool
+	}{
+		{"applicant", applicant, Read, true},
+		{"recruiter of the job", members[schema.OrgRecruiter], Update, true},
+		{"viewer of the job", members[schema.OrgViewer], Read, true},
+		{"viewer moves the application", members[schema.OrgViewer], Update, false},
+		{"other job seeker", Subject{ID: primitive.NewObjectID(), Role: "jobSeeker"}, Read, false},
+		{"other company", Subject{ID: primitive.NewObjectID(), Role: "company"}, Read, false},
+		{"faculty", Subject{ID: primitive.NewObjectID(), Role: "faculty"}, Read, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ApplicationPolicy{}.Authorize(tt.subject, tt.action, resource)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrForbidden)
+			}
+		})
+	}
+}
+
+func TestNotePolicy(t *testing.T) {
+	org, members := testOrganization()
+	applicant := Subject{ID: primitive.NewObjectID(), Role: "jobSeeker"}
+	resource := NoteResource{ApplicationResource: ApplicationResource{
+		Application: schema.JobApplication{ApplicantID: applicant.ID},
+		JobResource: JobResource{Job: schema.Job{CompanyID: org.ID}, Organization: org},
+	}}
+
+	assert.NoError(t, NotePolicy{}.Authorize(members[schema.OrgRecruiter], Update, resource))
+	assert.NoError(t, NotePolicy{}.Authorize(members[schema.OrgViewer], Read, resource))
+	assert.ErrorIs(t, NotePolicy{}.Authorize(members[schema.OrgViewer], Delete, resource), ErrForbidden)
+	// notes are the company's, not the applicant's
+	assert.ErrorIs(t, NotePolicy{}.Authorize(applicant, Read, resource), ErrForbidden)
+}
+
+func TestFilePolicy(t *testing.T) {
+	owner := Subject{ID: primitive.NewObjectID(), Role: "jobSeeker"}
+	file := schema.File{UserID: owner.ID}
+
+	assert.NoError(t, FilePolicy{}.Authorize(owner, Delete, file))
+	assert.ErrorIs(t, FilePolicy{}.Authorize(Subject{ID: primitive.NewObjectID(), Role: "company"}, Read, file), ErrForbidden)
+}
+
+func TestSavedSearchPolicy(t *testing.T) {
+	owner := Subject{ID: primitive.NewObjectID(), Role: "jobSeeker"}
+	search := schema.SavedSearch{UserID: owner.ID}
+
+	assert.NoError(t, SavedSearchPolicy{}.Authorize(owner, Update, search))
+	assert.ErrorIs(t, SavedSearchPolicy{}.Authorize(Subject{ID: primitive.NewObjectID(), Role: "jobSeeker"}, Read, search), ErrForbidden)
+}
+
+func TestResumePolicy(t *testing.T) {
+	owner := Subject{ID: primitive.NewObjectID(), Role: "jobSeeker"}
+	resume := schema.Resume{UserID: owner.ID}
+
+	assert.NoError(t, ResumePolicy{}.Authorize(owner, Read, resume))
+	// companies only see resumes attached to applications, through ApplicationPolicy
+	assert.ErrorIs(t, ResumePolicy{}.Authorize(Subject{ID: primitive.NewObjectID(), Role: "company"}, Read, resume), ErrForbidden)
+}
+
+func TestCohortPolicy(t *testing.T) {
+	faculty := Subject{ID: primitive.NewObjectID(), Role: "faculty"}
+	cohort := schema.Cohort{FacultyID: faculty.ID}
+
+	assert.NoError(t, CohortPolicy{}.Authorize(faculty, Update, cohort))
+	assert.ErrorIs(t, CohortPolicy{}.Authorize(Subject{ID: primitive.NewObjectID(), Role: "faculty"}, Read, cohort), ErrForbidden)
+	// students of the cohort do not manage it
+	assert.ErrorIs(t, CohortPolicy{}.Authorize(Subject{ID: faculty.ID, Role: "jobSeeker"}, Read, cohort), ErrForbidden)
+}
+
+func TestUserPolicy(t *testing.T) {
+	seeker := UserResource{User: schema.User{ID: primitive.NewObjectID(), Role: "jobSeeker"}}
+	company := schema.User{ID: primitive.NewObjectID(), Role: "company"}
+	faculty := UserResource{User: schema.User{ID: primitive.NewObjectID(), Role: "faculty"}}
+
+	self := Subject{ID: seeker.User.ID, Role: seeker.User.Role}
+	assert.NoError(t, UserPolicy{}.Authorize(self, Update, seeker))
+
+	viewer := Subject{ID: company.ID, Role: company.Role}
+	assert.NoError(t, UserPolicy{}.Authorize(viewer, Read, seeker))
+	assert.ErrorIs(t, UserPolicy{}.Authorize(viewer, Update, seeker), ErrForbidden)
+	assert.ErrorIs(t, UserPolicy{}.Authorize(viewer, Read, faculty), ErrForbidden)
+
+	other := UserResource{User: schema.User{ID: primitive.NewObjectID(), Role: "jobSeeker"}}
+	assert.ErrorIs(t, UserPolicy{}.Authorize(self, Read, other), ErrForbidden)
+}
+
+func TestUserPolicySharedWithFaculty(t *testing.T) {
+	teacher := Subject{ID: primitive.NewObjectID(), Role: "faculty"}
+	student := UserResource{
+		User:       schema.User{ID: primitive.NewObjectID(), Role: "jobSeeker"},
+		SharedWith: []primitive.ObjectID{teacher.ID},
+	}
+
+	assert.NoError(t, UserPolicy{}.Authorize(teacher, Read, student))
+	assert.ErrorIs(t, UserPolicy{}.Authorize(teacher, Update, student), ErrForbidden)
+	// other faculty members only see students who shared with them
+	assert.ErrorIs(t, UserPolicy{}.Authorize(Subject{ID: primitive.NewObjectID(), Role: "faculty"}, Read, student), ErrForbidden)
+}
+
+func TestCanViewUserRole(t *testing.T) {
+	tests := []struct {
+		viewer, target string
+		shared         bool
+		allowed        bool
+	}{
+		{"admin", "faculty", false, true},
+		{"jobSeeker", "company", false, true},
+		{"jobSeeker", "jobSeeker", false, false},
+		{"jobSeeker", "jobSeeker", true, false},
+		{"company", "jobSeeker", false, true},
+		{"company", "company", false, true},
+		{"company", "admin", false, false},
+		{"faculty", "company", false, true},
+		{"faculty", "jobSeeker", false, false},
+		{"faculty", "jobSeeker", true, true},
+		{"faculty", "faculty", true, false},
+		{"", "company", false, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, CanViewUserRole(tt.viewer, tt.target, tt.shared), tt.viewer+" viewing "+tt.target)
+	}
+}
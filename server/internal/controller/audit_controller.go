@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditController lets admins read the audit log, which is written through the audit package.
+type AuditController struct{}
+
+func NewAuditController() AuditController {
+	return AuditController{}
+}
+
+// Query godoc
+// @Summary      Read the audit log (admin only)
+// @Description  List audit events, newest first, optionally only those of an actor, on a target, of an action or within a time range.
+// @Tags         Admin
+// @Produce      json
+// @Param        actorID     query     string   false  "ID of the user who acted"
+// @Param        targetID    query     string   false  "ID of the document acted on"
+// @Param        targetType  query     string   false  "Collection of the target, e.g. users or jobs"
+// @Param        action      query     string   false  "e.g. verify, role_change, ban, delete, restore, login"
+// @Param        from        query     string   false  "Only events at or after this time (RFC 3339)"
+// @Param        to          query     string   false  "Only events before this time (RFC 3339)"
+// @Param        limit       query     integer  false  "Page size (default 20, max 100)"
+// @Param        cursor      query     string   false  "Cursor from the previous page's nextCursor"
+// @Success      200         {object}  repository.Page[schema.AuditEvent]
+// @Failure      400         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Router       /admin/audit [get]
+func (ac AuditController) Query(c *gin.Context) {
+	filter, err := auditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, err := getPageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page.SortOrder = -1
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, err := repository.FindPage[schema.AuditEvent](ctx, filter, page)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Retrieve Audit log failed"})
+		return
+	}
+	if events.Data == nil {
+		events.Data = []schema.AuditEvent{}
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+// auditFilter builds the filter of the audit log from the query string.
+func auditFilter(c *gin.Context) (bson.M, error) {
+	filter := bson.M{}
+	for param, field := range map[string]string{"actorID": "actorID", "targetID": "targetID"} {
+		if v := c.Query(param); v != "" {
+			id, err := primitive.ObjectIDFromHex(v)
+			if err != nil {
+				return nil, errors.New("Invalid " + param)
+			}
+			filter[field] = id
+		}
+	}
+	if v := c.Query("targetType"); v != "" {
+		filter["targetType"] = v
+	}
+	if v := c.Query("action"); v != "" {
+		filter["action"] = v
+	}
+
+	createdAt := bson.M{}
+	for param, op := range map[string]string{"from": "$gte", "to": "$lt"} {
+		if v := c.Query(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, errors.New(param + " must be an RFC 3339 time")
+			}
+			createdAt[op] = t
+		}
+	}
+	if len(createdAt) > 0 {
+		filter["createdAt"] = createdAt
+	}
+	return filter, nil
+}
@@ -3,6 +3,7 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"time"
@@ -33,6 +34,10 @@ func (controller BaseController[Schema, DTO]) Create(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
 		return
 	}
+	// only Delete may mark a document as deleted
+	if d, ok := any(&raw).(interface{ Undelete() }); ok {
+		d.Undelete()
+	}
 	// Validate input
 	if v, ok := any(&raw).(interface{ Validate() error }); ok {
 		if err := v.Validate(); err != nil {
@@ -57,13 +62,22 @@ func (controller BaseController[Schema, DTO]) Create(c *gin.Context) {
 	c.JSON(http.StatusCreated, res)
 }
 
-// RetrieveAll retrieves all documents (row) and all of its attirbutes
-// from collectionName collection
+// RetrieveAll retrieves one page of documents (row) and all of its attirbutes
+// from collectionName collection. Use `limit` and `cursor` to walk through pages.
 func (controller BaseController[Schema, DTO]) RetrieveAll(c *gin.Context) {
 	userInfo := getUserForLogging(c)
+	page, err := getPageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	res, err := repository.FindAll[Schema](ctx, bson.M{})
+	res, err := repository.FindPage[Schema](ctx, bson.M{}, page)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		msg := "Retrieve All " + controller.displayName + " failed"
 		slog.Error(userInfo + msg + ": " + err.Error())
@@ -99,6 +113,12 @@ func (controller BaseController[Schema, DTO]) Update(c *gin.Context) {
 
 	res, err := repository.Update[Schema](ctx, objID, newData)
 
+	if errors.Is(err, repository.ErrInvalidUpdate) {
+		msg := "Update " + controller.displayName + " failed: " + err.Error()
+		slog.Warn(userInfo + msg)
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
 	if err != nil {
 		msg := "Update " + controller.displayName + " failed"
 		slog.Error(userInfo + msg + ": " + err.Error())
@@ -119,6 +139,7 @@ func (controller BaseController[Schema, DTO]) Update(c *gin.Context) {
 }
 
 // Delete() deletes a resource by ID.
+// Resources which are schema.SoftDeletable are only marked as deleted, so that an admin can restore them.
 func (controller BaseController[Schema, DTO]) Delete(c *gin.Context) {
 	userInfo := getUserForLogging(c)
 	id := c.Param("id")
@@ -133,7 +154,8 @@ func (controller BaseController[Schema, DTO]) Delete(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	result, err := repository.DeleteOne[Schema](ctx, objID)
+	by, _, _ := getUserFromMiddleware(c)
+	found, err := repository.Delete[Schema](ctx, objID, by, time.Now())
 
 	if err != nil {
 		msg := "Delete " + controller.displayName + "failed"
@@ -142,7 +164,7 @@ func (controller BaseController[Schema, DTO]) Delete(c *gin.Context) {
 		return
 	}
 
-	if result.DeletedCount == 0 {
+	if !found {
 		msg := "Delete " + controller.displayName + "failed: resource not found"
 		slog.Warn(userInfo + msg)
 		c.JSON(http.StatusNotFound, gin.H{"error": msg})
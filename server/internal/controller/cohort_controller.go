@@ -0,0 +1,585 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/authz"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/dto"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CohortController lets faculty members follow cohorts of students:
+// their applications, once the students consent to it, and endorsements of their profiles.
+type CohortController struct {
+	baseController BaseController[schema.Cohort, dto.Cohort]
+}
+
+func NewCohortController() CohortController {
+	return CohortController{
+		baseController: BaseController[schema.Cohort, dto.Cohort]{
+			collectionName: "cohorts",
+			displayName:    "Cohort",
+		},
+	}
+}
+
+// Create godoc
+// @Summary      Create a cohort
+// @Description  Create an empty cohort of the authenticated faculty member.
+// @Tags         Cohorts
+// @Accept       json
+// @Produce      json
+// @Param        cohort  body      schema.Cohort  true  "Cohort (name)"
+// @Success      201     {object}  schema.Cohort
+// @Failure      400     {object}  map[string]string
+// @Failure      401     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /cohorts/ [post]
+func (cc CohortController) Create(c *gin.Context) {
+	userInfo := getUserForLogging(c)
+	var cohort schema.Cohort
+	if err := c.ShouldBindJSON(&cohort); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	facultyID, _, err := getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+	cohort.ID = primitive.NewObjectID()
+	cohort.FacultyID = facultyID
+	cohort.Students = []schema.CohortStudent{}
+	cohort.CreatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := repository.InsertOne(ctx, cohort); err != nil {
+		msg := "Create Cohort failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+
+	slog.Info(userInfo + "Created Cohort: " + cohort.ID.Hex())
+	c.JSON(http.StatusCreated, cohort)
+}
+
+// RetrieveAll godoc
+// @Summary      List cohorts
+// @Description  List the cohorts of the authenticated faculty member, or every cohort for admins.
+// @Tags         Cohorts
+// @Produce      json
+// @Success      200  {array}   schema.Cohort
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /cohorts/ [get]
+func (cc CohortController) RetrieveAll(c *gin.Context) {
+	userID, role, err := getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+	filter := bson.M{"facultyID": userID}
+	if role == "admin" {
+		filter = bson.M{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cohorts, err := repository.FindAll[schema.Cohort](ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Retrieve Cohorts failed"})
+		return
+	}
+	if cohorts == nil {
+		cohorts = []schema.Cohort{}
+	}
+	c.JSON(http.StatusOK, cohorts)
+}
+
+// RetrieveOne godoc
+// @Summary      Get a cohort
+// @Tags         Cohorts
+// @Produce      json
+// @Param        id   path      string  true  "Cohort ID"
+// @Success      200  {object}  schema.Cohort
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /cohorts/{id} [get]
+func (cc CohortController) RetrieveOne(c *gin.Context) {
+	cc.baseController.RetrieveOne(c)
+}
+
+// Update godoc
+// @Summary      Rename a cohort
+// @Tags         Cohorts
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string      true  "Cohort ID"
+// @Param        cohort  body      dto.Cohort  true  "Fields to update"
+// @Success      200     {object}  map[string]string
+// @Failure      400     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /cohorts/{id} [put]
+func (cc CohortController) Update(c *gin.Context) {
+	cc.baseController.Update(c)
+}
+
+// Delete godoc
+// @Summary      Delete a cohort
+// @Description  Delete a cohort together with the endorsements written for its students.
+// @Tags         Cohorts
+// @Produce      json
+// @Param        id   path      string  true  "Cohort ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /cohorts/{id} [delete]
+func (cc CohortController) Delete(c *gin.Context) {
+	cohortID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cohort ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := repository.DeleteMany[schema.Endorsement](ctx, "endorsements", bson.M{"cohortID": cohortID}); err != nil {
+		msg := "Delete Cohort failed"
+		slog.Error(getUserForLogging(c) + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	cc.baseController.Delete(c)
+}
+
+// AddStudent godoc
+// @Summary      Add a student to a cohort
+// @Description  Add the job seeker with the given email to a cohort. The faculty member sees nothing of the student until the student consents.
+// @Tags         Cohorts
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string             true  "Cohort ID"
+// @Param        student  body      dto.CohortStudent  true  "Email of the student"
+// @Success      201      {object}  schema.CohortStudent
+// @Failure      400      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      409      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Router       /cohorts/{id}/students [post]
+func (cc CohortController) AddStudent(c *gin.Context) {
+	userInfo := getUserForLogging(c)
+	cohortID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cohort ID"})
+		return
+	}
+	var req dto.CohortStudent
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := findUserByEmail(ctx, strings.TrimSpace(req.Email))
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && user.Role != schema.RoleJobSeeker) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no job seeker with this email"})
+		return
+	}
+	if err != nil {
+		msg := "Add Student failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+
+	student := schema.CohortStudent{UserID: user.ID, AddedAt: time.Now()}
+	res, err := repository.UpdateOne[schema.Cohort](ctx,
+		bson.M{"_id": cohortID, "students.userID": bson.M{"$ne": user.ID}},
+		bson.M{"$push": bson.M{"students": student}},
+	)
+	if err != nil {
+		msg := "Add Student failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "the student is already in this cohort"})
+		return
+	}
+
+	slog.Info(userInfo + "Added Student to Cohort: " + cohortID.Hex())
+	c.JSON(http.StatusCreated, student)
+}
+
+// RemoveStudent godoc
+// @Summary      Remove a student from a cohort
+// @Description  Remove a student from a cohort, together with the endorsement written for them in it.
+// @Tags         Cohorts
+// @Produce      json
+// @Param        id         path      string  true  "Cohort ID"
+// @Param        studentId  path      string  true  "User ID of the student"
+// @Success      200        {object}  map[string]string
+// @Failure      400        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /cohorts/{id}/students/{studentId} [delete]
+func (cc CohortController) RemoveStudent(c *gin.Context) {
+	userInfo := getUserForLogging(c)
+	cohortID, studentID, ok := cohortStudentParams(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := repository.UpdateOne[schema.Cohort](ctx,
+		bson.M{"_id": cohortID},
+		bson.M{"$pull": bson.M{"students": bson.M{"userID": studentID}}},
+	)
+	if err == nil {
+		err = repository.DeleteMany[schema.Endorsement](ctx, "endorsements", bson.M{"cohortID": cohortID, "studentID": studentID})
+	}
+	if err != nil {
+		msg := "Remove Student failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	if res.ModifiedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "student removed"})
+}
+
+// Outcomes godoc
+// @Summary      Application outcomes of a cohort
+// @Description  Count the applications of the students of a cohort by status, and how many of them were placed. Only students who consented are counted.
+// @Tags         Cohorts
+// @Produce      json
+// @Param        id   path      string  true  "Cohort ID"
+// @Success      200  {object}  schema.CohortOutcomes
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /cohorts/{id}/outcomes [get]
+func (cc CohortController) Outcomes(c *gin.Context) {
+	cohortID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cohort ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cohort, err := authz.Load(ctx, c, authz.Cohort, cohortPolicy.Load, cohortID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cohort not found"})
+		return
+	}
+
+	consented := cohort.Consented()
+	applications, err := repository.FindAll[schema.JobApplication](ctx, bson.M{"applicantID": bson.M{"$in": consented}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot fetch job applications"})
+		return
+	}
+	students, err := getUsersFromIDs(ctx, consented)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot fetch students"})
+		return
+	}
+
+	outcomes := schema.SummarizeOutcomes(cohort, applications)
+	for i, outcome := range outcomes.PerStudent {
+		outcomes.PerStudent[i].Name = students[outcome.StudentID].Name
+	}
+	c.JSON(http.StatusOK, outcomes)
+}
+
+// Endorse godoc
+// @Summary      Endorse a student
+// @Description  Write or replace the endorsement of a student of the cohort, shown on the student's profile. The student must have consented.
+// @Tags         Cohorts
+// @Accept       json
+// @Produce      json
+// @Param        id           path      string           true  "Cohort ID"
+// @Param        studentId    path      string           true  "User ID of the student"
+// @Param        endorsement  body      dto.Endorsement  true  "Endorsement"
+// @Success      200          {object}  schema.Endorsement
+// @Failure      400          {object}  map[string]string
+// @Failure      403          {object}  map[string]string
+// @Failure      404          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /cohorts/{id}/students/{studentId}/endorsement [put]
+func (cc CohortController) Endorse(c *gin.Context) {
+	userInfo := getUserForLogging(c)
+	cohortID, studentID, ok := cohortStudentParams(c)
+	if !ok {
+		return
+	}
+	var req dto.Endorsement
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cohort, err := authz.Load(ctx, c, authz.Cohort, cohortPolicy.Load, cohortID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cohort not found"})
+		return
+	}
+	student, ok := cohort.Student(studentID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+		return
+	}
+	if student.ConsentedAt == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "the student has not consented to share with you"})
+		return
+	}
+
+	now := time.Now()
+	endorsement, err := repository.FindOneAndUpdate[schema.Endorsement](ctx,
+		bson.M{"cohortID": cohort.ID, "studentID": studentID},
+		bson.M{
+			"$set":         bson.M{"comment": req.Comment, "facultyID": cohort.FacultyID, "updatedAt": now},
+			"$setOnInsert": bson.M{"createdAt": now},
+		},
+		options.FindOneAndUpdate().SetUpsert(true),
+	)
+	if err != nil {
+		msg := "Endorse Student failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+
+	slog.Info(userInfo + "Endorsed Student: " + studentID.Hex())
+	c.JSON(http.StatusOK, endorsement)
+}
+
+// Unendorse godoc
+// @Summary      Withdraw an endorsement
+// @Tags         Cohorts
+// @Produce      json
+// @Param        id         path      string  true  "Cohort ID"
+// @Param        studentId  path      string  true  "User ID of the student"
+// @Success      200        {object}  map[string]string
+// @Failure      400        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /cohorts/{id}/students/{studentId}/endorsement [delete]
+func (cc CohortController) Unendorse(c *gin.Context) {
+	cohortID, studentID, ok := cohortStudentParams(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := database.GetDatabase().Collection("endorsements").DeleteOne(ctx, bson.M{"cohortID": cohortID, "studentID": studentID})
+	if err != nil {
+		msg := "Withdraw Endorsement failed"
+		slog.Error(getUserForLogging(c) + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	if res.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "endorsement not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "endorsement withdrawn"})
+}
+
+// Mine godoc
+// @Summary      List my cohorts
+// @Description  List the cohorts the authenticated job seeker was added to, and whether they consented to share with each faculty member.
+// @Tags         Cohorts
+// @Produce      json
+// @Success      200  {array}   dto.StudentCohort
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /cohorts/mine [get]
+func (cc CohortController) Mine(c *gin.Context) {
+	userID, _, err := getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cohorts, err := repository.FindAll[schema.Cohort](ctx, bson.M{"students.userID": userID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Retrieve Cohorts failed"})
+		return
+	}
+	faculty, err := getUsersFromIDs(ctx, extractUnique(cohorts, func(cohort schema.Cohort) primitive.ObjectID { return cohort.FacultyID }))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Retrieve Cohorts failed"})
+		return
+	}
+
+	result := make([]dto.StudentCohort, 0, len(cohorts))
+	for _, cohort := range cohorts {
+		student, _ := cohort.Student(userID)
+		result = append(result, dto.StudentCohort{
+			ID:          cohort.ID,
+			Name:        cohort.Name,
+			FacultyID:   cohort.FacultyID,
+			FacultyName: faculty[cohort.FacultyID].Name,
+			AddedAt:     student.AddedAt,
+			ConsentedAt: student.ConsentedAt,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// Consent godoc
+// @Summary      Consent to share with a cohort's faculty member
+// @Description  Give or withdraw consent for the faculty member of a cohort to see your profile and applications. Withdrawing also removes their endorsement.
+// @Tags         Cohorts
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string             true  "Cohort ID"
+// @Param        consent  body      dto.CohortConsent  true  "Consent"
+// @Success      200      {object}  map[string]string
+// @Failure      400      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Router       /cohorts/{id}/consent [put]
+func (cc CohortController) Consent(c *gin.Context) {
+	userInfo := getUserForLogging(c)
+	cohortID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cohort ID"})
+		return
+	}
+	var req dto.CohortConsent
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, _, err := getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"students.$.consentedAt": time.Now()}}
+	if !*req.Consent {
+		update = bson.M{"$unset": bson.M{"students.$.consentedAt": ""}}
+	}
+	// students can only consent to cohorts they are in
+	res, err := repository.UpdateOne[schema.Cohort](ctx, bson.M{"_id": cohortID, "students.userID": userID}, update)
+	if err == nil && res.MatchedCount > 0 && !*req.Consent {
+		err = repository.DeleteMany[schema.Endorsement](ctx, "endorsements", bson.M{"cohortID": cohortID, "studentID": userID})
+	}
+	if err != nil {
+		msg := "Update Consent failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cohort not found"})
+		return
+	}
+
+	if *req.Consent {
+		slog.Info(userInfo + "Consented to Cohort: " + cohortID.Hex())
+		c.JSON(http.StatusOK, gin.H{"message": "consent given"})
+		return
+	}
+	slog.Info(userInfo + "Withdrew consent to Cohort: " + cohortID.Hex())
+	c.JSON(http.StatusOK, gin.H{"message": "consent withdrawn"})
+}
+
+// Endorsements godoc
+// @Summary      List the endorsements of a user
+// @Description  List the endorsements faculty members wrote for a student. Everyone who can view the student's profile can see them.
+// @Tags         Users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {array}   dto.EndorsementWithFaculty
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /users/{id}/endorsements [get]
+func (cc CohortController) Endorsements(c *gin.Context) {
+	studentID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	endorsements, err := repository.FindAll[schema.Endorsement](ctx, bson.M{"studentID": studentID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Retrieve Endorsements failed"})
+		return
+	}
+	faculty, err := getUsersFromIDs(ctx, extractUnique(endorsements, func(e schema.Endorsement) primitive.ObjectID { return e.FacultyID }))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Retrieve Endorsements failed"})
+		return
+	}
+
+	result := make([]dto.EndorsementWithFaculty, 0, len(endorsements))
+	for _, endorsement := range endorsements {
+		result = append(result, dto.EndorsementWithFaculty{
+			Endorsement: endorsement,
+			FacultyName: faculty[endorsement.FacultyID].Name,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// cohortStudentParams parses the cohort and student IDs of the path.
+func cohortStudentParams(c *gin.Context) (cohortID, studentID primitive.ObjectID, ok bool) {
+	cohortID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cohort ID"})
+		return cohortID, studentID, false
+	}
+	studentID, err = primitive.ObjectIDFromHex(c.Param("studentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid student ID"})
+		return cohortID, studentID, false
+	}
+	return cohortID, studentID, true
+}
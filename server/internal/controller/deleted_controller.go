@@ -0,0 +1,303 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/config"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/audit"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// purgeInterval is how often soft-deleted documents past their retention are purged.
+	purgeInterval = time.Hour
+	// defaultDeletedRetention is how long soft-deleted documents can be restored,
+	// unless DELETED_RETENTION_DAYS says otherwise.
+	defaultDeletedRetention = 30 * 24 * time.Hour
+)
+
+// errRestoreParentFirst is returned when restoring a document which belongs to one that is still deleted.
+var errRestoreParentFirst = errors.New("it belongs to a deleted document, restore that first")
+
+// deletedKind is a kind of soft-deleted document which admins can list and restore.
+type deletedKind struct {
+	collection string
+	find       func(ctx context.Context, page repository.PageRequest) (any, error)
+	restore    func(ctx context.Context, id primitive.ObjectID) (any, error)
+	purge      func(ctx context.Context, before time.Time) (int64, error)
+}
+
+// deletedKinds are keyed by the name admins use in the URL.
+var deletedKinds = map[string]deletedKind{
+	"jobs":         {"jobs", findDeleted[schema.Job], restoreJob, repository.Purge[schema.Job]},
+	"applications": {"job_applications", findDeleted[schema.JobApplication], restoreApplication, repository.Purge[schema.JobApplication]},
+	"notes":        {"notes", findDeleted[schema.Note], restoreNote, repository.Purge[schema.Note]},
+	"users":        {"users", findDeleted[schema.User], restoreDeleted[schema.User], repository.Purge[schema.User]},
+}
+
+// DeletedController lets admins find soft-deleted documents and restore them before they are purged.
+type DeletedController struct{}
+
+func NewDeletedController() DeletedController {
+	return DeletedController{}
+}
+
+// Query godoc
+// @Summary      List deleted documents (admin only)
+// @Description  List the soft-deleted jobs, applications, notes or users, most recently deleted first. They can be restored until they are purged.
+// @Tags         Admin
+// @Produce      json
+// @Param        kind    path      string   true   "jobs | applications | notes | users"
+// @Param        limit   query     integer  false  "Page size (default 20, max 100)"
+// @Param        cursor  query     string   false  "Cursor from the previous page's nextCursor"
+// @Success      200     {object}  map[string]any
+// @Failure      400     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /admin/deleted/{kind} [get]
+func (dc DeletedController) Query(c *gin.Context) {
+	kind, ok := deletedKinds[c.Param("kind")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown kind: " + c.Param("kind")})
+		return
+	}
+	page, err := getPageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := kind.find(ctx, page)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		msg := "Retrieve deleted " + c.Param("kind") + " failed"
+		slog.Error(getUserForLogging(c) + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// Restore godoc
+// @Summary      Restore a deleted document (admin only)
+// @Description  Undo the deletion of a job, application, note or user. Restoring a job restores the applications and notes deleted with it, restoring an application restores its notes.
+// @Tags         Admin
+// @Produce      json
+// @Param        kind  path      string  true  "jobs | applications | notes | users"
+// @Param        id    path      string  true  "Document ID"
+// @Success      200   {object}  map[string]any
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      409   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /admin/deleted/{kind}/{id}/restore [post]
+func (dc DeletedController) Restore(c *gin.Context) {
+	userInfo := getUserForLogging(c)
+	kind, ok := deletedKinds[c.Param("kind")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown kind: " + c.Param("kind")})
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	restored, err := kind.restore(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No deleted document with this ID"})
+		return
+	}
+	if errors.Is(err, errRestoreParentFirst) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		msg := "Restore " + c.Param("kind") + " failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+
+	slog.Info(fmt.Sprintf("%sRestored %s: %s", userInfo, c.Param("kind"), id.Hex()))
+	audit.Record(c, schema.AuditEvent{Action: schema.AuditRestore, TargetType: kind.collection, TargetID: id})
+	c.JSON(http.StatusOK, restored)
+}
+
+// findDeleted finds one page of the soft-deleted documents of T, most recently deleted first.
+func findDeleted[T schema.SoftDeletable](ctx context.Context, page repository.PageRequest) (any, error) {
+	page.SortField, page.SortOrder = "deletedAt", -1
+	res, err := repository.FindPage[T](ctx, bson.M{"deletedAt": bson.M{"$ne": nil}}, page)
+	if err != nil {
+		return nil, err
+	}
+	return pageResponse(res.Data, res.NextCursor, res.HasMore), nil
+}
+
+// restoreDeleted restores the soft-deleted document of T with id and returns it.
+func restoreDeleted[T schema.SoftDeletable](ctx context.Context, id primitive.ObjectID) (any, error) {
+	if _, err := repository.FindDeleted[T](ctx, id); err != nil {
+		return nil, err
+	}
+	if _, err := repository.Restore[T](ctx, bson.M{"_id": id}); err != nil {
+		return nil, err
+	}
+	return repository.FindOne[T](ctx, id)
+}
+
+// restoreJob restores a job along with the applications which were deleted with it.
+func restoreJob(ctx context.Context, id primitive.ObjectID) (any, error) {
+	job, err := repository.FindDeleted[schema.Job](ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := repository.Restore[schema.Job](ctx, bson.M{"_id": id}); err != nil {
+		return nil, err
+	}
+	if err := restoreApplications(ctx, bson.M{"jobID": id}, *job.DeletedAt); err != nil {
+		return nil, err
+	}
+	return repository.FindOne[schema.Job](ctx, id)
+}
+
+// restoreApplication restores an application along with its notes, unless its job is deleted.
+func restoreApplication(ctx context.Context, id primitive.ObjectID) (any, error) {
+	app, err := repository.FindDeleted[schema.JobApplication](ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := repository.FindOne[schema.Job](ctx, app.JobID); errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, errRestoreParentFirst
+	}
+	if err := restoreApplications(ctx, bson.M{"_id": id}, *app.DeletedAt); err != nil {
+		return nil, err
+	}
+	return repository.FindOne[schema.JobApplication](ctx, id)
+}
+
+// restoreNote restores a note, unless its application is deleted.
+func restoreNote(ctx context.Context, id primitive.ObjectID) (any, error) {
+	note, err := repository.FindDeleted[schema.Note](ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := repository.FindOne[schema.JobApplication](ctx, note.JobApplicationID); errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, errRestoreParentFirst
+	}
+	return restoreDeleted[schema.Note](ctx, id)
+}
+
+// deleteApplications soft-deletes the applications matching filter, and their notes,
+// along with the document deletion belongs to, so that they are restored together.
+func deleteApplications(ctx context.Context, filter bson.M, deletion schema.SoftDelete) error {
+	apps, err := repository.FindAll[schema.JobApplication](ctx, filter)
+	if err != nil || len(apps) == 0 {
+		return err
+	}
+	ids := make([]primitive.ObjectID, 0, len(apps))
+	for _, app := range apps {
+		ids = append(ids, app.ID)
+	}
+	if _, err := repository.SoftDelete[schema.JobApplication](ctx, bson.M{"_id": bson.M{"$in": ids}}, deleter(deletion), *deletion.DeletedAt); err != nil {
+		return err
+	}
+	return deleteNotesOf(ctx, ids, deletion)
+}
+
+// deleteNotesOf soft-deletes the notes on the applications with appIDs along with them.
+func deleteNotesOf(ctx context.Context, appIDs []primitive.ObjectID, deletion schema.SoftDelete) error {
+	_, err := repository.SoftDelete[schema.Note](ctx, bson.M{"jobApplicationID": bson.M{"$in": appIDs}}, deleter(deletion), *deletion.DeletedAt)
+	return err
+}
+
+// deleter is the ID of the user who made deletion, or primitive.NilObjectID if it is not known.
+func deleter(deletion schema.SoftDelete) primitive.ObjectID {
+	if deletion.DeletedBy == nil {
+		return primitive.NilObjectID
+	}
+	return *deletion.DeletedBy
+}
+
+// restoreApplications restores the applications matching filter which were deleted at deletedAt,
+// and the notes which were deleted with them.
+func restoreApplications(ctx context.Context, filter bson.M, deletedAt time.Time) error {
+	deleted := bson.M{"deletedAt": deletedAt}
+	for k, v := range filter {
+		deleted[k] = v
+	}
+	apps, err := repository.FindAll[schema.JobApplication](ctx, deleted)
+	if err != nil || len(apps) == 0 {
+		return err
+	}
+	ids := make([]primitive.ObjectID, 0, len(apps))
+	for _, app := range apps {
+		ids = append(ids, app.ID)
+	}
+	if _, err := repository.Restore[schema.JobApplication](ctx, bson.M{"_id": bson.M{"$in": ids}, "deletedAt": deletedAt}); err != nil {
+		return err
+	}
+	_, err = repository.Restore[schema.Note](ctx, bson.M{"jobApplicationID": bson.M{"$in": ids}, "deletedAt": deletedAt})
+	return err
+}
+
+// RunDeletedPurger permanently deletes the documents soft-deleted longer ago than the retention window,
+// then keeps doing so every purgeInterval until ctx is cancelled.
+func RunDeletedPurger(ctx context.Context) {
+	retention := deletedRetention()
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		purgeDeleted(ctx, time.Now().Add(-retention))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deletedRetention reads DELETED_RETENTION_DAYS.
+func deletedRetention() time.Duration {
+	days, err := strconv.Atoi(config.LoadEnv("DELETED_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		return defaultDeletedRetention
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// purgeDeleted permanently deletes every kind of document which was soft-deleted before the given time.
+func purgeDeleted(ctx context.Context, before time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	for name, kind := range deletedKinds {
+		purged, err := kind.purge(ctx, before)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Could not purge deleted %s: %s", name, err))
+			continue
+		}
+		if purged > 0 {
+			slog.Info(fmt.Sprintf("Purged %d deleted %s", purged, name))
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package controller
 
 import (
+	"bytes"
+	"errors"
 	"io"
+	"log/slog"
 	"mime"
 	"net/http"
 	"os"
@@ -11,8 +14,10 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/authz"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/storage"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -60,7 +65,7 @@ func (fc FileController) Upload(c *gin.Context) {
 		var userDoc struct {
 			Role string `bson:"role"`
 		}
-		err = userCollection.FindOne(c.Request.Context(), bson.M{"_id": userID}).Decode(&userDoc)
+		err = userCollection.FindOne(c.Request.Context(), bson.M{"_id": userID, "deletedAt": nil}).Decode(&userDoc)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify user role"})
 			return
@@ -94,35 +99,38 @@ func (fc FileController) Upload(c *gin.Context) {
 		return
 	}
 
-	// Read file content
-	fileBytes, err := io.ReadAll(file)
+	// Stream file content to the blob store
+	fileID := primitive.NewObjectID()
+	store := storage.GetBlobStore()
+	size, err := store.Put(c.Request.Context(), fileID.Hex(), file)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read file"})
+		slog.Error("failed to store file content: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
 		return
 	}
 
 	// Create file document
 	fileDoc := schema.File{
+		ID:            fileID,
 		UserID:        userID,
-		Content:       fileBytes,
+		StorageKey:    fileID.Hex(),
 		FileExtension: "pdf",
 		Filename:      header.Filename,
 		ContentType:   header.Header.Get("Content-Type"),
-		Size:          header.Size,
+		Size:          size,
 		Category:      category,
 		UploadDate:    time.Now(),
 	}
 
 	// Save to database
 	collection := db.Collection(fc.baseController.collectionName)
-	result, err := collection.InsertOne(c.Request.Context(), fileDoc)
-	if err != nil {
+	if _, err := collection.InsertOne(c.Request.Context(), fileDoc); err != nil {
+		// don't leave orphaned content behind
+		store.Delete(c.Request.Context(), fileDoc.StorageKey)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
 		return
 	}
 
-	fileDoc.ID = result.InsertedID.(primitive.ObjectID)
-
 	// Return metadata only
 	c.JSON(http.StatusCreated, gin.H{
 		"id":            fileDoc.ID,
@@ -165,11 +173,7 @@ func (fc FileController) Download(c *gin.Context) {
 		return
 	}
 
-	db := database.GetDatabase()
-	collection := db.Collection(fc.baseController.collectionName)
-
-	var fileDoc schema.File
-	err = collection.FindOne(c.Request.Context(), bson.M{"_id": objectID}).Decode(&fileDoc)
+	fileDoc, err := authz.Load(c.Request.Context(), c, authz.File, filePolicy.Load, objectID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
 		return
@@ -183,17 +187,7 @@ func (fc FileController) Download(c *gin.Context) {
 		return
 	}
 
-	// Sanitize headers derived from user input before sending
-	contentType := sanitizeHeaderValue(fileDoc.ContentType)
-	if contentType == "" {
-		contentType = "application/octet-stream"
-	}
-
-	filename := sanitizeFilename(fileDoc.Filename)
-
-	c.Header("Content-Type", contentType)
-	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
-	c.Data(http.StatusOK, fileDoc.ContentType, fileDoc.Content)
+	serveFile(c, fileDoc)
 }
 
 // ListByUser godoc
@@ -287,6 +281,7 @@ func (fc FileController) ListByUser(c *gin.Context) {
 // @Failure      401  {object}  map[string]string
 // @Failure      403  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
 // @Router       /files/{id} [delete]
 func (fc FileController) Delete(c *gin.Context) {
@@ -308,8 +303,7 @@ func (fc FileController) Delete(c *gin.Context) {
 	collection := db.Collection(fc.baseController.collectionName)
 
 	// First, check if file exists and user owns it
-	var fileDoc schema.File
-	err = collection.FindOne(c.Request.Context(), bson.M{"_id": objectID}).Decode(&fileDoc)
+	fileDoc, err := authz.Load(c.Request.Context(), c, authz.File, filePolicy.Load, objectID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
 		return
@@ -323,6 +317,19 @@ func (fc FileController) Delete(c *gin.Context) {
 		return
 	}
 
+	// The PDF of a resume version is attached to applications, which must keep it
+	versions, err := db.Collection(schema.Resume{}.GetCollectionName()).CountDocuments(
+		c.Request.Context(), bson.M{"fileID": objectID},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete file"})
+		return
+	}
+	if versions > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "this file is the PDF of a resume version, which cannot be deleted"})
+		return
+	}
+
 	// Delete the file
 	result, err := collection.DeleteOne(c.Request.Context(), bson.M{"_id": objectID})
 	if err != nil {
@@ -335,6 +342,13 @@ func (fc FileController) Delete(c *gin.Context) {
 		return
 	}
 
+	if fileDoc.StorageKey != "" {
+		if err := storage.GetBlobStore().Delete(c.Request.Context(), fileDoc.StorageKey); err != nil {
+			// The metadata is already gone, so the file is unreachable either way.
+			slog.Warn("failed to delete file content " + fileDoc.StorageKey + ": " + err.Error())
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "file deleted successfully"})
 }
 
@@ -378,37 +392,28 @@ func (fc FileController) GetApplicantFiles(c *gin.Context) {
 
 	db := database.GetDatabase()
 
-	// 1. Find the job application
-	var application schema.JobApplication
-	err = db.Collection("job_applications").FindOne(
-		c.Request.Context(),
-		bson.M{"_id": appObjectID},
-	).Decode(&application)
-	if err != nil {
+	// 1. Find the job application and its job, unless AccessControlMiddleware already did
+	resource, err := authz.Load(c.Request.Context(), c, authz.Application, applicationPolicy.Load, appObjectID)
+	if errors.Is(err, authz.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "application not found"})
 		return
 	}
-
-	// 2. Find the job to verify company ownership
-	var job schema.Job
-	err = db.Collection("jobs").FindOne(
-		c.Request.Context(),
-		bson.M{"_id": application.JobID},
-	).Decode(&job)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve application"})
 		return
 	}
+	application := resource.Application
 
-	// 3. Verify the requesting user (company) owns the job
-	if job.CompanyID != requestingUserID {
+	// 2. Verify the requesting user works for the company which posted the job
+	subject := authz.Subject{ID: requestingUserID, Role: requestingUserRole}
+	if err := applicationPolicy.Authorize(subject, authz.Read, resource); err != nil {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "you can only access files for applications to your own jobs",
 		})
 		return
 	}
 
-	// 4. Get applicant's files (only relevant categories: resume, transcript, certification)
+	// 3. Get applicant's files (only relevant categories: resume, transcript, certification)
 	cursor, err := db.Collection("files").Find(
 		c.Request.Context(),
 		bson.M{
@@ -547,37 +552,28 @@ func (fc FileController) DownloadApplicantFile(c *gin.Context) {
 
 	db := database.GetDatabase()
 
-	// 1. Find the job application
-	var application schema.JobApplication
-	err = db.Collection("job_applications").FindOne(
-		c.Request.Context(),
-		bson.M{"_id": appObjectID},
-	).Decode(&application)
-	if err != nil {
+	// 1. Find the job application and its job, unless AccessControlMiddleware already did
+	resource, err := authz.Load(c.Request.Context(), c, authz.Application, applicationPolicy.Load, appObjectID)
+	if errors.Is(err, authz.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "application not found"})
 		return
 	}
-
-	// 2. Find the job to verify company ownership
-	var job schema.Job
-	err = db.Collection("jobs").FindOne(
-		c.Request.Context(),
-		bson.M{"_id": application.JobID},
-	).Decode(&job)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve application"})
 		return
 	}
+	application := resource.Application
 
-	// 3. Verify the requesting user (company) owns the job
-	if job.CompanyID != requestingUserID {
+	// 2. Verify the requesting user works for the company which posted the job
+	subject := authz.Subject{ID: requestingUserID, Role: requestingUserRole}
+	if err := applicationPolicy.Authorize(subject, authz.Read, resource); err != nil {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "you can only access files for applications to your own jobs",
 		})
 		return
 	}
 
-	// 4. Get the file and verify it belongs to the applicant
+	// 3. Get the file and verify it belongs to the applicant
 	var fileDoc schema.File
 	err = db.Collection("files").FindOne(
 		c.Request.Context(),
@@ -588,7 +584,7 @@ func (fc FileController) DownloadApplicantFile(c *gin.Context) {
 		return
 	}
 
-	// 5. Verify the file belongs to the applicant
+	// 4. Verify the file belongs to the applicant
 	if fileDoc.UserID != application.ApplicantID {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "file does not belong to this applicant",
@@ -596,14 +592,43 @@ func (fc FileController) DownloadApplicantFile(c *gin.Context) {
 		return
 	}
 
-	// 6. Serve the file — sanitize header values first
+	// 5. Serve the file
+	serveFile(c, fileDoc)
+}
+
+// serveFile streams the content of fileDoc to the client.
+// Files uploaded before the blob store existed still carry their content inline.
+func serveFile(c *gin.Context, fileDoc schema.File) {
+	var (
+		content io.ReadCloser
+		size    int64
+		err     error
+	)
+	if fileDoc.StorageKey != "" {
+		content, size, err = storage.GetBlobStore().Get(c.Request.Context(), fileDoc.StorageKey)
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "file content not found"})
+			return
+		}
+		if err != nil {
+			slog.Error("failed to open file content " + fileDoc.StorageKey + ": " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read file"})
+			return
+		}
+	} else {
+		content = io.NopCloser(bytes.NewReader(fileDoc.Content))
+		size = int64(len(fileDoc.Content))
+	}
+	defer content.Close()
+
+	// Sanitize headers derived from user input before sending
 	contentType := sanitizeHeaderValue(fileDoc.ContentType)
 	if contentType == "" {
 		contentType = "application/octet-stream"
 	}
 	filename := sanitizeFilename(fileDoc.Filename)
 
-	c.Header("Content-Type", contentType)
-	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
-	c.Data(http.StatusOK, fileDoc.ContentType, fileDoc.Content)
+	c.DataFromReader(http.StatusOK, size, contentType, content, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": filename}),
+	})
 }
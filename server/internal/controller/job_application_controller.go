@@ -11,11 +11,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/dto"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/email"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/outbox"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // JobApplicationController handles JobApplication CRUD operations
@@ -35,7 +35,7 @@ func NewJobApplicationController() JobApplicationController {
 
 // Query godoc
 // @Summary      Query job applications
-// @Description  Get all job applications that match query parameters
+// @Description  Get all job applications that match query parameters, each with its applicant, answers to the screening questions of the job, and the outcome of its knockout rules
 // @Tags         Applications
 // @Accept       json
 // @Produce      json
@@ -43,7 +43,9 @@ func NewJobApplicationController() JobApplicationController {
 // @Param        jobID query string false "Job ID"
 // @Param        companyID query string false "Company ID"
 // @Param        status query string false "Status of the application"
-// @Success      200  {array}  schema.ApplicationWithApplicant
+// @Param        limit query integer false "Page size (default 20, max 100)"
+// @Param        cursor query string false "Cursor from the previous page's nextCursor"
+// @Success      200  {object}  repository.Page[schema.ApplicationWithApplicant]
 // @Failure      400  {object} map[string]string
 // @Failure      500  {object} map[string]string
 // @Router       /apply/query [get]
@@ -54,17 +56,28 @@ func (jc JobApplicationController) Query(c *gin.Context) {
 		return
 	}
 
+	page, err := getPageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page.SortField, page.SortOrder = "createdAt", -1
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	findOpts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
-	applications, err := repository.FindAll[schema.JobApplication](ctx, jobApplicationFilter, findOpts)
+	applicationPage, err := repository.FindPage[schema.JobApplication](ctx, jobApplicationFilter, page)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	applications := applicationPage.Data
 	if len(applications) == 0 {
-		c.JSON(http.StatusOK, []any{})
+		c.JSON(http.StatusOK, pageResponse([]any{}, "", false))
 		return
 	}
 
@@ -102,7 +115,7 @@ func (jc JobApplicationController) Query(c *gin.Context) {
 		combinedResults = append(combinedResults, result)
 	}
 
-	c.JSON(http.StatusOK, combinedResults)
+	c.JSON(http.StatusOK, pageResponse(combinedResults, applicationPage.NextCursor, applicationPage.HasMore))
 }
 
 func jobApplicationFilter(c *gin.Context) (bson.M, bool) {
@@ -158,6 +171,9 @@ func jobApplicationFilter(c *gin.Context) (bson.M, bool) {
 
 	// Loop through query params
 	for key, value := range c.Request.URL.Query() {
+		if paginationParams[key] {
+			continue
+		}
 		if fn, ok := allowedParams[key]; ok {
 			val, err := fn(value[0])
 			if err != nil {
@@ -181,102 +197,149 @@ func jobApplicationFilter(c *gin.Context) (bson.M, bool) {
 
 // Create godoc
 // @Summary      Create new job application
-// @Description  Submit a job application for a specific job
+// @Description  Submit a job application for a specific job, optionally with the ID of a version of the applicant's resume. Only published jobs before their deadline accept applications. The answers must answer every required screening question of the job. When the job has autoReject on, applications failing any of its knockout rules are saved as REJECTED, with the outcome of every rule in knockout.
 // @Tags         Applications
 // @Accept       json
 // @Produce      json
 // @Param        request body schema.JobApplication true "Job Application JSON"
-// @Success      200  {object} schema.JobApplication
+// @Success      201  {object} schema.JobApplication
 // @Failure      400  {object} map[string]string
 // @Failure      500  {object} map[string]string
 // @Router       /apply/ [post]
 func (jc JobApplicationController) Create(c *gin.Context) {
-	companyEmail, err := jc.shouldNotifyCompany(c)
-	if err {
+	userInfo := getUserForLogging(c)
+	var app schema.JobApplication
+	if err := c.ShouldBindJSON(&app); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := app.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	jc.baseController.Create(c)
-
-	if companyEmail != "" {
-		var raw schema.JobApplication
-		if err := c.ShouldBindBodyWithJSON(&raw); err == nil {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-
-			applicantID := raw.ApplicantID
-			jobID := raw.JobID
 
-			var validApplicantID, validJobID primitive.ObjectID
-			var convErr error
-			// Validate applicant ID
-			validApplicantID, convErr = primitive.ObjectIDFromHex(applicantID.Hex())
-			if convErr != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ApplicantID format"})
-				return
-			}
-			// Validate job ID
-			validJobID, convErr = primitive.ObjectIDFromHex(jobID.Hex())
-			if convErr != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JobID format"})
-				return
-			}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-			applicant, _ := repository.FindOne[schema.User](ctx, validApplicantID)
-			job, _ := repository.FindOne[schema.Job](ctx, validJobID)
+	job, err := repository.FindOne[schema.Job](ctx, app.JobID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !job.AcceptsApplications(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "this job is not open for applications"})
+		return
+	}
+	applicant := schema.Applicant{}
+	if app.ResumeID != nil {
+		cv, err := repository.FindOne[schema.Resume](ctx, *app.ResumeID)
+		if err != nil || cv.UserID != app.ApplicantID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "resumeID is not a resume of the applicant"})
+			return
+		}
+		applicant.Resume = &cv
+	}
+	answers, err := job.Questions.Answer(app.Answers)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	app.Answers = answers
 
-			subject := "New applicant applied to your job"
-			body := fmt.Sprintf(
-				"Hello,\n\n%s has applied to your job \"%s\".\n\nPlease review the application in your applicant board.\n\nBest regards,\nJob Applier 3000",
-				applicant.Name,
-				job.Title,
-			)
+	if job.AutoReject && len(job.KnockoutRules) > 0 {
+		if user, err := repository.FindOne[schema.User](ctx, app.ApplicantID); err == nil {
+			applicant.Profile, _ = user.UserInfo.(*schema.JobSeekerInfo)
+		}
+		result := schema.EvaluateKnockout(job, app, applicant, time.Now())
+		app.Knockout = &result
+		if !result.Passed {
+			app.Reject(result)
+		}
+	}
 
-			if err := email.Send(companyEmail, subject, body); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send email"})
-				return
-			}
+	res, err := repository.InsertOne(ctx, app)
+	if err != nil {
+		msg := "Create Application failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	slog.Info(userInfo + "Created Application")
+	c.JSON(http.StatusCreated, res)
+
+	// only send emails once the application is actually saved
+	if app.Status == schema.StatusRejected {
+		slog.Info(fmt.Sprintf("%sAuto-rejected Application %v: %s", userInfo, res.InsertedID, app.StatusHistory[len(app.StatusHistory)-1].ReasonCode))
+		if job.RejectionEmail.Send {
+			jc.notifyRejectedApplicant(ctx, c, job, app)
 		}
+		return
+	}
+	if job.EmailNotifications {
+		jc.notifyCompany(ctx, c, job, app)
 	}
 }
 
-// shouldNotifyCompany determines whether company should be notified when an applicant applied for a job or not.
-func (jc JobApplicationController) shouldNotifyCompany(c *gin.Context) (companyEmail string, err bool) {
-	var raw schema.JobApplication
-	if err := c.ShouldBindBodyWithJSON(&raw); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return "", true
+// notifyRejectedApplicant queues the rejection email of job for the auto-rejected application app,
+// to be sent once the delay of the job has passed.
+func (jc JobApplicationController) notifyRejectedApplicant(ctx context.Context, c *gin.Context, job schema.Job, app schema.JobApplication) {
+	applicant, err := repository.FindOne[schema.User](ctx, app.ApplicantID)
+	if err != nil || applicant.Email == "" {
+		return
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	job, jobErr := repository.FindOne[schema.Job](ctx, raw.JobID)
-	if jobErr != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": jobErr.Error()})
-		return "", true
+	company, _ := repository.FindOne[schema.User](ctx, job.CompanyID)
+
+	msg, err := email.Compose(applicant.Email, applicant.Locale, email.ApplicationRejected{
+		ApplicantName: applicant.Name,
+		JobTitle:      job.Title,
+		CompanyName:   company.Name,
+		Message:       job.RejectionEmail.Message,
+	})
+	if err != nil {
+		slog.Warn(getUserForLogging(c) + "rejection email failed: " + err.Error())
+		return
 	}
-	if !job.EmailNotifications {
-		return "", false
+	sendAt := time.Now().Add(time.Duration(job.RejectionEmail.DelayHours) * time.Hour)
+	if err := outbox.EnqueueAt(ctx, sendAt, msg); err != nil {
+		slog.Warn(getUserForLogging(c) + "rejection email failed: " + err.Error())
 	}
-	company, companyErr := repository.FindOne[schema.User](ctx, job.CompanyID)
-	if companyErr != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": companyErr.Error()})
-		return "", true
+}
+
+// notifyCompany emails the company which posted job about the new application app.
+func (jc JobApplicationController) notifyCompany(ctx context.Context, c *gin.Context, job schema.Job, app schema.JobApplication) {
+	company, err := repository.FindOne[schema.User](ctx, job.CompanyID)
+	if err != nil || company.Email == "" {
+		return
+	}
+	applicant, _ := repository.FindOne[schema.User](ctx, app.ApplicantID)
+
+	msg, err := email.Compose(company.Email, company.Locale, email.NewApplicant{ApplicantName: applicant.Name, JobTitle: job.Title})
+	if err != nil {
+		slog.Warn(getUserForLogging(c) + "new applicant notification failed: " + err.Error())
+		return
+	}
+	if err := outbox.Enqueue(ctx, msg); err != nil {
+		slog.Warn(getUserForLogging(c) + "new applicant notification failed: " + err.Error())
 	}
-	return company.Email, false
 }
 
 // Update godoc
-// @Summary      Update job application
-// @Description  Update job application data (including status changes)
+// @Summary      Change job application status
+// @Description  Move an application to another step of its lifecycle (PENDING -> SCREENING -> INTERVIEW -> OFFER -> ACCEPTED, or REJECTED/WITHDRAWN). Illegal transitions are rejected and every change is recorded in statusHistory. The job is closed once as many applications are ACCEPTED as it has positions.
 // @Tags         Applications
 // @Accept       json
 // @Produce      json
 // @Param        id path string true "Application ID"
-// @Param        request body schema.JobApplication true "Updated Application JSON"
+// @Param        request body dto.ApplicationStatusChange true "New status and optional reason"
 // @Success      200  {object} schema.JobApplication
 // @Failure      400  {object} map[string]string
+// @Failure      403  {object} map[string]string
+// @Failure      404  {object} map[string]string
+// @Failure      409  {object} map[string]string
 // @Failure      500  {object} map[string]string
 // @Router       /apply/{id} [put]
 func (jc JobApplicationController) Update(c *gin.Context) {
+	userInfo := getUserForLogging(c)
 	id := c.Param("id")
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -284,22 +347,135 @@ func (jc JobApplicationController) Update(c *gin.Context) {
 		return
 	}
 
-	jc.baseController.Update(c)
+	var body dto.ApplicationStatusChange
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	next, err := schema.ParseApplicationStatus(body.Status)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actorID, actorRole, err := getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	// Only the applicant decides to withdraw, and the applicant can do nothing else.
+	isApplicant := actorRole == schema.RoleJobSeeker
+	if actorRole != "admin" && isApplicant != (next == schema.StatusWithdrawn) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you cannot move this application to " + string(next)})
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	updatedApp, err := repository.FindOne[schema.JobApplication](ctx, objID)
+	app, err := repository.FindOne[schema.JobApplication](ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+		return
+	}
+
+	current := app.CurrentStatus()
+	if !current.CanTransitionTo(next) {
+		msg := fmt.Sprintf("cannot change application status from %s to %s", current, next)
+		slog.Warn(userInfo + msg + ": " + id)
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	change := schema.StatusChange{
+		From:      current,
+		To:        next,
+		ActorID:   actorID,
+		ActorRole: actorRole,
+		Reason:    email.SanitizeEmailBodyField(body.Reason),
+		ChangedAt: time.Now(),
+	}
+	// only apply the change if nobody changed the status since we read it
+	filter := bson.M{"_id": objID, "status": app.Status}
+	update := bson.M{
+		"$set":  bson.M{"status": next},
+		"$push": bson.M{"statusHistory": change},
+	}
+	res, err := repository.UpdateOne[schema.JobApplication](ctx, filter, update)
 	if err != nil {
-		fmt.Println("Failed to fetch updated application for notification:", err)
+		msg := "Update Application status failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	if res.MatchedCount == 0 {
+		// someone else changed the status between our read and write
+		c.JSON(http.StatusConflict, gin.H{"error": "application status was changed by someone else, please reload"})
 		return
 	}
 
-	jc.notifyApplicantOnStatusChange(ctx, updatedApp)
+	app.Status = next
+	app.StatusHistory = append(app.StatusHistory, change)
+	slog.Info(fmt.Sprintf("%sChanged Application %s status: %s -> %s", userInfo, id, current, next))
+
+	if err := jc.notifyApplicantOnStatusChange(ctx, app, change); err != nil {
+		slog.Warn(userInfo + "status change notification failed: " + err.Error())
+	}
+	if next == schema.StatusAccepted {
+		if err := closeJobIfFilled(ctx, app.JobID); err != nil {
+			slog.Warn(userInfo + "closing filled job failed: " + err.Error())
+		}
+	}
+
+	c.JSON(http.StatusOK, app)
+}
+
+// Timeline godoc
+// @Summary      Get job application timeline
+// @Description  Returns every status change of an application: who made it, when, and why, and the outcome of the knockout rules of the job.
+// @Tags         Applications
+// @Produce      json
+// @Param        id   path      string  true  "Application ID"
+// @Success      200  {object}  map[string]any
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /apply/{id}/timeline [get]
+func (jc JobApplicationController) Timeline(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	app, err := repository.FindOne[schema.JobApplication](ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+		return
+	}
+
+	timeline := app.StatusHistory
+	if timeline == nil {
+		timeline = []schema.StatusChange{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"applicationID": app.ID,
+		"status":        app.CurrentStatus(),
+		"timeline":      timeline,
+		"knockout":      app.Knockout,
+	})
 }
 
 // notifyApplicantOnStatusChange notifies the applicant when their application status changes
-func (jc JobApplicationController) notifyApplicantOnStatusChange(ctx context.Context, app schema.JobApplication) error {
+func (jc JobApplicationController) notifyApplicantOnStatusChange(ctx context.Context, app schema.JobApplication, change schema.StatusChange) error {
+	// the applicant withdrew by themselves, no need to tell them
+	if change.To == schema.StatusWithdrawn {
+		return nil
+	}
+
 	applicant, err := repository.FindOne[schema.User](ctx, app.ApplicantID)
 	if err != nil {
 		msg := "failed to fetch applicant for notification"
@@ -314,39 +490,20 @@ func (jc JobApplicationController) notifyApplicantOnStatusChange(ctx context.Con
 		return errors.New(msg)
 	}
 
-	var subject, body string
-
-	switch app.Status {
-	case "ACCEPTED":
-		subject = "Congratulations! Your job application has been accepted"
-		body = fmt.Sprintf(
-			"Hello %s,\n\nWe are pleased to inform you that your application for the job \"%s\" has been ACCEPTED.\n\nOur team will contact you soon with the next steps.\n\nBest regards,\nJob Applier 3000",
-			applicant.Name,
-			job.Title,
-		)
-	case "REJECTED":
-		subject = "Update on your job application"
-		body = fmt.Sprintf(
-			"Hello %s,\n\nWe regret to inform you that your application for the job \"%s\" has been REJECTED.\n\nWe appreciate your interest and encourage you to apply for future opportunities.\n\nBest regards,\nJob Applier 3000",
-			applicant.Name,
-			job.Title,
-		)
-	default:
-		subject = "Your job application status has been updated"
-		body = fmt.Sprintf(
-			"Hello %s,\n\nThe status of your application for the job \"%s\" has been updated to: %s\n\nBest regards,\nJob Applier 3000",
-			applicant.Name,
-			job.Title,
-			app.Status,
-		)
-	}
-
-	return email.Send(applicant.Email, subject, body)
+	msg, err := email.Compose(applicant.Email, applicant.Locale, email.ApplicationStatusChanged{
+		ApplicantName: applicant.Name,
+		JobTitle:      job.Title,
+		Status:        string(change.To),
+	})
+	if err != nil {
+		return err
+	}
+	return outbox.Enqueue(ctx, msg)
 }
 
 // Delete godoc
 // @Summary      Delete a job application
-// @Description  Remove a job application by its ID
+// @Description  Remove a job application and its notes by its ID. They can be restored by an admin until they are purged.
 // @Tags         Applications
 // @Accept       json
 // @Produce      json
@@ -357,15 +514,31 @@ func (jc JobApplicationController) notifyApplicantOnStatusChange(ctx context.Con
 // @Router       /apply/{id} [delete]
 func (jc JobApplicationController) Delete(c *gin.Context) {
 	jc.baseController.Delete(c)
+	if c.Writer.Status() != http.StatusOK {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	id, _ := primitive.ObjectIDFromHex(c.Param("id"))
+	app, err := repository.FindDeleted[schema.JobApplication](ctx, id)
+	if err == nil {
+		err = deleteNotesOf(ctx, []primitive.ObjectID{id}, app.SoftDelete)
+	}
+	if err != nil {
+		slog.Error(getUserForLogging(c) + "deleting notes of application " + id.Hex() + " failed: " + err.Error())
+	}
 }
 
 // RetrieveAll godoc
 // @Summary      Get all job applications
-// @Description  Retrieve all job applications in the system
+// @Description  Retrieve all job applications in the system, one page at a time
 // @Tags         Applications
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}   schema.JobApplication
+// @Param        limit query integer false "Page size (default 20, max 100)"
+// @Param        cursor query string false "Cursor from the previous page's nextCursor"
+// @Success      200  {object}  repository.Page[schema.JobApplication]
 // @Failure      500  {object}  map[string]string
 // @Router       /apply/ [get]
 func (jc JobApplicationController) RetrieveAll(c *gin.Context) {
@@ -2,14 +2,20 @@ package controller
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"log/slog"
 	"net/http"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/audit"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/authz"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/dto"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/email"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/outbox"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
 	"go.mongodb.org/mongo-driver/bson"
@@ -48,12 +54,14 @@ func (jc JobController) GetLatestPublic(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Filter: only public jobs, not expired
+	// Filter: only public jobs, published and not expired
 	now := time.Now()
 	filter := bson.M{
 		"visibility":          "public",
+		"status":              schema.JobPublished,
 		"postOpenDate":        bson.M{"$lte": now},
 		"applicationDeadline": bson.M{"$gte": now},
+		"deletedAt":           nil,
 	}
 
 	findOptions := options.Find().
@@ -87,185 +95,64 @@ func (jc JobController) GetLatestPublic(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param id query string false "Job ID (ObjectID)"
+// @Param q query string false "Full-text search on title, summary, required skills and description"
 // @Param title query string false "Title (regex match)"
 // @Param companyID query string false "Company ID (ObjectID)"
 // @Param location query string false "Location (regex match)"
-// @Param minSalary query integer false "Minimum salary"
-// @Param maxSalary query integer false "Maximum salary"
+// @Param minSalary query number false "Lowest acceptable salary, matches jobs whose salary band reaches it"
+// @Param maxSalary query number false "Highest acceptable salary, matches jobs whose salary band starts below it"
+// @Param currency query string false "Currency of minSalary and maxSalary (e.g. THB), postings in other currencies are converted"
 // @Param workType query string false "Work type (e.g., Full-time, Part-time)"
 // @Param workArrangement query string false "Work arrangement (e.g., Remote, On-site)"
+// @Param status query string false "Job status: draft | scheduled | published | closed | archived. Only members of the company in companyID may ask for other than published, and see jobs in every status without it"
 // @Param postOpenDate query string false "Post open date (1d or 6w)"
 // @Param latest query bool false "If true, returns the latest 3 jobs"
-// @Param sort query string false "Sorting: dateAsc | dateDesc | title"
-// @Success 200 {array} schema.Job
+// @Param sort query string false "Sorting: relevance | dateAsc | dateDesc | title (relevance is the default with q)"
+// @Param limit query integer false "Page size (default 20, max 100)"
+// @Param cursor query string false "Cursor from the previous page's nextCursor"
+// @Success 200 {object} repository.Page[dto.JobQueryResult]
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /jobs/query [get]
 func (jc JobController) Query(c *gin.Context) {
-	// Allowed query params
-	allowedParams := map[string]func(string) (interface{}, error){
-		"id": func(v string) (interface{}, error) {
-			if v == "" {
-				return nil, fmt.Errorf("id parameter is empty")
-			}
-			return primitive.ObjectIDFromHex(v)
-		},
-		"title": func(v string) (interface{}, error) {
-			if v == "" {
-				return nil, nil
-			}
-			return bson.M{"$regex": v, "$options": "i"}, nil
-		},
-		"companyID": func(v string) (interface{}, error) {
-			if v == "" {
-				return nil, nil
-			}
-			return primitive.ObjectIDFromHex(v)
-		},
-		"location": func(v string) (interface{}, error) {
-			if v == "" {
-				return nil, nil
-			}
-			return bson.M{"$regex": v, "$options": "i"}, nil
-		},
-		"minSalary": func(v string) (interface{}, error) {
-			if v == "" {
-				return nil, nil
-			}
-			return toInt(v), nil
-		},
-		"maxSalary": func(v string) (interface{}, error) {
-			if v == "" {
-				return nil, nil
-			}
-			return toInt(v), nil
-		},
-		"workType": func(v string) (interface{}, error) {
-			if v == "" {
-				return nil, nil
-			}
-			return bson.M{"$regex": v, "$options": "i"}, nil
-		},
-		"workArrangement": func(v string) (interface{}, error) {
-			if v == "" {
-				return nil, nil
-			}
-			return bson.M{"$regex": v, "$options": "i"}, nil
-		},
-		"postOpenDate": func(v string) (interface{}, error) {
-			if v == "" {
-				return nil, nil
-			}
-
-			now := time.Now()
-			midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-
-			switch v {
-			case "1d":
-				return bson.M{"$gte": midnight.AddDate(0, 0, -1)}, nil
-			case "6w":
-				return bson.M{"$gte": midnight.AddDate(0, 0, -7*6)}, nil
-			default:
-				return nil, nil
-			}
-		},
-		"latest": func(v string) (interface{}, error) {
-			if v == "true" {
-				return true, nil
-			}
-			return nil, nil
-		},
-		"sort": func(v string) (interface{}, error) {
-			switch v {
-			case "dateAsc", "dateDesc", "title":
-				return v, nil
-			case "", "null":
-				return nil, nil
-			default:
-				return nil, fmt.Errorf("invalid sort value")
-			}
-		},
+	query, err := parseJobQuery(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-
-	filter := bson.M{}
-	salaryFilter := bson.M{}
-	findOptions := options.Find()
-
-	// Apply query params
-	for key, values := range c.Request.URL.Query() {
-		if key == "sort" || key == "latest" {
-			continue
-		}
-		if fn, ok := allowedParams[key]; ok {
-			val, err := fn(values[0])
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-				return
-			}
-			if val != nil {
-				switch key {
-				case "id":
-					filter["_id"] = val
-				case "minSalary":
-					salaryFilter["$gte"] = val
-				case "maxSalary":
-					salaryFilter["$lte"] = val
-				case "postOpenDate":
-					filter["postOpenDate"] = val
-				case "latest":
-					now := time.Now()
-					filter["postOpenDate"] = bson.M{"$lte": now}
-					findOptions.SetSort(bson.D{{Key: "postOpenDate", Value: -1}})
-					findOptions.SetLimit(3)
-				default:
-					filter[key] = val
-				}
-			}
-		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported query parameter: " + key})
-			return
-		}
+	page, err := getPageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if len(salaryFilter) > 0 {
-		filter["salary"] = salaryFilter
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	// Handle latest parameter
-	latestParam := c.Query("latest")
-	if latestParam == "true" {
-		now := time.Now()
-		filter["postOpenDate"] = bson.M{"$lte": now}
-		findOptions.SetSort(bson.D{{Key: "postOpenDate", Value: -1}})
-		findOptions.SetLimit(3)
+	// members of a company see all of its jobs, everyone else only published ones
+	companyID, ok := query.filter["companyID"].(primitive.ObjectID)
+	if ok && mayManageJobsOf(ctx, c, companyID) {
+		if query.status == "" {
+			delete(query.filter, "status")
+		}
+	} else if query.status != "" && query.status != schema.JobPublished {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the company which posts a job can see it while it is " + string(query.status)})
+		return
 	}
 
-	// Handle sort parameter
-	sortParam := c.Query("sort")
-	switch sortParam {
-	case "dateAsc":
-		findOptions.SetSort(bson.D{{Key: "postOpenDate", Value: 1}})
-	case "dateDesc":
-		findOptions.SetSort(bson.D{{Key: "postOpenDate", Value: -1}})
-	case "title":
-		findOptions.SetSort(bson.D{{Key: "title", Value: 1}})
-	case "", "null":
-		// no sorting
-	default:
-		// ignore invalid sort
+	jobs, err := findJobs(ctx, query, page)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	jobs, err := repository.FindAll[schema.Job](ctx, filter, findOptions)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	if len(jobs) == 0 {
+	if len(jobs.Data) == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"message": "No jobs found"})
 		return
 	}
@@ -273,13 +160,6 @@ func (jc JobController) Query(c *gin.Context) {
 	c.JSON(http.StatusOK, jobs)
 }
 
-// small helper
-func toInt(s string) int {
-	var n int
-	_, _ = fmt.Sscan(s, &n)
-	return n
-}
-
 // Create godoc
 // @Summary Create a new job
 // @Description Add a new job posting to the database
@@ -289,22 +169,90 @@ func toInt(s string) int {
 // @Param job body schema.Job true "Job data"
 // @Success 201 {object} schema.Job
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /jobs/ [post]
 func (jc JobController) Create(c *gin.Context) {
+	if jc.validatePoster(c) {
+		return
+	}
 	jc.baseController.Create(c)
 }
 
+// validatePoster ensures the requesting user may post jobs for the organization in companyID:
+// owners and recruiters can, viewers cannot.
+func (jc JobController) validatePoster(c *gin.Context) (shouldReturn bool) {
+	enableAuth, _ := strconv.ParseBool(os.Getenv("ENABLE_AUTH"))
+	if !enableAuth {
+		return false
+	}
+	userID, role, err := getUserFromMiddleware(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return true
+	}
+	if role == "admin" {
+		return false
+	}
+
+	var job schema.Job
+	if err := c.ShouldBindBodyWithJSON(&job); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Create Job failed: incorrect request body"})
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	org, err := findOrganization(ctx, job.CompanyID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Create Job failed"})
+		return true
+	}
+	subject := authz.Subject{ID: userID, Role: role}
+	if err != nil || jobPolicy.Authorize(subject, authz.Update, authz.JobResource{Job: job, Organization: org}) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you can only post jobs for your own company"})
+		return true
+	}
+	return false
+}
+
 // RetrieveAll godoc
 // @Summary Get all jobs
-// @Description Retrieve all job postings
+// @Description Retrieve all published job postings, one page at a time. Admins get jobs in every status.
 // @Tags jobs
 // @Produce  json
-// @Success 200 {array} schema.Job
+// @Param limit query integer false "Page size (default 20, max 100)"
+// @Param cursor query string false "Cursor from the previous page's nextCursor"
+// @Success 200 {object} repository.Page[schema.Job]
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /jobs/ [get]
 func (jc JobController) RetrieveAll(c *gin.Context) {
-	jc.baseController.RetrieveAll(c)
+	page, err := getPageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter := bson.M{"status": schema.JobPublished}
+	if _, role, err := getUserFromContext(c); err == nil && role == "admin" {
+		filter = bson.M{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := repository.FindPage[schema.Job](ctx, filter, page)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		msg := "Retrieve All Job failed"
+		slog.Error(getUserForLogging(c) + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	c.JSON(http.StatusOK, res)
 }
 
 // Update godoc
@@ -326,7 +274,7 @@ func (jc JobController) Update(c *gin.Context) {
 
 // Delete godoc
 // @Summary Delete a job
-// @Description Delete a job posting by ID and notify applicants
+// @Description Delete a job posting by ID and notify applicants. The job and its applications can be restored by an admin until they are purged.
 // @Tags jobs
 // @Accept  json
 // @Produce  json
@@ -338,26 +286,42 @@ func (jc JobController) Update(c *gin.Context) {
 // @Failure 500 {object} map[string]string
 // @Router /jobs/{id} [delete]
 func (jc JobController) Delete(c *gin.Context) {
-	shouldReturn := notifyJobDeletion(c)
+	notices, reason, shouldReturn := jobDeletionNotices(c)
 	if shouldReturn {
 		return
 	}
 	jc.baseController.Delete(c)
+	if c.Writer.Status() != http.StatusOK {
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	id, _ := primitive.ObjectIDFromHex(c.Param("id"))
-	repository.DeleteMany[schema.JobApplication](ctx, "job_applications", bson.M{"jobID": bson.M{"$eq": id}})
+	// the applications are deleted along with the job, so that they come back when it is restored
+	job, err := repository.FindDeleted[schema.Job](ctx, id)
+	if err == nil {
+		err = deleteApplications(ctx, bson.M{"jobID": id}, job.SoftDelete)
+	}
+	if err != nil {
+		slog.Error(getUserForLogging(c) + "deleting applications of job " + id.Hex() + " failed: " + err.Error())
+	}
+	audit.Record(c, schema.AuditEvent{Action: schema.AuditDelete, TargetType: job.GetCollectionName(), TargetID: id, Detail: reason})
+	if err := outbox.Enqueue(ctx, notices...); err != nil {
+		slog.Warn(getUserForLogging(c) + "job deletion notices failed: " + err.Error())
+	}
 }
 
-// notifyJobDeletion send emails to all applicants when a job they applied to got deleted.
-func notifyJobDeletion(c *gin.Context) (shouldReturn bool) {
+// jobDeletionNotices builds the emails telling all applicants that a job they applied to got deleted,
+// with the reason the company gave.
+func jobDeletionNotices(c *gin.Context) (notices []email.Message, reason string, shouldReturn bool) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	jobID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Job ID"})
-		return true
+		return nil, "", true
 	}
 
 	var body struct {
@@ -365,29 +329,25 @@ func notifyJobDeletion(c *gin.Context) (shouldReturn bool) {
 	}
 	if err := c.BindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON body"})
-		return true
-	}
-	reason := body.Reason
-	if reason == "" {
-		reason = "No reason provided."
+		return nil, "", true
 	}
 	// Sanitize untrusted reason: strip newlines and carriage returns to prevent email content injection
-	reason = email.SanitizeEmailBodyField(reason)
+	reason = email.SanitizeEmailBodyField(body.Reason)
 
 	job, err := repository.FindOne[schema.Job](ctx, jobID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No job Found"})
-		return true
+		return nil, reason, true
 	}
 
 	filter := bson.M{"jobID": bson.M{"$eq": job.ID}}
 	jobApplications, err := repository.FindAll[schema.JobApplication](ctx, filter)
 	if err == mongo.ErrNoDocuments {
-		return false
+		return nil, reason, false
 	}
 	if err != mongo.ErrNoDocuments && err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Problems when finding job applications"})
-		return true
+		return nil, reason, true
 	}
 
 	visited := make(map[primitive.ObjectID]bool)
@@ -400,43 +360,61 @@ func notifyJobDeletion(c *gin.Context) (shouldReturn bool) {
 	}
 
 	if len(applicantIDs) == 0 {
-		return false
+		return nil, reason, false
 	}
 
 	applicants, err := getUsersFromIDs(ctx, applicantIDs)
 	if err == mongo.ErrNoDocuments {
-		return false
+		return nil, reason, false
 	}
 	if err != nil && err != mongo.ErrNoDocuments {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return true
+		return nil, reason, true
 	}
 
 	for _, applicant := range applicants {
-		emailBody := fmt.Sprintf(
-			"The job '%s' you applied for has been deleted.\n\nReason: %s",
-			job.Title,
-			reason,
-		)
-		if err := email.Send(applicant.Email, "Job Deletion Notice", emailBody); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send email"})
-			return true
+		notice, err := email.Compose(applicant.Email, applicant.Locale, email.JobDeleted{JobTitle: job.Title, Reason: reason})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to compose deletion notices"})
+			return nil, reason, true
 		}
+		notices = append(notices, notice)
 	}
 
-	return false
+	return notices, reason, false
 }
 
 // RetrieveOne godoc
 // @Summary Get a job by ID
-// @Description Retrieve details of a specific job posting
+// @Description Retrieve details of a specific job posting. Drafts and scheduled jobs are only found by the company which posts them.
 // @Tags jobs
 // @Produce  json
 // @Param id path string true "Job ID"
 // @Success 200 {object} schema.Job
+// @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /jobs/{id} [get]
 func (jc JobController) RetrieveOne(c *gin.Context) {
-	jc.baseController.RetrieveOne(c)
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Retrieve Job failed: invalid ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	job, err := repository.FindOne[schema.Job](ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && !job.Status.IsPublic() && !mayManageJobsOf(ctx, c, job.CompanyID)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if err != nil {
+		msg := "Retrieve Job failed"
+		slog.Error(getUserForLogging(c) + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	c.JSON(http.StatusOK, job)
 }
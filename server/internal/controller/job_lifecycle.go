@@ -0,0 +1,231 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/config"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/authz"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/dto"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// jobLifecycleInterval is how often scheduled jobs are published, and due jobs closed or archived.
+	jobLifecycleInterval = time.Minute
+	// defaultJobArchiveAfter is how long closed jobs stay closed before they are archived,
+	// unless JOB_ARCHIVE_AFTER_DAYS says otherwise.
+	defaultJobArchiveAfter = 90 * 24 * time.Hour
+)
+
+// RunJobLifecycle moves jobs along their lifecycle when their time comes,
+// then keeps doing so every jobLifecycleInterval until ctx is cancelled.
+func RunJobLifecycle(ctx context.Context) {
+	archiveAfter := jobArchiveAfter()
+	ticker := time.NewTicker(jobLifecycleInterval)
+	defer ticker.Stop()
+	for {
+		if err := advanceJobLifecycle(ctx, time.Now(), archiveAfter); err != nil {
+			slog.Error("Job lifecycle: " + err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// jobArchiveAfter reads JOB_ARCHIVE_AFTER_DAYS.
+func jobArchiveAfter() time.Duration {
+	days, err := strconv.Atoi(config.LoadEnv("JOB_ARCHIVE_AFTER_DAYS"))
+	if err != nil || days <= 0 {
+		return defaultJobArchiveAfter
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// advanceJobLifecycle publishes scheduled jobs which opened, closes published jobs
+// past their deadline, and archives jobs closed for longer than archiveAfter.
+// Every update is conditional on the status, so several servers can run it at once.
+func advanceJobLifecycle(ctx context.Context, now time.Time, archiveAfter time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	published, err := repository.UpdateMany[schema.Job](ctx,
+		bson.M{"status": schema.JobScheduled, "postOpenDate": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"status": schema.JobPublished}},
+	)
+	if err != nil {
+		return fmt.Errorf("publish scheduled jobs: %w", err)
+	}
+	closed, err := repository.UpdateMany[schema.Job](ctx,
+		bson.M{"status": schema.JobPublished, "applicationDeadline": bson.M{"$lt": now}},
+		bson.M{"$set": bson.M{"status": schema.JobClosed, "closedAt": now, "closedReason": schema.ClosedAtDeadline}},
+	)
+	if err != nil {
+		return fmt.Errorf("close expired jobs: %w", err)
+	}
+	archived, err := repository.UpdateMany[schema.Job](ctx,
+		bson.M{"status": schema.JobClosed, "closedAt": bson.M{"$lte": now.Add(-archiveAfter)}},
+		bson.M{"$set": bson.M{"status": schema.JobArchived, "archivedAt": now}},
+	)
+	if err != nil {
+		return fmt.Errorf("archive closed jobs: %w", err)
+	}
+
+	if published.ModifiedCount+closed.ModifiedCount+archived.ModifiedCount > 0 {
+		slog.Info(fmt.Sprintf("Job lifecycle: published %d, closed %d, archived %d jobs",
+			published.ModifiedCount, closed.ModifiedCount, archived.ModifiedCount))
+	}
+	return nil
+}
+
+// closeJobIfFilled closes the job with jobID once as many applications were accepted as it has positions.
+func closeJobIfFilled(ctx context.Context, jobID primitive.ObjectID) error {
+	job, err := repository.FindOne[schema.Job](ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if job.Status != schema.JobPublished {
+		return nil
+	}
+	accepted, err := database.GetDatabase().Collection(schema.JobApplication{}.GetCollectionName()).CountDocuments(ctx,
+		bson.M{"jobID": jobID, "status": schema.StatusAccepted, "deletedAt": nil},
+	)
+	if err != nil {
+		return err
+	}
+	if accepted < int64(job.NumberOfPositions) {
+		return nil
+	}
+	_, err = repository.UpdateOne[schema.Job](ctx,
+		bson.M{"_id": jobID, "status": schema.JobPublished},
+		bson.M{"$set": bson.M{"status": schema.JobClosed, "closedAt": time.Now(), "closedReason": schema.ClosedWhenFilled}},
+	)
+	return err
+}
+
+// mayManageJobsOf reports whether the requesting user is an admin or a member of the company with companyID,
+// who may see its jobs whatever their status.
+func mayManageJobsOf(ctx context.Context, c *gin.Context, companyID primitive.ObjectID) bool {
+	userID, role, err := getUserFromContext(c)
+	if err != nil {
+		return false
+	}
+	if role == "admin" {
+		return true
+	}
+	org, err := findOrganization(ctx, companyID)
+	if err != nil {
+		return false
+	}
+	subject := authz.Subject{ID: userID, Role: role}
+	resource := authz.JobResource{Job: schema.Job{CompanyID: companyID}, Organization: org}
+	return jobPolicy.Authorize(subject, authz.Read, resource) == nil
+}
+
+// ChangeStatus godoc
+// @Summary      Change job status
+// @Description  Move a job along its lifecycle: publish a draft (scheduled until postOpenDate), publish a scheduled job right away or move it back to draft, close a published job, reopen a closed job before its deadline, or archive it. Scheduled jobs are published, and published jobs closed at their deadline or once numberOfPositions applications are accepted, automatically.
+// @Tags         jobs
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                true  "Job ID"
+// @Param        body  body      dto.JobStatusChange  true  "New status"
+// @Success      200   {object}  schema.Job
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      409   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /jobs/{id}/status [put]
+func (jc JobController) ChangeStatus(c *gin.Context) {
+	userInfo := getUserForLogging(c)
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Job ID"})
+		return
+	}
+	var body dto.JobStatusChange
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	next, err := schema.ParseJobStatus(body.Status)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	job, err := repository.FindOne[schema.Job](ctx, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if !job.Status.CanTransitionTo(next) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("cannot change job status from %s to %s", job.Status, next)})
+		return
+	}
+
+	now := time.Now()
+	set := bson.M{"status": next}
+	unset := bson.M{}
+	switch next {
+	case schema.JobPublished:
+		if now.After(job.ApplicationDeadline) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "the application deadline has passed, move it first"})
+			return
+		}
+		// Publishing a scheduled job opens it right away, and so does publishing a draft
+		// whose open date has passed while it was written. Saved search digests only list
+		// jobs which opened since their last run, so an old open date would hide the job from them.
+		if job.Status == schema.JobScheduled || (job.Status == schema.JobDraft && !job.PostOpenDate.After(now)) {
+			job.PostOpenDate = now
+			set["postOpenDate"] = now
+		}
+		next = job.PublishedStatus(now)
+		set["status"] = next
+		unset["closedAt"], unset["closedReason"] = "", ""
+	case schema.JobClosed:
+		set["closedAt"], set["closedReason"] = now, schema.ClosedByCompany
+	case schema.JobArchived:
+		set["archivedAt"] = now
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	// only apply the change if nobody changed the status since we read it
+	res, err := repository.UpdateOne[schema.Job](ctx, bson.M{"_id": id, "status": job.Status}, update)
+	if err != nil {
+		msg := "Change Job status failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "job status was changed meanwhile, please reload"})
+		return
+	}
+	slog.Info(fmt.Sprintf("%sChanged Job %s status: %s -> %s", userInfo, id.Hex(), job.Status, next))
+
+	job, err = repository.FindOne[schema.Job](ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Change Job status failed"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
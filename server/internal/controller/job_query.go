@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/currency"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/dto"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// jobQuery is a parsed set of /jobs/query parameters.
+type jobQuery struct {
+	filter    bson.M
+	sortField string
+	sortOrder int
+	limit     int
+	search    string
+	currency  string
+	// status is the status asked for, the filter has only published jobs when it is empty
+	status schema.JobStatus
+}
+
+// maxSearchLength is the longest search text accepted by Query.
+const maxSearchLength = 200
+
+// jobQueryParams are the parameters accepted by JobController.Query,
+// each with a function turning its value into a filter value (nil to skip it).
+var jobQueryParams = map[string]func(string) (interface{}, error){
+	"id": func(v string) (interface{}, error) {
+		if v == "" {
+			return nil, fmt.Errorf("id parameter is empty")
+		}
+		return primitive.ObjectIDFromHex(v)
+	},
+	"title": func(v string) (interface{}, error) {
+		if v == "" {
+			return nil, nil
+		}
+		return bson.M{"$regex": v, "$options": "i"}, nil
+	},
+	"companyID": func(v string) (interface{}, error) {
+		if v == "" {
+			return nil, nil
+		}
+		return primitive.ObjectIDFromHex(v)
+	},
+	"location": func(v string) (interface{}, error) {
+		if v == "" {
+			return nil, nil
+		}
+		return bson.M{"$regex": v, "$options": "i"}, nil
+	},
+	"minSalary": func(v string) (interface{}, error) {
+		if v == "" {
+			return nil, nil
+		}
+		return parseSalary("minSalary", v)
+	},
+	"maxSalary": func(v string) (interface{}, error) {
+		if v == "" {
+			return nil, nil
+		}
+		return parseSalary("maxSalary", v)
+	},
+	"currency": func(v string) (interface{}, error) {
+		if v == "" {
+			return nil, nil
+		}
+		if len(v) != 3 {
+			return nil, fmt.Errorf("currency must be a 3-letter code")
+		}
+		return strings.ToUpper(v), nil
+	},
+	"workType": func(v string) (interface{}, error) {
+		if v == "" {
+			return nil, nil
+		}
+		return bson.M{"$regex": v, "$options": "i"}, nil
+	},
+	"workArrangement": func(v string) (interface{}, error) {
+		if v == "" {
+			return nil, nil
+		}
+		return bson.M{"$regex": v, "$options": "i"}, nil
+	},
+	"postOpenDate": func(v string) (interface{}, error) {
+		if v == "" {
+			return nil, nil
+		}
+
+		now := time.Now()
+		midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+		switch v {
+		case "1d":
+			return bson.M{"$gte": midnight.AddDate(0, 0, -1)}, nil
+		case "6w":
+			return bson.M{"$gte": midnight.AddDate(0, 0, -7*6)}, nil
+		default:
+			return nil, nil
+		}
+	},
+	"latest": func(v string) (interface{}, error) {
+		if v == "true" {
+			return true, nil
+		}
+		return nil, nil
+	},
+	"q": func(v string) (interface{}, error) {
+		if len(v) > maxSearchLength {
+			return nil, fmt.Errorf("q must be at most %d characters", maxSearchLength)
+		}
+		return nil, nil
+	},
+	"status": func(v string) (interface{}, error) {
+		if v == "" {
+			return nil, nil
+		}
+		return schema.ParseJobStatus(v)
+	},
+	"sort": func(v string) (interface{}, error) {
+		switch v {
+		case "relevance", "dateAsc", "dateDesc", "title":
+			return v, nil
+		case "", "null":
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("invalid sort value")
+		}
+	},
+}
+
+// parseJobQuery validates the parameters of JobController.Query and builds
+// the query they describe. Pagination parameters are ignored.
+func parseJobQuery(params url.Values) (jobQuery, error) {
+	query := jobQuery{filter: bson.M{}}
+	var minSalary, maxSalary *float64
+
+	for key, values := range params {
+		if key == "sort" || key == "latest" || paginationParams[key] {
+			continue
+		}
+		fn, ok := jobQueryParams[key]
+		if !ok {
+			return query, errors.New("Unsupported query parameter: " + key)
+		}
+		val, err := fn(values[0])
+		if err != nil {
+			return query, err
+		}
+		if val == nil {
+			continue
+		}
+		switch key {
+		case "id":
+			query.filter["_id"] = val
+		case "minSalary":
+			minSalary = val.(*float64)
+		case "maxSalary":
+			maxSalary = val.(*float64)
+		case "currency":
+			query.currency = val.(string)
+		case "status":
+			query.status = val.(schema.JobStatus)
+			query.filter["status"] = query.status
+		default:
+			query.filter[key] = val
+		}
+	}
+
+	if minSalary != nil && maxSalary != nil && *minSalary > *maxSalary {
+		return query, fmt.Errorf("minSalary cannot be greater than maxSalary")
+	}
+	if salary := salaryOverlapFilter(minSalary, maxSalary, query.currency, currency.GetRates()); salary != nil {
+		// kept apart from the top level, where full-text search may add its own $or
+		query.filter["$and"] = bson.A{salary}
+	}
+
+	if query.status == "" {
+		query.filter["status"] = schema.JobPublished
+	}
+
+	query.search = strings.TrimSpace(params.Get("q"))
+
+	switch params.Get("sort") {
+	case "dateAsc":
+		query.sortField, query.sortOrder = "postOpenDate", 1
+	case "dateDesc":
+		query.sortField, query.sortOrder = "postOpenDate", -1
+	case "title":
+		query.sortField, query.sortOrder = "title", 1
+	case "", "null", "relevance":
+		// no sorting
+	default:
+		// ignore invalid sort
+	}
+
+	if params.Get("latest") == "true" {
+		query.filter["postOpenDate"] = bson.M{"$lte": time.Now()}
+		query.sortField, query.sortOrder = "postOpenDate", -1
+		query.limit = 3
+	}
+	return query, nil
+}
+
+// findJobs runs a job query and returns one page of its results.
+func findJobs(ctx context.Context, query jobQuery, page repository.PageRequest) (repository.Page[dto.JobQueryResult], error) {
+	page.SortField, page.SortOrder = query.sortField, query.sortOrder
+	if query.limit > 0 {
+		page.Limit = query.limit
+	}
+
+	var (
+		jobs repository.Page[dto.JobQueryResult]
+		err  error
+	)
+	if query.search != "" {
+		jobs, err = searchJobs(ctx, query.filter, query.search, page, query.sortField == "")
+	} else {
+		jobs, err = repository.FindPage[dto.JobQueryResult](ctx, query.filter, page)
+	}
+	if err != nil {
+		return jobs, err
+	}
+
+	if query.currency != "" {
+		rates := currency.GetRates()
+		for i := range jobs.Data {
+			jobs.Data[i].ConvertedSalary = convertSalary(jobs.Data[i].Job, query.currency, rates)
+		}
+	}
+	return jobs, nil
+}
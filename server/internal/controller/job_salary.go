@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/currency"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/dto"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// parseSalary parses a salary query parameter.
+func parseSalary(name string, v string) (*float64, error) {
+	salary, err := strconv.ParseFloat(v, 64)
+	if err != nil || salary < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative number", name)
+	}
+	return &salary, nil
+}
+
+// salaryOverlapFilter matches jobs whose [minSalary, maxSalary] band overlaps
+// the searched one. Either end of the searched band may be nil.
+//
+// With a currency, the searched band is converted into the currency of each
+// posting, so that postings in every currency of rates can match.
+// Without one, the amounts are compared as they are.
+func salaryOverlapFilter(min, max *float64, cur string, rates *currency.Rates) bson.M {
+	if min == nil && max == nil {
+		return nil
+	}
+
+	band := func(code string) bson.M {
+		cond := bson.M{}
+		if min != nil {
+			v, _ := rates.Convert(*min, cur, code)
+			cond["maxSalary"] = bson.M{"$gte": v}
+		}
+		if max != nil {
+			v, _ := rates.Convert(*max, cur, code)
+			cond["minSalary"] = bson.M{"$lte": v}
+		}
+		return cond
+	}
+	if cur == "" {
+		return band("")
+	}
+
+	codes := []string{cur}
+	if rates.Supports(cur) {
+		codes = rates.Currencies()
+		slices.Sort(codes)
+	}
+	anyCurrency := bson.A{}
+	for _, code := range codes {
+		cond := band(code)
+		cond["currency"] = code
+		anyCurrency = append(anyCurrency, cond)
+	}
+	return bson.M{"$or": anyCurrency}
+}
+
+// convertSalary returns the job's salary band in another currency,
+// or nil if the job's currency cannot be converted.
+func convertSalary(job schema.Job, to string, rates *currency.Rates) *dto.SalaryBand {
+	min, err := rates.Convert(job.MinSalary, job.Currency, to)
+	if err != nil {
+		return nil
+	}
+	max, err := rates.Convert(job.MaxSalary, job.Currency, to)
+	if err != nil {
+		return nil
+	}
+	return &dto.SalaryBand{Currency: to, MinSalary: min, MaxSalary: max}
+}
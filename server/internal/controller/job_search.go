@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/dto"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/search"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// jobSearchFields are the fields covered by the jobs text index, see database.indexes.
+var jobSearchFields = []string{"title", "jobSummary", "requiredSkills", "jobDescription"}
+
+// searchJobs finds one page of jobs matching both filter and the search text.
+// If byRelevance is true the best matches come first, otherwise page's sort is kept.
+// When the text index is missing it falls back to a (slow) regex search.
+func searchJobs(
+	ctx context.Context,
+	filter bson.M,
+	text string,
+	page repository.PageRequest,
+	byRelevance bool,
+) (repository.Page[dto.JobQueryResult], error) {
+	var (
+		hits repository.Page[dto.JobQueryResult]
+		err  error
+	)
+	if byRelevance {
+		hits, err = repository.SearchPage[dto.JobQueryResult](ctx, filter, text, page)
+	} else {
+		hits, err = repository.FindPage[dto.JobQueryResult](ctx, withField(filter, "$text", bson.M{"$search": text}), page)
+	}
+
+	if repository.IsTextIndexMissing(err) {
+		pattern := bson.M{"$regex": regexp.QuoteMeta(text), "$options": "i"}
+		anyField := bson.A{}
+		for _, field := range jobSearchFields {
+			anyField = append(anyField, bson.M{field: pattern})
+		}
+		hits, err = repository.FindPage[dto.JobQueryResult](ctx, withField(filter, "$or", anyField), page)
+	}
+	if err != nil {
+		return hits, err
+	}
+
+	terms := search.Terms(text)
+	for i := range hits.Data {
+		hits.Data[i].Highlights = highlightJob(&hits.Data[i], terms)
+	}
+	return hits, nil
+}
+
+// highlightJob returns the highlights of every searched field that matched.
+func highlightJob(hit *dto.JobQueryResult, terms []string) map[string][]string {
+	fields := map[string]string{
+		"title":          hit.Title,
+		"jobSummary":     hit.JobSummary,
+		"requiredSkills": hit.RequiredSkills,
+		"jobDescription": hit.JobDescription,
+	}
+	highlights := map[string][]string{}
+	for field, value := range fields {
+		if snippets := search.Highlight(value, terms); snippets != nil {
+			highlights[field] = snippets
+		}
+	}
+	return highlights
+}
+
+// withField returns a copy of filter with key set to value.
+func withField(filter bson.M, key string, value any) bson.M {
+	result := bson.M{key: value}
+	for k, v := range filter {
+		result[k] = v
+	}
+	return result
+}
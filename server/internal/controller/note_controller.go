@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/authz"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/dto"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
@@ -37,22 +38,17 @@ func getMiddlewareUserID(c *gin.Context) (primitive.ObjectID, error) {
 	return middlewareUserID, nil
 }
 
-// getJobAndApplication to get JobApplication and its parent Job
-func getJobAndApplication(ctx context.Context, jobAppID primitive.ObjectID) (schema.JobApplication, schema.Job, error) {
-	jobApplication, err := repository.FindOne[schema.JobApplication](ctx, jobAppID)
+// helper to get all jobs of the organizations a company user is a member of
+func getJobsOfMember(ctx context.Context, userID primitive.ObjectID) ([]schema.Job, error) {
+	orgs, err := organizationsOf(ctx, userID)
 	if err != nil {
-		return schema.JobApplication{}, schema.Job{}, err
+		return nil, err
 	}
-	job, err := repository.FindOne[schema.Job](ctx, jobApplication.JobID)
-	if err != nil {
-		return schema.JobApplication{}, schema.Job{}, err
+	companyIDs := make([]primitive.ObjectID, 0, len(orgs))
+	for _, org := range orgs {
+		companyIDs = append(companyIDs, org.ID)
 	}
-	return jobApplication, job, nil
-}
-
-// helper to get all jobs owned by a company user
-func getJobsByCompanyID(ctx context.Context, companyID primitive.ObjectID) ([]schema.Job, error) {
-	return repository.FindAll[schema.Job](ctx, bson.M{"companyID": companyID})
+	return repository.FindAll[schema.Job](ctx, bson.M{"companyID": bson.M{"$in": companyIDs}})
 }
 
 // validateNoteOwner ensures the requesting user is the job owner
@@ -73,30 +69,24 @@ func (nc NoteController) validateNoteOwner(c *gin.Context) (shouldReturn bool) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	existingNote, err := repository.FindOne[schema.Note](ctx, objID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Note not found"})
-		return true
-	}
-
-	jobApplication, job, err := getJobAndApplication(ctx, existingNote.JobApplicationID)
+	note, err := authz.Load(ctx, c, authz.Note, notePolicy.Load, objID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot find Job or Job Application"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot find Note, Job or Job Application"})
 		return true
 	}
 
-	middlewareUserID, err := getMiddlewareUserID(c)
+	middlewareUserID, role, err := getUserFromMiddleware(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot get user from middleware"})
 		return true
 	}
 
-	if middlewareUserID != job.CompanyID {
+	subject := authz.Subject{ID: middlewareUserID, Role: role}
+	if err := notePolicy.Authorize(subject, authz.ActionOf(c.Request.Method), note); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "the requested user and the job poster are not the same person"})
 		return true
 	}
 
-	_ = jobApplication
 	return false
 }
 
@@ -200,8 +190,8 @@ func (nc NoteController) Query(c *gin.Context) {
 	jobAppParam := c.Query("jobApplicationID")
 	var jobAppFilter bson.M
 
-	// 1. Find all jobs owned by this company
-	jobs, err := getJobsByCompanyID(ctx, middlewareUserID)
+	// 1. Find all jobs of the user's organizations
+	jobs, err := getJobsOfMember(ctx, middlewareUserID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot fetch jobs"})
 		return
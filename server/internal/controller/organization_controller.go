@@ -0,0 +1,497 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/config"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/authz"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/dto"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/email"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/outbox"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// invitationAge is how long an invitation to an organization can be accepted.
+const invitationAge = 7 * 24 * time.Hour
+
+// OrganizationController lets company accounts work together in organizations:
+// owners invite members by email and remove them.
+type OrganizationController struct{}
+
+func NewOrganizationController() OrganizationController {
+	return OrganizationController{}
+}
+
+// Mine godoc
+// @Summary      List my organizations
+// @Description  List the organizations the authenticated company account is a member of.
+// @Tags         Organizations
+// @Produce      json
+// @Success      200  {array}   schema.Organization
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /organizations/mine [get]
+func (oc OrganizationController) Mine(c *gin.Context) {
+	userID, _, err := getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	orgs, err := organizationsOf(ctx, userID)
+	if err != nil {
+		slog.Error(getUserForLogging(c) + "Retrieve Organizations failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Retrieve Organizations failed"})
+		return
+	}
+	c.JSON(http.StatusOK, orgs)
+}
+
+// RetrieveOne godoc
+// @Summary      Get an organization
+// @Description  Get an organization with its members and pending invitations. Only its members can see it.
+// @Tags         Organizations
+// @Produce      json
+// @Param        id   path      string  true  "Organization ID"
+// @Success      200  {object}  schema.Organization
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /organizations/{id} [get]
+func (oc OrganizationController) RetrieveOne(c *gin.Context) {
+	orgID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	org, err := authz.Load(ctx, c, authz.Organization, organizationPolicy.Load, orgID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+		return
+	}
+	c.JSON(http.StatusOK, org)
+}
+
+// Invite godoc
+// @Summary      Invite a member
+// @Description  Email an invitation to join the organization with a sub-role (owner, recruiter or viewer). Only owners can invite. A new invitation to the same email replaces the pending one.
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Param        id          path      string                      true  "Organization ID"
+// @Param        invitation  body      dto.OrganizationInvitation  true  "Email and sub-role of the new member"
+// @Success      201         {object}  schema.OrganizationInvitation
+// @Failure      400         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Failure      409         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Router       /organizations/{id}/invitations [post]
+func (oc OrganizationController) Invite(c *gin.Context) {
+	userInfo := getUserForLogging(c)
+	orgID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization ID"})
+		return
+	}
+	var req dto.OrganizationInvitation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	role, err := schema.ParseOrganizationRole(req.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	inviterID, _, err := getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+	address := strings.ToLower(strings.TrimSpace(req.Email))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	org, err := authz.Load(ctx, c, authz.Organization, organizationPolicy.Load, orgID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+		return
+	}
+
+	// the invitee may not have an account yet
+	invitee, err := findUserByEmail(ctx, address)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		msg := "Invite Member failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	if _, member := org.RoleOf(invitee.ID); err == nil && member {
+		c.JSON(http.StatusConflict, gin.H{"error": "this user is already a member"})
+		return
+	}
+
+	token, err := newRandomToken()
+	if err != nil {
+		msg := "Invite Member failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	now := time.Now()
+	invitation := schema.OrganizationInvitation{
+		ID:        primitive.NewObjectID(),
+		Email:     address,
+		Role:      role,
+		TokenHash: hashInvitationToken(token),
+		InvitedBy: inviterID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(invitationAge),
+	}
+
+	if err := addInvitation(ctx, org, invitation); err != nil {
+		msg := "Invite Member failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+
+	inviter, _ := repository.FindOne[schema.User](ctx, inviterID)
+	msg, err := email.Compose(address, invitee.Locale, email.OrganizationInvitation{
+		OrganizationName: org.Name,
+		InviterName:      inviter.Name,
+		Role:             string(role),
+		URL:              config.LoadEnv("FRONTEND") + "/company/invitations?token=" + url.QueryEscape(token),
+		ValidDays:        int(invitationAge.Hours() / 24),
+	})
+	if err == nil {
+		err = outbox.Enqueue(ctx, msg)
+	}
+	if err != nil {
+		// the owner can invite again, which replaces this invitation
+		slog.Error(userInfo + "Queue invitation email failed: " + err.Error())
+	}
+
+	slog.Info(userInfo + "Invited " + string(role) + " to Organization: " + org.ID.Hex())
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// addInvitation stores invitation in org, replacing any pending invitation to the same email.
+// A personal organization is stored first.
+func addInvitation(ctx context.Context, org schema.Organization, invitation schema.OrganizationInvitation) error {
+	_, err := repository.UpdateOne[schema.Organization](ctx,
+		bson.M{"_id": org.ID},
+		bson.M{"$setOnInsert": bson.M{
+			"name":        org.Name,
+			"members":     org.Members,
+			"invitations": bson.A{},
+			"createdAt":   org.CreatedAt,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+	// the same field cannot be pulled from and pushed to by one update
+	_, err = repository.UpdateOne[schema.Organization](ctx,
+		bson.M{"_id": org.ID},
+		bson.M{"$pull": bson.M{"invitations": bson.M{"email": invitation.Email}}},
+	)
+	if err != nil {
+		return err
+	}
+	_, err = repository.UpdateOne[schema.Organization](ctx,
+		bson.M{"_id": org.ID},
+		bson.M{"$push": bson.M{"invitations": invitation}},
+	)
+	return err
+}
+
+// RevokeInvitation godoc
+// @Summary      Revoke an invitation
+// @Description  Delete a pending invitation, so that it can no longer be accepted. Only owners can revoke.
+// @Tags         Organizations
+// @Produce      json
+// @Param        id            path      string  true  "Organization ID"
+// @Param        invitationId  path      string  true  "Invitation ID"
+// @Success      200           {object}  map[string]string
+// @Failure      400           {object}  map[string]string
+// @Failure      404           {object}  map[string]string
+// @Failure      500           {object}  map[string]string
+// @Router       /organizations/{id}/invitations/{invitationId} [delete]
+func (oc OrganizationController) RevokeInvitation(c *gin.Context) {
+	orgID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization ID"})
+		return
+	}
+	invitationID, err := primitive.ObjectIDFromHex(c.Param("invitationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := repository.UpdateOne[schema.Organization](ctx,
+		bson.M{"_id": orgID},
+		bson.M{"$pull": bson.M{"invitations": bson.M{"id": invitationID}}},
+	)
+	if err != nil {
+		msg := "Revoke Invitation failed"
+		slog.Error(getUserForLogging(c) + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	if res.ModifiedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invitation not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "invitation revoked"})
+}
+
+// AcceptInvitation godoc
+// @Summary      Accept an invitation
+// @Description  Join the organization of an invitation, with the token from the invitation email. The invitation must be to the email of the authenticated company account.
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Param        request  body      dto.AcceptOrganizationInvitation  true  "Invitation token"
+// @Success      200      {object}  schema.Organization
+// @Failure      400      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      409      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Router       /organizations/invitations/accept [post]
+func (oc OrganizationController) AcceptInvitation(c *gin.Context) {
+	userInfo := getUserForLogging(c)
+	var req dto.AcceptOrganizationInvitation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, _, err := getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tokenHash := hashInvitationToken(strings.ToLower(req.Token))
+	var org schema.Organization
+	err = database.GetDatabase().Collection(org.GetCollectionName()).FindOne(ctx, bson.M{
+		"invitations": bson.M{"$elemMatch": bson.M{
+			"tokenHash": tokenHash,
+			"expiresAt": bson.M{"$gt": time.Now()},
+		}},
+	}).Decode(&org)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invitation not found or expired"})
+		return
+	}
+	if err != nil {
+		msg := "Accept Invitation failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	var invitation schema.OrganizationInvitation
+	for _, inv := range org.Invitations {
+		if inv.TokenHash == tokenHash {
+			invitation = inv
+		}
+	}
+
+	user, err := repository.FindOne[schema.User](ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	// only company accounts work for organizations, and the token alone is not enough
+	if user.Role != schema.RoleCompany || !strings.EqualFold(user.Email, invitation.Email) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "this invitation is for another account"})
+		return
+	}
+
+	member := schema.OrganizationMember{UserID: user.ID, Role: invitation.Role, JoinedAt: time.Now()}
+	org, err = repository.FindOneAndUpdate[schema.Organization](ctx,
+		bson.M{
+			"_id":            org.ID,
+			"invitations.id": invitation.ID,
+			"members.userID": bson.M{"$ne": user.ID},
+		},
+		bson.M{
+			"$push": bson.M{"members": member},
+			"$pull": bson.M{"invitations": bson.M{"id": invitation.ID}},
+		},
+	)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusConflict, gin.H{"error": "you are already a member of this organization"})
+		return
+	}
+	if err != nil {
+		msg := "Accept Invitation failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+
+	slog.Info(userInfo + "Joined Organization: " + org.ID.Hex())
+	c.JSON(http.StatusOK, org)
+}
+
+// RemoveMember godoc
+// @Summary      Remove a member
+// @Description  Remove a member from the organization. Owners can remove anyone, and every member can leave. The last owner cannot be removed.
+// @Tags         Organizations
+// @Produce      json
+// @Param        id      path      string  true  "Organization ID"
+// @Param        userId  path      string  true  "User ID of the member"
+// @Success      200     {object}  map[string]string
+// @Failure      400     {object}  map[string]string
+// @Failure      403     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Failure      409     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /organizations/{id}/members/{userId} [delete]
+func (oc OrganizationController) RemoveMember(c *gin.Context) {
+	userInfo := getUserForLogging(c)
+	orgID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization ID"})
+		return
+	}
+	memberID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	actorID, actorRole, err := getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	org, err := findOrganization(ctx, orgID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+		return
+	}
+	if err != nil {
+		msg := "Remove Member failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+
+	if actorRole != "admin" && actorID != memberID && !org.HasRole(actorID, schema.OrgOwner) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only owners can remove other members"})
+		return
+	}
+	role, member := org.RoleOf(memberID)
+	if !member {
+		c.JSON(http.StatusNotFound, gin.H{"error": "member not found"})
+		return
+	}
+	if role == schema.OrgOwner && org.Owners() <= 1 {
+		c.JSON(http.StatusConflict, gin.H{"error": "an organization needs an owner, make someone else owner first"})
+		return
+	}
+
+	filter := bson.M{"_id": org.ID, "members.userID": memberID}
+	if role == schema.OrgOwner {
+		// another owner may have been removed meanwhile
+		filter["members"] = bson.M{"$elemMatch": bson.M{"role": schema.OrgOwner, "userID": bson.M{"$ne": memberID}}}
+	}
+	res, err := repository.UpdateOne[schema.Organization](ctx, filter,
+		bson.M{"$pull": bson.M{"members": bson.M{"userID": memberID}}},
+	)
+	if err != nil {
+		msg := "Remove Member failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	if res.ModifiedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "the members changed, please try again"})
+		return
+	}
+
+	slog.Info(userInfo + "Removed " + memberID.Hex() + " from Organization: " + org.ID.Hex())
+	c.JSON(http.StatusOK, gin.H{"message": "member removed"})
+}
+
+// organizationsOf returns the organizations the user with userID is a member of,
+// including their personal one unless they have founded an organization.
+func organizationsOf(ctx context.Context, userID primitive.ObjectID) ([]schema.Organization, error) {
+	orgs, err := repository.FindAll[schema.Organization](ctx, bson.M{"members.userID": userID})
+	if err != nil {
+		return nil, err
+	}
+	if orgs == nil {
+		orgs = []schema.Organization{}
+	}
+	for _, org := range orgs {
+		if org.ID == userID {
+			return orgs, nil
+		}
+	}
+	// a founder removed from their own organization is not a member of it anymore
+	personal, err := findOrganization(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return orgs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if personal.HasRole(userID, schema.OrgViewer) {
+		orgs = append(orgs, personal)
+	}
+	return orgs, nil
+}
+
+// hashInvitationToken returns what organizations store of an invitation token.
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// findUserByEmail returns the user with address as their email, ignoring case.
+func findUserByEmail(ctx context.Context, address string) (schema.User, error) {
+	var user schema.User
+	err := database.GetDatabase().Collection(user.GetCollectionName()).FindOne(ctx, bson.M{
+		"email":     primitive.Regex{Pattern: "^" + regexp.QuoteMeta(address) + "$", Options: "i"},
+		"deletedAt": nil,
+	}).Decode(&user)
+	return user, err
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/outbox"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// OutboxController lets admins inspect queued emails and retry the ones which failed for good.
+type OutboxController struct{}
+
+func NewOutboxController() OutboxController {
+	return OutboxController{}
+}
+
+// Query godoc
+// @Summary      List queued emails (admin only)
+// @Description  List the emails in the outbox, newest first, optionally only those with a given status.
+// @Tags         Admin
+// @Produce      json
+// @Param        status  query     string   false  "PENDING | SENT | DEAD"
+// @Param        limit   query     integer  false  "Page size (default 20, max 100)"
+// @Param        cursor  query     string   false  "Cursor from the previous page's nextCursor"
+// @Success      200     {object}  repository.Page[schema.OutboxMessage]
+// @Failure      400     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /admin/outbox [get]
+func (oc OutboxController) Query(c *gin.Context) {
+	filter := bson.M{}
+	if status := c.Query("status"); status != "" {
+		switch schema.OutboxStatus(status) {
+		case schema.OutboxPending, schema.OutboxSent, schema.OutboxDead:
+			filter["status"] = status
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status: " + status})
+			return
+		}
+	}
+	page, err := getPageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page.SortOrder = -1
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	messages, err := repository.FindPage[schema.OutboxMessage](ctx, filter, page)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Retrieve Outbox failed"})
+		return
+	}
+	if messages.Data == nil {
+		messages.Data = []schema.OutboxMessage{}
+	}
+	c.JSON(http.StatusOK, messages)
+}
+
+// Retry godoc
+// @Summary      Retry a dead email (admin only)
+// @Description  Queue an email which failed too many times again, with a fresh set of attempts.
+// @Tags         Admin
+// @Produce      json
+// @Param        id   path      string  true  "Outbox message ID"
+// @Success      200  {object}  schema.OutboxMessage
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/outbox/{id}/retry [post]
+func (oc OutboxController) Retry(c *gin.Context) {
+	userInfo := getUserForLogging(c)
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	msg, err := outbox.Retry(ctx, objID)
+	if errors.Is(err, outbox.ErrNotDead) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Outbox message not found"})
+		return
+	}
+	if err != nil {
+		msg := "Retry Outbox message failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+
+	slog.Info(userInfo + "Retried Outbox message: " + msg.ID.Hex())
+	c.JSON(http.StatusOK, msg)
+}
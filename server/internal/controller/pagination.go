@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+)
+
+// paginationParams are query parameters consumed by getPageRequest.
+// Query handlers skip them when building their filters.
+var paginationParams = map[string]bool{
+	"limit":  true,
+	"cursor": true,
+}
+
+// getPageRequest reads `limit` and `cursor` from the query string.
+// Limits above repository.MaxPageLimit are clamped by the repository.
+func getPageRequest(c *gin.Context) (repository.PageRequest, error) {
+	page := repository.PageRequest{Cursor: c.Query("cursor")}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return page, fmt.Errorf("limit must be a positive integer")
+		}
+		page.Limit = limit
+	}
+	return page, nil
+}
+
+// pageResponse builds the response body shared by every paginated endpoint.
+// nextCursor is left out on the last page, like in repository.Page.
+func pageResponse[T any](data []T, nextCursor string, hasMore bool) gin.H {
+	if data == nil {
+		data = []T{}
+	}
+	res := gin.H{
+		"data":    data,
+		"hasMore": hasMore,
+	}
+	if nextCursor != "" {
+		res["nextCursor"] = nextCursor
+	}
+	return res
+}
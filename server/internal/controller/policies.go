@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/authz"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Policies of the resources, loading them from MongoDB.
+var (
+	organizationPolicy = authz.OrganizationPolicy{FindOrganization: findOrganization}
+	jobPolicy          = authz.JobPolicy{FindJob: repository.FindOne[schema.Job], Organizations: organizationPolicy}
+	applicationPolicy  = authz.ApplicationPolicy{FindApplication: repository.FindOne[schema.JobApplication], Jobs: jobPolicy}
+	notePolicy         = authz.NotePolicy{FindNote: repository.FindOne[schema.Note], Applications: applicationPolicy}
+	filePolicy         = authz.FilePolicy{FindFile: repository.FindOne[schema.File]}
+	savedSearchPolicy  = authz.SavedSearchPolicy{FindSavedSearch: repository.FindOne[schema.SavedSearch]}
+	cohortPolicy       = authz.CohortPolicy{FindCohort: repository.FindOne[schema.Cohort]}
+	resumePolicy       = authz.ResumePolicy{FindResume: repository.FindOne[schema.Resume]}
+	userPolicy         = authz.UserPolicy{FindUser: repository.FindOne[schema.User], FindSharedWith: findSharedWith}
+)
+
+// registerPolicies registers the policy of every resource type with authz.
+func registerPolicies() {
+	authz.Register(authz.Organization, organizationPolicy)
+	authz.Register(authz.Job, jobPolicy)
+	authz.Register(authz.Application, applicationPolicy)
+	authz.Register(authz.Note, notePolicy)
+	authz.Register(authz.File, filePolicy)
+	authz.Register(authz.SavedSearch, savedSearchPolicy)
+	authz.Register(authz.Cohort, cohortPolicy)
+	authz.Register(authz.Resume, resumePolicy)
+	authz.Register(authz.User, userPolicy)
+}
+
+// findOrganization returns the organization with id. A company account which
+// has not founded an organization yet is the only owner of its personal one.
+func findOrganization(ctx context.Context, id primitive.ObjectID) (schema.Organization, error) {
+	org, err := repository.FindOne[schema.Organization](ctx, id)
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return org, err
+	}
+	founder, err := repository.FindOne[schema.User](ctx, id)
+	if err != nil {
+		return schema.Organization{}, err
+	}
+	if founder.Role != schema.RoleCompany {
+		return schema.Organization{}, mongo.ErrNoDocuments
+	}
+	return schema.PersonalOrganization(founder), nil
+}
+
+// findSharedWith returns the IDs of the faculty members the student with id
+// consented to share with, through the cohorts they are in.
+func findSharedWith(ctx context.Context, id primitive.ObjectID) ([]primitive.ObjectID, error) {
+	cohorts, err := repository.FindAll[schema.Cohort](ctx, bson.M{
+		"students": bson.M{"$elemMatch": bson.M{"userID": id, "consentedAt": bson.M{"$ne": nil}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return extractUnique(cohorts, func(cohort schema.Cohort) primitive.ObjectID { return cohort.FacultyID }), nil
+}
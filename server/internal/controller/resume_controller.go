@@ -0,0 +1,320 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/authz"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/resume"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ResumeController lets job seekers build structured resumes, which are rendered to PDF.
+// Every save is a new version, which applications can be sent with.
+type ResumeController struct{}
+
+func NewResumeController() ResumeController {
+	return ResumeController{}
+}
+
+// Create godoc
+// @Summary      Save a new resume version
+// @Description  Save the structured resume of the authenticated job seeker as its next version, and render it to a PDF file of the resume category.
+// @Tags         Resumes
+// @Accept       json
+// @Produce      json
+// @Param        resume  body      schema.Resume  true  "Resume (title, summary, experience, education, skills, projects)"
+// @Success      201     {object}  schema.Resume
+// @Failure      400     {object}  map[string]string
+// @Failure      401     {object}  map[string]string
+// @Failure      409     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /resumes/ [post]
+func (rc ResumeController) Create(c *gin.Context) {
+	userInfo := getUserForLogging(c)
+	var cv schema.Resume
+	if err := c.ShouldBindJSON(&cv); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := cv.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, _, err := getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	owner, err := repository.FindOne[schema.User](ctx, userID)
+	if err != nil {
+		msg := "Create Resume failed: user not found"
+		slog.Warn(userInfo + msg)
+		c.JSON(http.StatusNotFound, gin.H{"error": msg})
+		return
+	}
+	latest, err := latestResumeVersion(ctx, userID)
+	if err != nil {
+		msg := "Create Resume failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+
+	cv.ID = primitive.NewObjectID()
+	cv.UserID = userID
+	cv.Version = latest + 1
+	cv.CreatedAt = time.Now()
+
+	file, err := storeRenderedResume(ctx, owner, &cv)
+	if err != nil {
+		msg := "Create Resume failed: rendering failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+
+	if _, err := repository.InsertOne(ctx, cv); err != nil {
+		discardRenderedResume(ctx, file)
+		// the unique index on userID and version turns a concurrent save into an error
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "another version of the resume was saved meanwhile, try again"})
+			return
+		}
+		msg := "Create Resume failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+
+	slog.Info(fmt.Sprintf("%sCreated Resume: %s version %d", userInfo, cv.ID.Hex(), cv.Version))
+	c.JSON(http.StatusCreated, cv)
+}
+
+// latestResumeVersion returns the highest resume version of the user with userID, 0 if they have none.
+func latestResumeVersion(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	var latest schema.Resume
+	err := database.GetDatabase().Collection(latest.GetCollectionName()).FindOne(
+		ctx,
+		bson.M{"userID": userID},
+		options.FindOne().SetSort(bson.M{"version": -1}).SetProjection(bson.M{"version": 1}),
+	).Decode(&latest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	return latest.Version, err
+}
+
+// storeRenderedResume renders cv to PDF, stores it as a file of its owner, and sets the FileID of cv.
+func storeRenderedResume(ctx context.Context, owner schema.User, cv *schema.Resume) (schema.File, error) {
+	var pdf bytes.Buffer
+	if err := resume.Render(&pdf, owner, *cv); err != nil {
+		return schema.File{}, err
+	}
+
+	fileID := primitive.NewObjectID()
+	store := storage.GetBlobStore()
+	size, err := store.Put(ctx, fileID.Hex(), &pdf)
+	if err != nil {
+		return schema.File{}, err
+	}
+	file := schema.File{
+		ID:            fileID,
+		UserID:        cv.UserID,
+		StorageKey:    fileID.Hex(),
+		FileExtension: "pdf",
+		Filename:      fmt.Sprintf("resume-v%d.pdf", cv.Version),
+		ContentType:   "application/pdf",
+		Size:          size,
+		Category:      schema.CategoryResume,
+		UploadDate:    cv.CreatedAt,
+	}
+	if _, err := repository.InsertOne(ctx, file); err != nil {
+		store.Delete(ctx, file.StorageKey)
+		return schema.File{}, err
+	}
+	cv.FileID = file.ID
+	return file, nil
+}
+
+// discardRenderedResume deletes a file stored by storeRenderedResume for a version which was not saved.
+func discardRenderedResume(ctx context.Context, file schema.File) {
+	if _, err := repository.DeleteOne[schema.File](ctx, file.ID); err != nil {
+		slog.Warn("failed to delete rendered resume " + file.ID.Hex() + ": " + err.Error())
+	}
+	if err := storage.GetBlobStore().Delete(ctx, file.StorageKey); err != nil {
+		slog.Warn("failed to delete rendered resume content " + file.StorageKey + ": " + err.Error())
+	}
+}
+
+// RetrieveAll godoc
+// @Summary      List resume versions
+// @Description  List every resume version of the authenticated job seeker, the latest first.
+// @Tags         Resumes
+// @Produce      json
+// @Success      200  {array}   schema.Resume
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /resumes/ [get]
+func (rc ResumeController) RetrieveAll(c *gin.Context) {
+	userID, _, err := getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resumes, err := repository.FindAll[schema.Resume](
+		ctx,
+		bson.M{"userID": userID},
+		options.Find().SetSort(bson.M{"version": -1}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Retrieve Resumes failed"})
+		return
+	}
+	if resumes == nil {
+		resumes = []schema.Resume{}
+	}
+	c.JSON(http.StatusOK, resumes)
+}
+
+// RetrieveOne godoc
+// @Summary      Get a resume version
+// @Description  Get one version of a resume of the authenticated job seeker.
+// @Tags         Resumes
+// @Produce      json
+// @Param        id   path      string  true  "Resume ID"
+// @Success      200  {object}  schema.Resume
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /resumes/{id} [get]
+func (rc ResumeController) RetrieveOne(c *gin.Context) {
+	cv, ok := rc.load(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, cv)
+}
+
+// Download godoc
+// @Summary      Download a resume version as PDF
+// @Description  Download the PDF rendered from one version of a resume of the authenticated job seeker.
+// @Tags         Resumes
+// @Produce      application/pdf
+// @Param        id   path      string  true  "Resume ID"
+// @Success      200  {file}    binary
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /resumes/{id}/pdf [get]
+func (rc ResumeController) Download(c *gin.Context) {
+	cv, ok := rc.load(c)
+	if !ok {
+		return
+	}
+	serveRenderedResume(c, cv)
+}
+
+// load returns the resume with the id of the path, unless it responded with an error.
+func (rc ResumeController) load(c *gin.Context) (schema.Resume, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid resume ID"})
+		return schema.Resume{}, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cv, err := authz.Load(ctx, c, authz.Resume, resumePolicy.Load, id)
+	if errors.Is(err, authz.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "resume not found"})
+		return cv, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve resume"})
+		return cv, false
+	}
+	return cv, true
+}
+
+// ForApplication godoc
+// @Summary      Get the resume of an application
+// @Description  Get the structured resume version a job application was sent with. Companies see it for applications to their jobs.
+// @Tags         Applications
+// @Produce      json
+// @Param        id   path      string  true  "Application ID"
+// @Param        format  query  string  false  "pdf to download the rendered PDF instead"
+// @Success      200  {object}  schema.Resume
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /apply/{id}/resume [get]
+func (rc ResumeController) ForApplication(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid application ID"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// AccessControlMiddleware checked that the user may read the application
+	resource, err := authz.Load(ctx, c, authz.Application, applicationPolicy.Load, id)
+	if errors.Is(err, authz.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "application not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve application"})
+		return
+	}
+	if resource.Application.ResumeID == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "application was sent without a resume"})
+		return
+	}
+
+	cv, err := repository.FindOne[schema.Resume](ctx, *resource.Application.ResumeID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "resume not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve resume"})
+		return
+	}
+
+	if c.Query("format") == "pdf" {
+		serveRenderedResume(c, cv)
+		return
+	}
+	c.JSON(http.StatusOK, cv)
+}
+
+// serveRenderedResume streams the PDF rendered from cv.
+func serveRenderedResume(c *gin.Context, cv schema.Resume) {
+	file, err := repository.FindOne[schema.File](c.Request.Context(), cv.FileID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "resume PDF not found"})
+		return
+	}
+	serveFile(c, file)
+}
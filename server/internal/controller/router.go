@@ -1,14 +1,17 @@
 package controller
 
 import (
+	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	_ "github.com/lnwdevelopers007/job-applier-3000/server/docs"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/auth"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/authz"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/middleware"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -17,11 +20,18 @@ import (
 // NewRouter returns new, default router.
 func NewRouter() *gin.Engine {
 	router := gin.Default()
+	registerPolicies()
+	// Only the proxies in TRUSTED_PROXIES may tell the client IP through X-Forwarded-For,
+	// otherwise anyone could forge the IP recorded in sessions and the audit log.
+	if err := router.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES: " + err.Error())
+	}
 
 	allowedOrigins := []string{
 		os.Getenv("FRONTEND"),
 	}
 
+	router.Use(middleware.RequestID())
 	router.Use(middleware.SecurityHeaders())
 	router.Use(middleware.RejectUnknownOriginsMiddleware(allowedOrigins))
 	router.Use(func(c *gin.Context) {
@@ -31,113 +41,232 @@ func NewRouter() *gin.Engine {
 
 	router.Use(cors.New(cfg))
 
-	authGroup := router.Group("/auth")
+	// Every route is registered with its permission, see middleware.Permissions.
+	perms := middleware.NewPermissions()
+	routes := perms.Routes(&router.RouterGroup)
+
+	// lets other services verify our tokens without sharing a secret
+	routes.GET("/.well-known/jwks.json", middleware.Public, auth.JWKS)
+
+	authGroup := routes.Group("/auth")
 	{
-		authGroup.GET("/:provider", auth.Login)
-		authGroup.GET("/:provider/callback", auth.OAuthCallback)
-		authGroup.POST("/:provider/logout", auth.Logout)
-		authGroup.POST("/refresh", auth.RefreshRefreshToken)
-		authGroup.GET("/me", middleware.AuthMiddleware(), auth.Me)
+		authGroup.GET("/:provider", middleware.Public, auth.Login)
+		authGroup.GET("/:provider/callback", middleware.Public, auth.OAuthCallback)
+		authGroup.GET("/:provider/link", middleware.SignedIn, middleware.AuthMiddleware(), auth.LinkProvider)
+		authGroup.DELETE("/identities/:provider", middleware.SignedIn, middleware.AuthMiddleware(), auth.UnlinkProvider)
+		authGroup.POST("/:provider/logout", middleware.Public, auth.Logout)
+		authGroup.POST("/refresh", middleware.Public, auth.RefreshRefreshToken)
+		authGroup.POST("/magic-link", middleware.Public, auth.RequestMagicLink)
+		authGroup.GET("/magic-link/verify", middleware.Public, auth.VerifyMagicLink)
+		authGroup.GET("/me", middleware.SignedIn, middleware.AuthMiddleware(), auth.Me)
+		authGroup.GET("/sessions", middleware.SignedIn, middleware.AuthMiddleware(), auth.ListSessions)
+		authGroup.DELETE("/sessions/:id", middleware.SignedIn, middleware.AuthMiddleware(), auth.DeleteSession)
 	}
 
 	// Job controller
 	jobCtrl := NewJobController()
 
 	// Public job routes (no auth required)
-	publicJobs := router.Group("/jobs/public")
+	publicJobs := routes.Group("/jobs/public")
 	{
-		publicJobs.GET("/latest", jobCtrl.GetLatestPublic)
+		publicJobs.GET("/latest", middleware.Public, jobCtrl.GetLatestPublic)
 	}
 
 	publicuserController := NewUserController()
-	publicUser := router.Group("/users/public")
+	publicUser := routes.Group("/users/public")
 	{
-		publicUser.GET("/:id", publicuserController.GetPublicInfo)
+		publicUser.GET("/:id", middleware.Public, publicuserController.GetPublicInfo)
 	}
 
 	// Apply AuthMiddleware and AccessControlMiddleware to all protected routes
 	// Order matters: AuthMiddleware first, then AccessControlMiddleware
-	protected := router.Group("/")
-	protected.Use(middleware.AuthMiddleware())
-	protected.Use(middleware.AccessControlMiddleware())
+	protected := routes.Group("/", middleware.AuthMiddleware(), middleware.AccessControlMiddleware(perms))
+
+	allRoles := middleware.Allow(middleware.AllRoles...)
+	companies := middleware.Allow("company", "admin")
+	jobSeekers := middleware.Allow("jobSeeker", "admin")
+	applicants := middleware.Allow("jobSeeker", "company", "admin")
+	admins := middleware.Allow("admin")
 
 	// Protected job routes
 	jobs := protected.Group("/jobs")
 	{
-		jobs.GET("/query", jobCtrl.Query)
-		jobs.GET("/", jobCtrl.RetrieveAll)
-		jobs.POST("/", jobCtrl.Create)
-		jobs.PUT("/:id", jobCtrl.Update)
-		jobs.DELETE("/:id", jobCtrl.Delete)
-		jobs.GET("/:id", jobCtrl.RetrieveOne)
+		jobs.GET("/query", allRoles, jobCtrl.Query)
+		jobs.GET("/", allRoles, jobCtrl.RetrieveAll)
+		jobs.POST("/", companies, jobCtrl.Create)
+		// Company can only update and delete their own jobs
+		jobs.PUT("/:id", companies.Owned(authz.Job, "id"), jobCtrl.Update)
+		jobs.DELETE("/:id", companies.Owned(authz.Job, "id"), jobCtrl.Delete)
+		jobs.PUT("/:id/status", companies.Owned(authz.Job, "id"), jobCtrl.ChangeStatus)
+		jobs.GET("/:id", allRoles, jobCtrl.RetrieveOne)
 	}
 
 	// Job application routes
 	applicationController := NewJobApplicationController()
 	applyRoutes := protected.Group("/apply")
 	{
-		applyRoutes.GET("/query", applicationController.Query)
-		applyRoutes.POST("/", applicationController.Create)
-		applyRoutes.PUT("/:id", applicationController.Update)
-		applyRoutes.DELETE("/:id", applicationController.Delete)
-		applyRoutes.GET("/:id", applicationController.RetrieveOne)
+		applyRoutes.GET("/query", applicants, applicationController.Query)
+		applyRoutes.POST("/", jobSeekers, applicationController.Create)
+		// Company moves applications to its jobs, job seeker can only withdraw their own
+		applyRoutes.PUT("/:id", applicants.Owned(authz.Application, "id"), applicationController.Update)
+		// Job seekers can only delete their own applications
+		applyRoutes.DELETE("/:id", jobSeekers.Owned(authz.Application, "id"), applicationController.Delete)
+		applyRoutes.GET("/:id", applicants.Owned(authz.Application, "id"), applicationController.RetrieveOne)
+		applyRoutes.GET("/:id/timeline", applicants.Owned(authz.Application, "id"), applicationController.Timeline)
+	}
+
+	// Resume routes
+	resumeCtrl := NewResumeController()
+	// Companies see the resume sent with applications to their jobs
+	applyRoutes.GET("/:id/resume", applicants.Owned(authz.Application, "id"), resumeCtrl.ForApplication)
+	resumeRoutes := protected.Group("/resumes")
+	{
+		resumeRoutes.GET("/", jobSeekers, resumeCtrl.RetrieveAll)
+		resumeRoutes.POST("/", jobSeekers, resumeCtrl.Create)
+		// Job seekers can only see their own resumes
+		resumeRoutes.GET("/:id", jobSeekers.Owned(authz.Resume, "id"), resumeCtrl.RetrieveOne)
+		resumeRoutes.GET("/:id/pdf", jobSeekers.Owned(authz.Resume, "id"), resumeCtrl.Download)
 	}
 
-	// User routes (admin only)
+	// User routes
 	userController := NewUserController()
 	userRoutes := protected.Group("/users")
 	{
-		userRoutes.GET("/query", userController.Query)
-		userRoutes.GET("/", userController.RetrieveAll)
-		userRoutes.POST("/", userController.Create)
-		userRoutes.PUT("/:id", userController.Update)
-		userRoutes.DELETE("/:id", userController.Delete)
-		userRoutes.GET("/:id", userController.RetrieveOne)
-		userRoutes.PATCH("/:id/verify", userController.VerifyUser)
-		userRoutes.PATCH("/:id/role", userController.EditPermission)
+		// Users can view the profiles authz.CanViewUserRole allows, and only edit their own
+		userRoutes.GET("/query", allRoles.Owned(authz.User, "id"), userController.Query)
+		userRoutes.GET("/", admins, userController.RetrieveAll)
+		userRoutes.POST("/", admins, userController.Create)
+		userRoutes.PUT("/:id", allRoles.Owned(authz.User, "id"), userController.Update)
+		userRoutes.DELETE("/:id", admins, userController.Delete)
+		userRoutes.GET("/:id", allRoles.Owned(authz.User, "id"), userController.RetrieveOne)
+		userRoutes.PATCH("/:id/verify", admins, userController.VerifyUser)
+		userRoutes.PATCH("/:id/role", admins, userController.EditPermission)
+	}
+
+	// Cohort routes
+	cohort := NewCohortController()
+	userRoutes.GET("/:id/endorsements", allRoles.Owned(authz.User, "id"), cohort.Endorsements)
+	faculty := middleware.Allow("faculty", "admin")
+	cohortRoutes := protected.Group("/cohorts")
+	{
+		cohortRoutes.GET("/", faculty, cohort.RetrieveAll)
+		cohortRoutes.POST("/", faculty, cohort.Create)
+		// Students see the cohorts they are in, and decide whether to share with their faculty member
+		cohortRoutes.GET("/mine", jobSeekers, cohort.Mine)
+		cohortRoutes.PUT("/:id/consent", jobSeekers, cohort.Consent)
+		// Faculty members can only manage their own cohorts
+		cohortRoutes.GET("/:id", faculty.Owned(authz.Cohort, "id"), cohort.RetrieveOne)
+		cohortRoutes.PUT("/:id", faculty.Owned(authz.Cohort, "id"), cohort.Update)
+		cohortRoutes.DELETE("/:id", faculty.Owned(authz.Cohort, "id"), cohort.Delete)
+		cohortRoutes.POST("/:id/students", faculty.Owned(authz.Cohort, "id"), cohort.AddStudent)
+		cohortRoutes.DELETE("/:id/students/:studentId", faculty.Owned(authz.Cohort, "id"), cohort.RemoveStudent)
+		cohortRoutes.GET("/:id/outcomes", faculty.Owned(authz.Cohort, "id"), cohort.Outcomes)
+		cohortRoutes.PUT("/:id/students/:studentId/endorsement", faculty.Owned(authz.Cohort, "id"), cohort.Endorse)
+		cohortRoutes.DELETE("/:id/students/:studentId/endorsement", faculty.Owned(authz.Cohort, "id"), cohort.Unendorse)
 	}
 
 	// File routes
 	file := NewFileController()
 	fileRoutes := protected.Group("/files")
 	{
-		fileRoutes.POST("/upload", file.Upload)
-		fileRoutes.GET("/download/:id", file.Download)
-		fileRoutes.GET("/user/:userId", file.ListByUser)
-		fileRoutes.DELETE("/:id", file.Delete)
-		fileRoutes.GET("/application/:applicationId", file.GetApplicantFiles)
-		fileRoutes.GET("/application/:applicationId/download/:fileId", file.DownloadApplicantFile)
+		fileRoutes.POST("/upload", applicants, file.Upload)
+		// Users can only download, list and delete their own files
+		fileRoutes.GET("/download/:id", applicants.Owned(authz.File, "id"), file.Download)
+		// ListByUser checks the user itself
+		fileRoutes.GET("/user/:userId", applicants, file.ListByUser)
+		fileRoutes.DELETE("/:id", applicants.Owned(authz.File, "id"), file.Delete)
+		// Company can view applicant files for their jobs
+		fileRoutes.GET("/application/:applicationId", companies.Owned(authz.Application, "applicationId"), file.GetApplicantFiles)
+		fileRoutes.GET("/application/:applicationId/download/:fileId", companies.Owned(authz.Application, "applicationId"), file.DownloadApplicantFile)
+	}
+
+	// Organization routes
+	organization := NewOrganizationController()
+	organizationRoutes := protected.Group("/organizations")
+	{
+		organizationRoutes.GET("/mine", middleware.Allow("company"), organization.Mine)
+		organizationRoutes.POST("/invitations/accept", middleware.Allow("company"), organization.AcceptInvitation)
+		// Members can see their organization, only owners can invite
+		organizationRoutes.GET("/:id", companies.Owned(authz.Organization, "id"), organization.RetrieveOne)
+		organizationRoutes.POST("/:id/invitations", companies.Owned(authz.Organization, "id"), organization.Invite)
+		organizationRoutes.DELETE("/:id/invitations/:invitationId", companies.Owned(authz.Organization, "id"), organization.RevokeInvitation)
+		// RemoveMember checks the member itself, as members can leave on their own
+		organizationRoutes.DELETE("/:id/members/:userId", companies, organization.RemoveMember)
+	}
+
+	// Saved search routes
+	savedSearch := NewSavedSearchController()
+	// linked from digest emails, the token identifies the saved search
+	routes.GET("/saved-searches/unsubscribe", middleware.Public, savedSearch.UnsubscribeLink)
+	routes.POST("/saved-searches/unsubscribe", middleware.Public, savedSearch.Unsubscribe)
+	savedSearchRoutes := protected.Group("/saved-searches")
+	{
+		savedSearchRoutes.GET("/", jobSeekers, savedSearch.RetrieveAll)
+		savedSearchRoutes.POST("/", jobSeekers, savedSearch.Create)
+		// Job seekers can only see their own saved searches
+		savedSearchRoutes.GET("/:id", jobSeekers.Owned(authz.SavedSearch, "id"), savedSearch.RetrieveOne)
+		savedSearchRoutes.PUT("/:id", jobSeekers.Owned(authz.SavedSearch, "id"), savedSearch.Update)
+		savedSearchRoutes.DELETE("/:id", jobSeekers.Owned(authz.SavedSearch, "id"), savedSearch.Delete)
+	}
+
+	// Admin routes
+	outboxCtrl := NewOutboxController()
+	deleted := NewDeletedController()
+	auditCtrl := NewAuditController()
+	adminRoutes := protected.Group("/admin")
+	{
+		adminRoutes.GET("/outbox", admins, outboxCtrl.Query)
+		adminRoutes.POST("/outbox/:id/retry", admins, outboxCtrl.Retry)
+		adminRoutes.GET("/deleted/:kind", admins, deleted.Query)
+		adminRoutes.POST("/deleted/:kind/:id/restore", admins, deleted.Restore)
+		adminRoutes.GET("/audit", admins, auditCtrl.Query)
 	}
 
 	// Public routes (no auth required)
-	router.GET("/health", func(c *gin.Context) {
+	routes.GET("/health", middleware.Public, func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"ok": true})
 	})
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	routes.GET("/swagger/*any", middleware.Public, ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// NoteController checks who may use a note itself
 	note := NewNoteController()
-	noteRoutes := router.Group("/notes")
-	noteRoutes.Use(middleware.AuthMiddleware())
+	noteRoutes := routes.Group("/notes", middleware.AuthMiddleware())
 	{
-		noteRoutes.GET("/", note.Query)
-		noteRoutes.GET("/query", note.Query)
-		noteRoutes.GET("/:id", note.RetrieveOne)
-		noteRoutes.POST("/", note.Create)
-		noteRoutes.PUT("/:id", note.Update)
-		noteRoutes.DELETE("/:id", note.Delete)
+		noteRoutes.GET("/", middleware.SignedIn, note.Query)
+		noteRoutes.GET("/query", middleware.SignedIn, note.Query)
+		noteRoutes.GET("/:id", middleware.SignedIn, note.RetrieveOne)
+		noteRoutes.POST("/", middleware.SignedIn, note.Create)
+		noteRoutes.PUT("/:id", middleware.SignedIn, note.Update)
+		noteRoutes.DELETE("/:id", middleware.SignedIn, note.Delete)
+	}
+
+	// a route without permission would be closed to everyone, or open to everyone if it is not protected
+	if err := perms.Check(router.Routes()); err != nil {
+		log.Fatal(err)
 	}
 
 	return router
 }
 
+// trustedProxies returns the comma separated IPs and CIDRs of TRUSTED_PROXIES, none when it is unset.
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
 func setUpCors() cors.Config {
 	cfg := cors.Config{
 		AllowOrigins: []string{
 			os.Getenv("FRONTEND"),
 		},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-User-Id", "X-User-Role"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-User-Id", "X-User-Role", middleware.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", middleware.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}
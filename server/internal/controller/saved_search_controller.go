@@ -0,0 +1,336 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/config"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/dto"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SavedSearchController lets job seekers save job searches and get email digests of them.
+type SavedSearchController struct{}
+
+func NewSavedSearchController() SavedSearchController {
+	return SavedSearchController{}
+}
+
+// Create godoc
+// @Summary      Save a job search
+// @Description  Save /jobs/query parameters to receive a daily or weekly email digest of new matching jobs.
+// @Tags         Saved Searches
+// @Accept       json
+// @Produce      json
+// @Param        search  body      schema.SavedSearch  true  "Saved search (name, params, frequency)"
+// @Success      201     {object}  schema.SavedSearch
+// @Failure      400     {object}  map[string]string
+// @Failure      401     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /saved-searches/ [post]
+func (sc SavedSearchController) Create(c *gin.Context) {
+	userInfo := getUserForLogging(c)
+	var search schema.SavedSearch
+	if err := c.ShouldBindJSON(&search); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateSearchParams(search.Params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _, err := getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+	token, err := newRandomToken()
+	if err != nil {
+		msg := "Create Saved Search failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+
+	// the first digest only lists jobs posted from now on
+	now := time.Now()
+	search.ID = primitive.NewObjectID()
+	search.UserID = userID
+	search.Subscribed = true
+	search.UnsubscribeToken = token
+	search.LastRunAt = now
+	search.NextRunAt = now.Add(search.Frequency.Period())
+	search.CreatedAt = now
+	if search.Params == nil {
+		search.Params = map[string]string{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := repository.InsertOne(ctx, search); err != nil {
+		msg := "Create Saved Search failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+
+	slog.Info(userInfo + "Created Saved Search: " + search.ID.Hex())
+	c.JSON(http.StatusCreated, search)
+}
+
+// RetrieveAll godoc
+// @Summary      List saved searches
+// @Description  List the saved searches of the authenticated user.
+// @Tags         Saved Searches
+// @Produce      json
+// @Success      200  {array}   schema.SavedSearch
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /saved-searches/ [get]
+func (sc SavedSearchController) RetrieveAll(c *gin.Context) {
+	userID, _, err := getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	searches, err := repository.FindAll[schema.SavedSearch](ctx, bson.M{"userID": userID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Retrieve Saved Searches failed"})
+		return
+	}
+	if searches == nil {
+		searches = []schema.SavedSearch{}
+	}
+	c.JSON(http.StatusOK, searches)
+}
+
+// RetrieveOne godoc
+// @Summary      Get a saved search
+// @Tags         Saved Searches
+// @Produce      json
+// @Param        id   path      string  true  "Saved search ID"
+// @Success      200  {object}  schema.SavedSearch
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /saved-searches/{id} [get]
+func (sc SavedSearchController) RetrieveOne(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	search, shouldReturn := sc.findOwnSavedSearch(ctx, c)
+	if shouldReturn {
+		return
+	}
+	c.JSON(http.StatusOK, search)
+}
+
+// Update godoc
+// @Summary      Update a saved search
+// @Description  Change the name, params or frequency of a saved search, or (un)subscribe from its digest.
+// @Tags         Saved Searches
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string           true  "Saved search ID"
+// @Param        search  body      dto.SavedSearch  true  "Fields to update"
+// @Success      200     {object}  schema.SavedSearch
+// @Failure      400     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /saved-searches/{id} [put]
+func (sc SavedSearchController) Update(c *gin.Context) {
+	userInfo := getUserForLogging(c)
+	var body dto.SavedSearch
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.Params != nil {
+		if err := validateSearchParams(body.Params); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	search, shouldReturn := sc.findOwnSavedSearch(ctx, c)
+	if shouldReturn {
+		return
+	}
+
+	set := bson.M{}
+	if body.Name != nil {
+		search.Name = *body.Name
+		set["name"] = search.Name
+	}
+	if body.Params != nil {
+		search.Params = body.Params
+		set["params"] = search.Params
+	}
+	if body.Frequency != nil {
+		search.Frequency = schema.DigestFrequency(*body.Frequency)
+		search.NextRunAt = search.LastRunAt.Add(search.Frequency.Period())
+		set["frequency"] = search.Frequency
+		set["nextRunAt"] = search.NextRunAt
+	}
+	if body.Subscribed != nil {
+		search.Subscribed = *body.Subscribed
+		set["subscribed"] = search.Subscribed
+	}
+	if len(set) == 0 {
+		c.JSON(http.StatusOK, search)
+		return
+	}
+
+	if _, err := repository.UpdateOne[schema.SavedSearch](ctx, bson.M{"_id": search.ID}, bson.M{"$set": set}); err != nil {
+		msg := "Update Saved Search failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+
+	slog.Info(userInfo + "Updated Saved Search: " + search.ID.Hex())
+	c.JSON(http.StatusOK, search)
+}
+
+// Delete godoc
+// @Summary      Delete a saved search
+// @Tags         Saved Searches
+// @Produce      json
+// @Param        id   path      string  true  "Saved search ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /saved-searches/{id} [delete]
+func (sc SavedSearchController) Delete(c *gin.Context) {
+	userInfo := getUserForLogging(c)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	search, shouldReturn := sc.findOwnSavedSearch(ctx, c)
+	if shouldReturn {
+		return
+	}
+
+	if _, err := repository.DeleteOne[schema.SavedSearch](ctx, search.ID); err != nil {
+		msg := "Delete Saved Search failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+
+	slog.Info(userInfo + "Deleted Saved Search: " + search.ID.Hex())
+	c.JSON(http.StatusOK, gin.H{"message": "Saved search deleted"})
+}
+
+// UnsubscribeLink godoc
+// @Summary      Open an unsubscribe link
+// @Description  Redirect the unsubscribe link of digests sent before it moved to the frontend to the page
+// @Description  which asks to confirm. It does not unsubscribe, as link scanners of mail servers open links too.
+// @Tags         Saved Searches
+// @Param        token  query  string  true  "Unsubscribe token from the digest email"
+// @Success      303
+// @Router       /saved-searches/unsubscribe [get]
+func (sc SavedSearchController) UnsubscribeLink(c *gin.Context) {
+	c.Redirect(http.StatusSeeOther, unsubscribePageURL(c.Query("token")))
+}
+
+// Unsubscribe godoc
+// @Summary      Unsubscribe from a saved search digest
+// @Description  Stop emailing the digest of a saved search. The token from the digest identifies it, so it needs no login.
+// @Description  This is also the one-click unsubscribe (RFC 8058) of the List-Unsubscribe header of digests.
+// @Tags         Saved Searches
+// @Produce      json
+// @Param        token  query     string  true  "Unsubscribe token from the digest email"
+// @Success      200    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /saved-searches/unsubscribe [post]
+func (sc SavedSearchController) Unsubscribe(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invalid unsubscribe link"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := repository.UpdateOne[schema.SavedSearch](ctx,
+		bson.M{"unsubscribeToken": token},
+		bson.M{"$set": bson.M{"subscribed": false}},
+	)
+	if err != nil {
+		msg := "Unsubscribe from Saved Search failed"
+		slog.Error(msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invalid unsubscribe link"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Unsubscribed from saved search"})
+}
+
+// findOwnSavedSearch loads the saved search in the path and checks that the user owns it.
+func (sc SavedSearchController) findOwnSavedSearch(ctx context.Context, c *gin.Context) (search schema.SavedSearch, shouldReturn bool) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return search, true
+	}
+	userID, role, err := getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return search, true
+	}
+
+	search, err = repository.FindOne[schema.SavedSearch](ctx, objID)
+	// someone else's saved search is reported as missing, to not leak that it exists
+	if err != nil || (search.UserID != userID && role != "admin") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Saved search not found"})
+		return search, true
+	}
+	return search, false
+}
+
+// validateSearchParams checks that params would be accepted by JobController.Query.
+func validateSearchParams(params map[string]string) error {
+	values := url.Values{}
+	for key, value := range params {
+		// saved searches only ever find published jobs
+		if paginationParams[key] || key == "latest" || key == "status" {
+			return fmt.Errorf("%s cannot be saved in a search", key)
+		}
+		values.Set(key, value)
+	}
+	_, err := parseJobQuery(values)
+	return err
+}
+
+// unsubscribePageURL is the frontend page which asks to confirm unsubscribing with token.
+func unsubscribePageURL(token string) string {
+	return config.LoadEnv("FRONTEND") + "/unsubscribe?token=" + url.QueryEscape(token)
+}
+
+// unsubscribeURL is where mail clients POST the one-click unsubscribe of token.
+func unsubscribeURL(token string) string {
+	return config.LoadEnv("SERVER_URL") + "/saved-searches/unsubscribe?token=" + url.QueryEscape(token)
+}
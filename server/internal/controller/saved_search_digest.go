@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/config"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/dto"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/email"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/outbox"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// digestCheckInterval is how often the scheduler looks for saved searches which are due.
+	digestCheckInterval = 15 * time.Minute
+	// maxDigestJobs is the most jobs listed in one digest.
+	maxDigestJobs = 50
+)
+
+// RunSavedSearchDigests queues the digest of every saved search which is due,
+// then keeps doing so every digestCheckInterval until ctx is cancelled.
+func RunSavedSearchDigests(ctx context.Context) {
+	ticker := time.NewTicker(digestCheckInterval)
+	defer ticker.Stop()
+	for {
+		sendDueDigests(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func sendDueDigests(ctx context.Context) {
+	findCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	due, err := repository.FindAll[schema.SavedSearch](findCtx, bson.M{
+		"subscribed": true,
+		"nextRunAt":  bson.M{"$lte": now},
+	})
+	if err != nil {
+		slog.Error("Could not fetch due saved searches: " + err.Error())
+		return
+	}
+	for _, search := range due {
+		if err := sendDigest(ctx, search, now); err != nil {
+			slog.Error(fmt.Sprintf("Could not send digest of saved search %s: %s", search.ID.Hex(), err))
+		}
+	}
+}
+
+// sendDigest emails the jobs which matched search and were posted between its last run and now.
+// Nothing is sent when there are no such jobs.
+func sendDigest(ctx context.Context, search schema.SavedSearch, now time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// Claim this run first by moving the next run, so that a digest is never sent twice
+	// even when several servers run the scheduler.
+	claimed, err := repository.UpdateOne[schema.SavedSearch](ctx,
+		bson.M{"_id": search.ID, "nextRunAt": search.NextRunAt},
+		bson.M{"$set": bson.M{"nextRunAt": now.Add(search.Frequency.Period())}},
+	)
+	if err != nil {
+		return err
+	}
+	if claimed.MatchedCount == 0 {
+		return nil
+	}
+
+	if err := queueDigest(ctx, search, now); err != nil {
+		return err
+	}
+	// only now the jobs up to this run are taken care of,
+	// after a failure the next run lists them along with its own
+	_, err = repository.UpdateOne[schema.SavedSearch](ctx,
+		bson.M{"_id": search.ID},
+		bson.M{"$set": bson.M{"lastRunAt": now}},
+	)
+	return err
+}
+
+// queueDigest queues the email of the jobs which matched search between its last run and now, if any.
+func queueDigest(ctx context.Context, search schema.SavedSearch, now time.Time) error {
+	jobs, err := findNewJobs(ctx, search, now)
+	if err != nil || len(jobs) == 0 {
+		return err
+	}
+
+	user, err := repository.FindOne[schema.User](ctx, search.UserID)
+	if err != nil || user.Email == "" {
+		return err
+	}
+	msg, err := email.Compose(user.Email, user.Locale, digestData(search, jobs))
+	if err != nil {
+		return err
+	}
+	msg.UnsubscribeURL = unsubscribeURL(search.UnsubscribeToken)
+	return outbox.Enqueue(ctx, msg)
+}
+
+// findNewJobs runs search and keeps only the jobs which opened between its last run and now:
+// jobs created since the last run which are already open, and jobs which were
+// created earlier but only opened since then.
+func findNewJobs(ctx context.Context, search schema.SavedSearch, now time.Time) ([]dto.JobQueryResult, error) {
+	params := url.Values{}
+	for key, value := range search.Params {
+		params.Set(key, value)
+	}
+	query, err := parseJobQuery(params)
+	if err != nil {
+		return nil, err
+	}
+
+	lastRunID := primitive.NewObjectIDFromTimestamp(search.LastRunAt)
+	newSinceLastRun := bson.M{"$or": bson.A{
+		bson.M{"_id": bson.M{"$gt": lastRunID}, "postOpenDate": bson.M{"$lte": now}},
+		bson.M{"postOpenDate": bson.M{"$gt": search.LastRunAt, "$lte": now}},
+	}}
+	and, _ := query.filter["$and"].(bson.A)
+	query.filter["$and"] = append(and, newSinceLastRun)
+
+	page, err := findJobs(ctx, query, repository.PageRequest{Limit: maxDigestJobs})
+	return page.Data, err
+}
+
+func digestData(search schema.SavedSearch, jobs []dto.JobQueryResult) email.SavedSearchDigest {
+	frontend := config.LoadEnv("FRONTEND")
+	data := email.SavedSearchDigest{
+		SearchName:     email.SanitizeEmailBodyField(search.Name),
+		UnsubscribeURL: unsubscribePageURL(search.UnsubscribeToken),
+	}
+	for _, job := range jobs {
+		data.Jobs = append(data.Jobs, email.DigestJob{
+			Title:    email.SanitizeEmailBodyField(job.Title),
+			Location: email.SanitizeEmailBodyField(job.Location),
+			URL:      frontend + "/app/jobs/" + job.ID.Hex(),
+		})
+	}
+	return data
+}
@@ -2,14 +2,20 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/audit"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/auth"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/dto"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/email"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/outbox"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
 	"go.mongodb.org/mongo-driver/bson"
@@ -37,7 +43,9 @@ func NewUserController() UserController {
 // @Produce      json
 // @Param        id    query     string  false  "User ID (ObjectID)"
 // @Param        role  query     string  false  "User role (e.g. company, jobSeeker)"
-// @Success      200   {array}   schema.User
+// @Param        limit query integer false "Page size (default 20, max 100)"
+// @Param        cursor query string false "Cursor from the previous page's nextCursor"
+// @Success      200   {object}  repository.Page[schema.User]
 // @Failure      400   {object}  map[string]string
 // @Failure      500   {object}  map[string]string
 // @Router       /users/query [get]
@@ -47,10 +55,20 @@ func (jc UserController) Query(c *gin.Context) {
 		return
 	}
 
+	page, err := getPageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	users, err := repository.FindAll[schema.User](ctx, userFilter)
+	users, err := repository.FindPage[schema.User](ctx, userFilter, page)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -79,6 +97,9 @@ func userFilter(c *gin.Context) (bson.M, bool) {
 
 	// Loop through query params
 	for key, value := range c.Request.URL.Query() {
+		if paginationParams[key] {
+			continue
+		}
 		if fn, ok := allowedParams[key]; ok {
 			val, err := fn(value[0])
 			if err != nil {
@@ -103,6 +124,7 @@ func userFilter(c *gin.Context) (bson.M, bool) {
 // Update godoc
 // @Summary      Update an existing user
 // @Description  Modify user data by providing the user ID in the path and updated JSON body.
+// @Description  userInfo is validated as a CompanyInfo for companies and a JobSeekerInfo for job seekers.
 // @Tags         Users
 // @Accept       json
 // @Produce      json
@@ -114,7 +136,109 @@ func userFilter(c *gin.Context) (bson.M, bool) {
 // @Failure      500   {object}  map[string]string
 // @Router       /users/{id} [put]
 func (jc UserController) Update(c *gin.Context) {
-	jc.baseController.Update(c)
+	userInfo := getUserForLogging(c)
+	id := c.Param("id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		msg := "Update User failed: invalid ID"
+		slog.Warn(userInfo + msg + ": " + id)
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	var newData dto.User
+	if err := c.ShouldBindBodyWithJSON(&newData); err != nil {
+		msg := "Update User failed: incorrect request body"
+		slog.Warn(userInfo + msg)
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	before, err := repository.FindOne[schema.User](ctx, objID)
+	if err != nil {
+		msg := "Update User failed: resource not found"
+		slog.Warn(userInfo + msg)
+		c.JSON(http.StatusNotFound, gin.H{"error": msg})
+		return
+	}
+
+	if newData.UserInfo != nil {
+		// the profile is validated against the role the user has after the update
+		role := before.Role
+		if newData.Role != nil {
+			role = *newData.Role
+		}
+		profile, err := decodeUserInfo(role, *newData.UserInfo)
+		if err != nil {
+			msg := "Update User failed: " + err.Error()
+			slog.Warn(userInfo + msg)
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		newData.Profile = &profile
+	}
+
+	res, err := repository.Update[schema.User](ctx, objID, newData)
+	if err != nil {
+		msg := "Update User failed"
+		slog.Error(userInfo + msg + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+	if res.MatchedCount == 0 {
+		msg := "Update User failed: resource not found"
+		slog.Warn(userInfo + msg)
+		c.JSON(http.StatusNotFound, gin.H{"error": msg})
+		return
+	}
+
+	msg := "Updated User: " + id
+	slog.Info(userInfo + msg)
+	c.JSON(http.StatusOK, gin.H{"message": msg})
+
+	if newData.Banned != nil && *newData.Banned != before.Banned {
+		action := schema.AuditUnban
+		if *newData.Banned {
+			action = schema.AuditBan
+		}
+		auditUserChange(ctx, c, action, before)
+	}
+	if newData.Role != nil && *newData.Role != before.Role {
+		auditUserChange(ctx, c, schema.AuditRoleChange, before)
+	}
+}
+
+// auditUserChange records that the signed-in user did action to the user who was before,
+// along with what it changed.
+func auditUserChange(ctx context.Context, c *gin.Context, action schema.AuditAction, before schema.User) {
+	event := schema.AuditEvent{Action: action, TargetType: before.GetCollectionName(), TargetID: before.ID}
+	if after, err := repository.FindOne[schema.User](ctx, before.ID); err == nil {
+		event.Changes = audit.Diff(before, after)
+	}
+	audit.Record(c, event)
+}
+
+// decodeUserInfo decodes and validates raw as the profile of a user with role.
+func decodeUserInfo(role string, raw json.RawMessage) (schema.UserInfo, error) {
+	profile := schema.NewUserInfo(role)
+	if profile == nil {
+		return nil, fmt.Errorf("users with role %q have no profile", role)
+	}
+	if err := json.Unmarshal(raw, profile); err != nil {
+		return nil, errors.New("incorrect userInfo")
+	}
+	if err := binding.Validator.ValidateStruct(profile); err != nil {
+		return nil, err
+	}
+	if v, ok := profile.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return profile, nil
 }
 
 // Create godoc
@@ -134,7 +258,7 @@ func (jc UserController) Create(c *gin.Context) {
 
 // Delete godoc
 // @Summary      Delete a user
-// @Description  Remove a user document from the database by ID.
+// @Description  Delete a user by ID. An admin can restore the user until it is purged.
 // @Tags         Users
 // @Accept       json
 // @Produce      json
@@ -158,27 +282,39 @@ func (jc UserController) Delete(c *gin.Context) {
 
 	user, err := repository.FindOne[schema.User](ctx, oid)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot find user"})
+		c.JSON(http.StatusNotFound, gin.H{"error": "cannot find user"})
+		return
 	}
 
-	emailBody := fmt.Sprintf(
-		"Dear %s,\nYour account has been deleted by the administrator. If you beleive this is a mistake, please reply to this email immediately.\nRegards,\nJob Applier 3000", user.Name,
-	)
-
-	if err := email.Send(user.Email, "User Deletion Notice", emailBody); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send email"})
+	jc.baseController.Delete(c)
+	if c.Writer.Status() != http.StatusOK {
 		return
 	}
-	jc.baseController.Delete(c)
+
+	audit.Record(c, schema.AuditEvent{Action: schema.AuditDelete, TargetType: user.GetCollectionName(), TargetID: user.ID})
+	notifyUser(ctx, c, user, email.AccountDeleted{Name: user.Name})
+}
+
+// notifyUser queues an email to user, a failure is only logged since the change itself is already saved.
+func notifyUser(ctx context.Context, c *gin.Context, user schema.User, data email.TemplateData) {
+	msg, err := email.Compose(user.Email, user.Locale, data)
+	if err == nil {
+		err = outbox.Enqueue(ctx, msg)
+	}
+	if err != nil {
+		slog.Warn(fmt.Sprintf("%sNotifying user %s failed: %s", getUserForLogging(c), user.ID.Hex(), err))
+	}
 }
 
 // RetrieveAll godoc
 // @Summary      Retrieve all users
-// @Description  Fetch all user documents from the database.
+// @Description  Fetch user documents from the database, one page at a time.
 // @Tags         Users
 // @Accept       json
 // @Produce      json
-// @Success      200   {array}   schema.User
+// @Param        limit query integer false "Page size (default 20, max 100)"
+// @Param        cursor query string false "Cursor from the previous page's nextCursor"
+// @Success      200   {object}  repository.Page[schema.User]
 // @Failure      500   {object}  map[string]string
 // @Router       /users/ [get]
 func (jc UserController) RetrieveAll(c *gin.Context) {
@@ -223,7 +359,7 @@ func (jc UserController) VerifyUser(c *gin.Context) {
 		return
 	}
 
-	claims, err := auth.ParseJWT(tokenStr)
+	claims, err := auth.ParseToken(tokenStr, auth.TokenAccess)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 		return
@@ -234,38 +370,32 @@ func (jc UserController) VerifyUser(c *gin.Context) {
 		return
 	}
 
-	jc.baseController.Update(c)
-
-	uid := c.Param("id")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	oid, err := getPrimitiveObjID(uid)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "wrong UserID"})
+	oid, _ := getPrimitiveObjID(c.Param("id"))
+	before, _ := repository.FindOne[schema.User](ctx, oid)
+	jc.baseController.Update(c)
+	if c.Writer.Status() != http.StatusOK {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
 	user, err := repository.FindOne[schema.User](ctx, oid)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot find user"})
+		slog.Warn(getUserForLogging(c) + "cannot find verified user to notify: " + err.Error())
+		return
 	}
-	var verificationStatus string
+	action := schema.AuditUnverify
 	if user.Verified {
-		verificationStatus = "verified"
-	} else {
-		verificationStatus = "unverified"
+		action = schema.AuditVerify
 	}
-	emailBody := fmt.Sprintf(
-		"Dear %s,\nYour account has been %s.", user.Name, verificationStatus,
-	)
-
-	if err := email.Send(user.Email, "User Account Verification Notice", emailBody); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send email"})
-		return
-	}
-
+	audit.Record(c, schema.AuditEvent{
+		Action:     action,
+		TargetType: user.GetCollectionName(),
+		TargetID:   user.ID,
+		Changes:    audit.Diff(before, user),
+	})
+	notifyUser(ctx, c, user, email.AccountVerification{Name: user.Name, Verified: user.Verified})
 }
 
 // EditPermission godoc
@@ -290,7 +420,7 @@ func (jc UserController) EditPermission(c *gin.Context) {
 		return
 	}
 
-	claims, err := auth.ParseJWT(tokenStr)
+	claims, err := auth.ParseToken(tokenStr, auth.TokenAccess)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 		return
@@ -301,29 +431,28 @@ func (jc UserController) EditPermission(c *gin.Context) {
 		return
 	}
 
-	jc.baseController.Update(c)
-
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	uid := c.Param("id")
-	oid, err := getPrimitiveObjID(uid)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "wrong UserID"})
+
+	oid, _ := getPrimitiveObjID(c.Param("id"))
+	before, _ := repository.FindOne[schema.User](ctx, oid)
+	jc.baseController.Update(c)
+	if c.Writer.Status() != http.StatusOK {
 		return
 	}
 
 	user, err := repository.FindOne[schema.User](ctx, oid)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot find user"})
-	}
-	emailBody := fmt.Sprintf(
-		"Dear %s, \n Your account permission has been changed to %s", user.Name, user.Role,
-	)
-
-	if err := email.Send(user.Email, "User Permission Change Notice", emailBody); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send email"})
+		slog.Warn(getUserForLogging(c) + "cannot find user to notify of permission change: " + err.Error())
 		return
 	}
+	audit.Record(c, schema.AuditEvent{
+		Action:     schema.AuditRoleChange,
+		TargetType: user.GetCollectionName(),
+		TargetID:   user.ID,
+		Changes:    audit.Diff(before, user),
+	})
+	notifyUser(ctx, c, user, email.RoleChanged{Name: user.Name, Role: user.Role})
 }
 
 // GetPublicInfo godoc
@@ -354,8 +483,11 @@ func (jc UserController) GetPublicInfo(c *gin.Context) {
 		return
 	}
 
-	// Extract custom name and logo from userInfo if available
-	customName, customLogo := extractUserInfo(user.UserInfo)
+	// Take custom name and logo from the company profile if available
+	var customName, customLogo string
+	if info, ok := user.UserInfo.(*schema.CompanyInfo); ok {
+		customName, customLogo = info.Name, info.Logo
+	}
 
 	resp := gin.H{
 		"name":         user.Name, // default top-level name
@@ -380,9 +512,3 @@ func (jc UserController) GetPublicInfo(c *gin.Context) {
 
 	c.JSON(http.StatusOK, resp)
 }
-
-func extractUserInfo(info bson.M) (name string, logo string) {
-	name, _ = info["name"].(string)
-	logo, _ = info["logo"].(string)
-	return
-}
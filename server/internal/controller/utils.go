@@ -2,6 +2,8 @@ package controller
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"os"
@@ -139,3 +141,12 @@ func getPrimitiveObjID(objID any) (primitive.ObjectID, error) {
 
 	return oid, nil
 }
+
+// newRandomToken returns 32 random bytes, hex encoded, for tokens sent in links such as invitations.
+func newRandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
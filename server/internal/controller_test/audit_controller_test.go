@@ -0,0 +1,86 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAuditLog(t *testing.T) {
+	router := getTestRouter()
+	admin := primitive.NewObjectID()
+	seeker := insertJobSeeker(t, "audited")
+	start := time.Now().Add(-time.Second).UTC().Format(time.RFC3339)
+
+	w := httptest.NewRecorder()
+	raw, _ := json.Marshal(map[string]bool{"banned": true})
+	req, _ := http.NewRequest("PUT", "/users/"+seeker.ID.Hex(), bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-Id", admin.Hex())
+	req.Header.Set("X-User-Role", "admin")
+	req.Header.Set("X-Request-Id", "audit-test-1")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "audit-test-1", w.Header().Get("X-Request-Id"))
+
+	r := regexp.MustCompile(`"InsertedID":"(.+)"`)
+	jobID := createJob(router, r)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/jobs/"+jobID, bytes.NewReader([]byte(`{"reason": "duplicate posting"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-Id", admin.Hex())
+	req.Header.Set("X-User-Role", "admin")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	audit := func(query string) []schema.AuditEvent {
+		w := resumeRequest(router, "GET", "/admin/audit?"+query, admin, "admin", nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page struct {
+			Data []schema.AuditEvent `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page.Data
+	}
+
+	events := audit("targetID=" + seeker.ID.Hex())
+	if assert.Len(t, events, 1) {
+		ban := events[0]
+		assert.Equal(t, schema.AuditBan, ban.Action)
+		assert.Equal(t, "users", ban.TargetType)
+		if assert.NotNil(t, ban.ActorID) {
+			assert.Equal(t, admin, *ban.ActorID)
+		}
+		assert.Equal(t, "admin", ban.ActorRole)
+		assert.Equal(t, "audit-test-1", ban.RequestID)
+		assert.NotEmpty(t, ban.IP)
+		assert.Equal(t, []schema.AuditChange{{Field: "banned", Before: nil, After: true}}, ban.Changes)
+	}
+
+	events = audit("targetID=" + jobID + "&action=delete")
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "jobs", events[0].TargetType)
+		assert.Equal(t, "duplicate posting", events[0].Detail)
+	}
+
+	// newest first
+	events = audit("actorID=" + admin.Hex() + "&from=" + start)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, schema.AuditDelete, events[0].Action)
+		assert.Equal(t, schema.AuditBan, events[1].Action)
+	}
+	assert.Empty(t, audit("actorID="+admin.Hex()+"&to="+start))
+
+	w = resumeRequest(router, "GET", "/admin/audit?from=yesterday", admin, "admin", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = resumeRequest(router, "GET", "/admin/audit?actorID=nobody", admin, "admin", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,99 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func cohortRequest(router *gin.Engine, method, path string, userID primitive.ObjectID, role string, body any) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	raw, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, path, bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-Id", userID.Hex())
+	req.Header.Set("X-User-Role", role)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCohortOutcomesNeedConsent(t *testing.T) {
+	router := getTestRouter()
+	teacher := primitive.NewObjectID()
+	student := schema.User{
+		ID:    primitive.NewObjectID(),
+		Name:  "Cohort Student",
+		Email: "student@cohort.example.com",
+		Role:  schema.RoleJobSeeker,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	db := database.GetDatabase()
+	_, err := db.Collection("users").InsertOne(ctx, student)
+	assert.NoError(t, err)
+	_, err = db.Collection("job_applications").InsertOne(ctx, schema.JobApplication{
+		ID:          primitive.NewObjectID(),
+		ApplicantID: student.ID,
+		JobID:       primitive.NewObjectID(),
+		Status:      schema.StatusAccepted,
+		CreatedAt:   time.Now(),
+	})
+	assert.NoError(t, err)
+
+	w := cohortRequest(router, "POST", "/cohorts/", teacher, "faculty", map[string]any{"name": "CPE 2026"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var cohort schema.Cohort
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &cohort))
+	path := "/cohorts/" + cohort.ID.Hex()
+
+	w = cohortRequest(router, "POST", path+"/students", teacher, "faculty", map[string]any{"email": student.Email})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	w = cohortRequest(router, "POST", path+"/students", teacher, "faculty", map[string]any{"email": student.Email})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// nothing is shared before the student consents
+	w = cohortRequest(router, "GET", path+"/outcomes", teacher, "faculty", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var outcomes schema.CohortOutcomes
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &outcomes))
+	assert.Equal(t, 1, outcomes.Students)
+	assert.Zero(t, outcomes.Applications)
+	endorsement := map[string]any{"comment": "A reliable team player"}
+	w = cohortRequest(router, "PUT", path+"/students/"+student.ID.Hex()+"/endorsement", teacher, "faculty", endorsement)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = cohortRequest(router, "PUT", path+"/consent", student.ID, "jobSeeker", map[string]any{"consent": true})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = cohortRequest(router, "GET", path+"/outcomes", teacher, "faculty", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &outcomes))
+	assert.Equal(t, 1, outcomes.Applications)
+	assert.Equal(t, 1, outcomes.Placed)
+
+	w = cohortRequest(router, "PUT", path+"/students/"+student.ID.Hex()+"/endorsement", teacher, "faculty", endorsement)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = cohortRequest(router, "GET", "/users/"+student.ID.Hex()+"/endorsements", primitive.NewObjectID(), "company", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "A reliable team player")
+
+	// withdrawing consent also withdraws the endorsement
+	w = cohortRequest(router, "PUT", path+"/consent", student.ID, "jobSeeker", map[string]any{"consent": false})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = cohortRequest(router, "GET", "/users/"+student.ID.Hex()+"/endorsements", primitive.NewObjectID(), "company", nil)
+	assert.Equal(t, "[]", w.Body.String())
+
+	// students can only consent to cohorts they are in
+	w = cohortRequest(router, "PUT", path+"/consent", primitive.NewObjectID(), "jobSeeker", map[string]any{"consent": true})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,95 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDeleteJobCanBeRestored(t *testing.T) {
+	router := getTestRouter()
+	r := regexp.MustCompile(`"InsertedID":"(.+)"`)
+	applicant := createUser(router, r, "restored applicant")
+	jobID := createJob(router, r)
+	w, _ := createJobApplication(router, applicant, jobID)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	appID := r.FindStringSubmatch(w.Body.String())[1]
+	appObjID, _ := primitive.ObjectIDFromHex(appID)
+	w, _ = createNote(router, r, appObjID, "strong candidate")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	noteID := r.FindStringSubmatch(w.Body.String())[1]
+
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	post := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/jobs/"+jobID, bytes.NewReader([]byte(`{"reason": "misclick"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// the job and everything under it is gone for everyone, but still in the database
+	assert.Equal(t, http.StatusNotFound, get("/jobs/"+jobID))
+	assert.Equal(t, http.StatusNotFound, get("/apply/"+appID))
+	assert.Equal(t, http.StatusNotFound, get("/notes/"+noteID))
+	jobObjID, _ := primitive.ObjectIDFromHex(jobID)
+	var stored schema.Job
+	err := database.GetDatabase().Collection("jobs").FindOne(context.Background(), bson.M{"_id": jobObjID}).Decode(&stored)
+	assert.NoError(t, err)
+	assert.True(t, stored.IsDeleted())
+
+	// the application cannot come back without its job
+	w = post("/admin/deleted/applications/" + appID + "/restore")
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/admin/deleted/jobs?limit=100", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var deleted struct {
+		Data []schema.Job `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &deleted))
+	found := false
+	for _, job := range deleted.Data {
+		found = found || job.ID == jobObjID
+	}
+	assert.True(t, found, "deleted job is listed")
+
+	w = post("/admin/deleted/jobs/" + jobID + "/restore")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var restored schema.Job
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &restored))
+	assert.False(t, restored.IsDeleted())
+	assert.Equal(t, http.StatusOK, get("/jobs/"+jobID))
+	assert.Equal(t, http.StatusOK, get("/apply/"+appID))
+	assert.Equal(t, http.StatusOK, get("/notes/"+noteID))
+
+	// only deleted documents can be restored
+	assert.Equal(t, http.StatusNotFound, post("/admin/deleted/jobs/"+jobID+"/restore").Code)
+	assert.Equal(t, http.StatusNotFound, post("/admin/deleted/resumes/"+jobID+"/restore").Code)
+
+	assert.Equal(t, http.StatusOK, deleteJobApplication(appID, router).Code)
+	assert.Equal(t, http.StatusNotFound, get("/notes/"+noteID))
+	assert.Equal(t, http.StatusNotFound, deleteJobApplication(appID, router).Code)
+}
@@ -7,6 +7,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
 	"os"
 	"testing"
 	"time"
@@ -523,3 +524,56 @@ func TestFileCompanyCannotAccessOtherCompanyFiles(t *testing.T) {
 	json.Unmarshal(w.Body.Bytes(), &response)
 	assert.Contains(t, response["error"], "your own jobs")
 }
+
+// Test 11: Uploaded content goes to the blob store and streams back on download
+func TestFileUploadAndDownloadThroughBlobStore(t *testing.T) {
+	setupFileTestData(t)
+	router := getTestRouter()
+
+	testContent := []byte("%PDF-1.4\n%Blob store round trip\n%%EOF")
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	partHeader := textproto.MIMEHeader{}
+	partHeader.Set("Content-Disposition", `form-data; name="file"; filename="round-trip.pdf"`)
+	partHeader.Set("Content-Type", "application/pdf")
+	part, _ := writer.CreatePart(partHeader)
+	part.Write(testContent)
+	writer.WriteField("category", "resume")
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/files/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("X-User-Id", testFileUserID1.Hex())
+	req.Header.Set("X-User-Role", "jobSeeker")
+
+	w1 := httptest.NewRecorder()
+	router.ServeHTTP(w1, req)
+	assert.Equal(t, http.StatusCreated, w1.Code)
+
+	var uploaded map[string]interface{}
+	json.Unmarshal(w1.Body.Bytes(), &uploaded)
+	fileID := uploaded["id"].(string)
+
+	// content must not be stored inline anymore
+	db := database.GetDatabase()
+	objID, _ := primitive.ObjectIDFromHex(fileID)
+	count, _ := db.Collection("files").CountDocuments(context.Background(), bson.M{
+		"_id":        objID,
+		"content":    bson.M{"$exists": false},
+		"storageKey": fileID,
+	})
+	assert.Equal(t, int64(1), count)
+
+	req, _ = http.NewRequest("GET", "/files/download/"+fileID, nil)
+	req.Header.Set("X-User-Id", testFileUserID1.Hex())
+	req.Header.Set("X-User-Role", "jobSeeker")
+
+	w2 := httptest.NewRecorder()
+	router.ServeHTTP(w2, req)
+
+	assert.Equal(t, http.StatusOK, w2.Code)
+	assert.Equal(t, "application/pdf", w2.Header().Get("Content-Type"))
+	assert.Contains(t, w2.Header().Get("Content-Disposition"), "round-trip.pdf")
+	assert.Equal(t, testContent, w2.Body.Bytes())
+}
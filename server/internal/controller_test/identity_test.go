@@ -0,0 +1,48 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUnlinkProvider(t *testing.T) {
+	router := getTestRouter()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	user := schema.User{
+		ID:       primitive.NewObjectID(),
+		Name:     "Linked Twice",
+		Role:     "jobSeeker",
+		Provider: "google",
+		UserID:   primitive.NewObjectID().Hex(),
+		Identities: []schema.Identity{
+			{Provider: "google", Subject: primitive.NewObjectID().Hex(), LinkedAt: time.Now()},
+			{Provider: "github", Subject: primitive.NewObjectID().Hex(), LinkedAt: time.Now()},
+		},
+	}
+	_, err := repository.InsertOne(ctx, user)
+	assert.NoError(t, err)
+
+	w := sessionRequest(router, "DELETE", "/auth/identities/github", user.ID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var remaining []schema.Identity
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &remaining))
+	assert.Len(t, remaining, 1)
+	assert.Equal(t, "google", remaining[0].Provider)
+
+	w = sessionRequest(router, "DELETE", "/auth/identities/github", user.ID)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// the user could not log in anymore
+	w = sessionRequest(router, "DELETE", "/auth/identities/google", user.ID)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
@@ -2,6 +2,7 @@ package controller_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -11,7 +12,10 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -31,7 +35,7 @@ func rawJobApplication(applicantID primitive.ObjectID, jobID primitive.ObjectID)
 	return map[string]any{
 		"applicantID": applicantID,
 		"jobID":       jobID,
-		"status":      "waiting for approval",
+		"status":      "PENDING",
 		"createdAt":   now,
 	}
 }
@@ -121,3 +125,244 @@ func TestCreateAndDeleteJobApplication(t *testing.T) {
 	w3 := deleteJobApplication(jobAppID, router)
 	assert.Equal(t, http.StatusOK, w3.Code)
 }
+
+func updateJobApplicationStatus(router *gin.Engine, jobAppID string, status string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	body, _ := json.Marshal(map[string]string{"status": status})
+	req, _ := http.NewRequest("PUT", "/apply/"+jobAppID, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// Test that status changes follow the state machine and end up in the timeline.
+// Without auth the fake user is a job seeker, who may only withdraw.
+func TestJobApplicationStatusTransitions(t *testing.T) {
+	router := getTestRouter()
+	r := regexp.MustCompile(`"InsertedID":"(.+)"`)
+	userID := createUser(router, r, "Statusstatusstatus")
+	jobID := createJob(router, r)
+
+	w, _ := createJobApplication(router, userID, jobID)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	jobAppID := r.FindStringSubmatch(w.Body.String())[1]
+	defer deleteJobApplication(jobAppID, router)
+
+	// a job seeker cannot move their own application forward
+	w = updateJobApplicationStatus(router, jobAppID, "SCREENING")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = updateJobApplicationStatus(router, jobAppID, "WITHDRAWN")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// WITHDRAWN is final
+	w = updateJobApplicationStatus(router, jobAppID, "WITHDRAWN")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/apply/"+jobAppID+"/timeline", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Status   string                `json:"status"`
+		Timeline []schema.StatusChange `json:"timeline"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "WITHDRAWN", response.Status)
+	if assert.Len(t, response.Timeline, 2) {
+		assert.Equal(t, schema.StatusPending, response.Timeline[0].To)
+		assert.Equal(t, schema.StatusWithdrawn, response.Timeline[1].To)
+	}
+}
+
+// Test that the applicant is emailed when the company moves their application forward,
+// but not when they withdraw it themselves.
+func TestJobApplicationStatusChangeNotifiesApplicant(t *testing.T) {
+	router := getTestRouter()
+	r := regexp.MustCompile(`"InsertedID":"(.+)"`)
+	address := primitive.NewObjectID().Hex() + "@example.com"
+	userID := createUserWithEmail(router, r, "Notified Applicant", address)
+	companyID := primitive.NewObjectID()
+	jobID := createJob(router, r, companyID.Hex())
+
+	w, _ := createJobApplication(router, userID, jobID)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	jobAppID := r.FindStringSubmatch(w.Body.String())[1]
+	defer deleteJobApplication(jobAppID, router)
+
+	w = httptest.NewRecorder()
+	body, _ := json.Marshal(map[string]string{"status": "SCREENING"})
+	req, _ := http.NewRequest("PUT", "/apply/"+jobAppID, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-Id", companyID.Hex())
+	req.Header.Set("X-User-Role", "company")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	sent := deliveredEmails(t, address)
+	if assert.Len(t, sent, 1) {
+		assert.Equal(t, "Your job application is being reviewed", sent[0].Subject)
+		assert.Contains(t, sent[0].Body, "Job for Job Application Creation Test")
+	}
+
+	w = updateJobApplicationStatus(router, jobAppID, "WITHDRAWN")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, deliveredEmails(t, address), 1)
+}
+
+func jsonRequest(router *gin.Engine, method, path string, body any) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	raw, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, path, bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// Test that applications must answer the screening questions of the job,
+// and that the answers stay as they were sent when the questions change.
+func TestJobApplicationScreeningAnswers(t *testing.T) {
+	router := getTestRouter()
+	r := regexp.MustCompile(`"InsertedID":"(.+)"`)
+	userID := createUser(router, r, "Answerer")
+
+	job := rawJob("Job with screening questions")
+	job["questions"] = []map[string]any{
+		{"prompt": "Can you work night shifts?", "type": "yesNo", "required": true},
+		{"prompt": "Preferred stack", "type": "singleChoice", "options": []string{"Go", "Rust"}},
+	}
+	w := jsonRequest(router, "POST", "/jobs/", job)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	jobID := r.FindStringSubmatch(w.Body.String())[1]
+
+	w = jsonRequest(router, "GET", "/jobs/"+jobID, nil)
+	var saved schema.Job
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &saved))
+	if !assert.Len(t, saved.Questions, 2) {
+		return
+	}
+	shifts, stack := saved.Questions[0], saved.Questions[1]
+	assert.NotEmpty(t, shifts.ID)
+
+	application := func(answers ...map[string]any) map[string]any {
+		raw := rawJobApplication(primitive.NewObjectID(), primitive.NewObjectID())
+		raw["applicantID"], raw["jobID"], raw["answers"] = userID, jobID, answers
+		return raw
+	}
+
+	// the required question is not answered
+	w = jsonRequest(router, "POST", "/apply/", application(map[string]any{"questionID": stack.ID, "choices": []string{"Go"}}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	// Java is not an option
+	w = jsonRequest(router, "POST", "/apply/", application(
+		map[string]any{"questionID": shifts.ID, "yes": true},
+		map[string]any{"questionID": stack.ID, "choices": []string{"Java"}},
+	))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = jsonRequest(router, "POST", "/apply/", application(
+		map[string]any{"questionID": shifts.ID, "yes": true},
+		map[string]any{"questionID": stack.ID, "choices": []string{"Go"}},
+	))
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	jobAppID := r.FindStringSubmatch(w.Body.String())[1]
+	defer deleteJobApplication(jobAppID, router)
+
+	// the company rewords a question and replaces the other one
+	shifts.Prompt = "Can you work weekends?"
+	w = jsonRequest(router, "PUT", "/jobs/"+jobID, map[string]any{"questions": []schema.ScreeningQuestion{
+		shifts,
+		{Prompt: "Years of experience", Type: schema.QuestionNumber},
+	}})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = jsonRequest(router, "GET", "/apply/query?jobID="+jobID, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var page struct {
+		Data []schema.ApplicationWithApplicant `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	if assert.Len(t, page.Data, 1) {
+		answers := page.Data[0].JobApplication.Answers
+		if assert.Len(t, answers, 2) {
+			assert.Equal(t, "Can you work night shifts?", answers[0].Prompt)
+			assert.True(t, *answers[0].Yes)
+			assert.Equal(t, []string{"Go"}, answers[1].Choices)
+		}
+		assert.NotNil(t, page.Data[0].Applicant)
+	}
+
+	// invalid questions are rejected on update too
+	w = jsonRequest(router, "PUT", "/jobs/"+jobID, map[string]any{"questions": []map[string]any{{"prompt": "Pick", "type": "singleChoice"}}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// Test that jobs with autoReject reject applications failing their knockout rules,
+// explain why, and email the applicant only once the delay has passed.
+func TestJobApplicationAutoReject(t *testing.T) {
+	router := getTestRouter()
+	r := regexp.MustCompile(`"InsertedID":"(.+)"`)
+	address := primitive.NewObjectID().Hex() + "@example.com"
+	userID := createUserWithEmail(router, r, "Knocked Out", address)
+
+	job := rawJob("Job with knockout rules")
+	job["autoReject"] = true
+	job["questions"] = []map[string]any{{"id": "nights", "prompt": "Can you work night shifts?", "type": "yesNo", "required": true}}
+	job["knockoutRules"] = []map[string]any{{"type": "answer", "questionID": "nights", "values": []string{"yes"}}}
+	job["rejectionEmail"] = map[string]any{"send": true, "delayHours": 24, "message": "Thanks for your interest"}
+	w := jsonRequest(router, "POST", "/jobs/", job)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	jobID := r.FindStringSubmatch(w.Body.String())[1]
+
+	// rules must be about questions of the job
+	invalid := rawJob("Job with a rule on no question")
+	invalid["autoReject"] = true
+	invalid["knockoutRules"] = []map[string]any{{"type": "answer", "questionID": "nights", "values": []string{"yes"}}}
+	w = jsonRequest(router, "POST", "/jobs/", invalid)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	apply := func(nights bool) schema.JobApplication {
+		raw := rawJobApplication(primitive.NewObjectID(), primitive.NewObjectID())
+		raw["applicantID"], raw["jobID"] = userID, jobID
+		raw["answers"] = []map[string]any{{"questionID": "nights", "yes": nights}}
+		w := jsonRequest(router, "POST", "/apply/", raw)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		jobAppID := r.FindStringSubmatch(w.Body.String())[1]
+		t.Cleanup(func() { deleteJobApplication(jobAppID, router) })
+
+		w = jsonRequest(router, "GET", "/apply/"+jobAppID, nil)
+		var app schema.JobApplication
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &app))
+		return app
+	}
+
+	passed := apply(true)
+	assert.Equal(t, schema.StatusPending, passed.Status)
+	if assert.NotNil(t, passed.Knockout) {
+		assert.True(t, passed.Knockout.Passed)
+	}
+
+	rejected := apply(false)
+	assert.Equal(t, schema.StatusRejected, rejected.Status)
+	if assert.NotNil(t, rejected.Knockout) && assert.Len(t, rejected.Knockout.Checks, 1) {
+		assert.False(t, rejected.Knockout.Passed)
+		assert.Equal(t, `answered "Can you work night shifts?" with no, accepted: yes`, rejected.Knockout.Checks[0].Detail)
+	}
+	if assert.Len(t, rejected.StatusHistory, 2) {
+		assert.Equal(t, schema.ActorSystem, rejected.StatusHistory[1].ActorRole)
+		assert.Equal(t, schema.ReasonAnswerNotAccepted, rejected.StatusHistory[1].ReasonCode)
+	}
+
+	// the rejection email waits for its delay
+	assert.Empty(t, deliveredEmails(t, address))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var queued schema.OutboxMessage
+	err := database.GetDatabase().Collection(queued.GetCollectionName()).FindOne(ctx, bson.M{"to": address}).Decode(&queued)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Update on your application for Job with knockout rules", queued.Subject)
+		assert.Contains(t, queued.Body, "Thanks for your interest")
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), queued.NextAttemptAt, time.Minute)
+	}
+}
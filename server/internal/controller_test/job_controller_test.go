@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -39,7 +40,7 @@ func rawJob(title string, companyID ...string) map[string]any {
 
 		// post settings
 		"postOpenDate":        now,
-		"applicationDeadline": now,
+		"applicationDeadline": now.Add(30 * 24 * time.Hour),
 		"numberOfPositions":   1,
 		"visibility":          "public",
 		"emailNotifications":  false,
@@ -188,3 +189,291 @@ func TestDeleteJob(t *testing.T) {
 	t.Log(w2.Body)
 	assert.Equal(t, w2.Code, http.StatusOK)
 }
+
+func TestDeleteJobNotifiesApplicants(t *testing.T) {
+	router := getTestRouter()
+	r := regexp.MustCompile(`"InsertedID":"(.+)"`)
+	address := primitive.NewObjectID().Hex() + "@example.com"
+	userID := createUserWithEmail(router, r, "Deleted Job Applicant", address)
+	jobID := createJob(router, r)
+	w, _ := createJobApplication(router, userID, jobID)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/jobs/"+jobID, bytes.NewReader([]byte(`{"reason": "position filled"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	sent := deliveredEmails(t, address)
+	if assert.Len(t, sent, 1) {
+		assert.Equal(t, "Job Deletion Notice", sent[0].Subject)
+		assert.Contains(t, sent[0].Body, "position filled")
+	}
+}
+
+func TestRetrieveAllJobsPaginated(t *testing.T) {
+	router := getTestRouter()
+	r := regexp.MustCompile(`"InsertedID":"(.+)"`)
+	for range 3 {
+		createJob(router, r)
+	}
+
+	w1 := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/jobs/?limit=2", nil)
+	router.ServeHTTP(w1, req)
+	assert.Equal(t, http.StatusOK, w1.Code)
+
+	var first struct {
+		Data       []map[string]any `json:"data"`
+		NextCursor string           `json:"nextCursor"`
+		HasMore    bool             `json:"hasMore"`
+	}
+	assert.NoError(t, json.Unmarshal(w1.Body.Bytes(), &first))
+	assert.Len(t, first.Data, 2)
+	assert.True(t, first.HasMore)
+	assert.NotEmpty(t, first.NextCursor)
+
+	w2 := httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/jobs/?limit=2&cursor="+first.NextCursor, nil)
+	router.ServeHTTP(w2, req)
+	assert.Equal(t, http.StatusOK, w2.Code)
+
+	var second struct {
+		Data []map[string]any `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w2.Body.Bytes(), &second))
+	assert.NotEmpty(t, second.Data)
+	assert.NotEqual(t, first.Data[0]["id"], second.Data[0]["id"])
+	assert.NotEqual(t, first.Data[1]["id"], second.Data[0]["id"])
+}
+
+func TestQueryJobsCursorOfAnotherSortOrder(t *testing.T) {
+	router := getTestRouter()
+	r := regexp.MustCompile(`"InsertedID":"(.+)"`)
+	for range 3 {
+		createJob(router, r)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/jobs/query?sort=dateAsc&limit=2", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var first struct {
+		NextCursor string `json:"nextCursor"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+	assert.NotEmpty(t, first.NextCursor)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/jobs/query?sort=dateDesc&limit=2&cursor="+first.NextCursor, nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// the last page has no cursor at all
+	var last map[string]any
+	url := "/jobs/query?sort=dateAsc&limit=100"
+	for range 100 {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", url, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		last = nil
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &last))
+		cursor, ok := last["nextCursor"].(string)
+		if !ok {
+			break
+		}
+		url = "/jobs/query?sort=dateAsc&limit=100&cursor=" + cursor
+	}
+	assert.Equal(t, false, last["hasMore"])
+	assert.NotContains(t, last, "nextCursor")
+}
+
+func TestRetrieveAllJobsInvalidPagination(t *testing.T) {
+	router := getTestRouter()
+
+	w1 := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/jobs/?limit=abc", nil)
+	router.ServeHTTP(w1, req)
+	assert.Equal(t, http.StatusBadRequest, w1.Code)
+
+	w2 := httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/jobs/?cursor=not-a-cursor", nil)
+	router.ServeHTTP(w2, req)
+	assert.Equal(t, http.StatusBadRequest, w2.Code)
+}
+
+func TestQueryJobsFullTextSearch(t *testing.T) {
+	router := getTestRouter()
+
+	w1 := httptest.NewRecorder()
+	body, _ := json.Marshal(rawJob("Quokka Wrangler"))
+	req, _ := http.NewRequest("POST", "/jobs/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w1, req)
+	assert.Equal(t, http.StatusCreated, w1.Code)
+
+	w2 := httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/jobs/query?q=quokkas", nil)
+	router.ServeHTTP(w2, req)
+	assert.Equal(t, http.StatusOK, w2.Code)
+
+	var page struct {
+		Data []struct {
+			Title      string              `json:"title"`
+			Score      float64             `json:"score"`
+			Highlights map[string][]string `json:"highlights"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w2.Body.Bytes(), &page))
+	if assert.NotEmpty(t, page.Data) {
+		assert.Equal(t, "Quokka Wrangler", page.Data[0].Title)
+		assert.Greater(t, page.Data[0].Score, 0.0)
+		assert.Equal(t, []string{"<mark>Quokka</mark> Wrangler"}, page.Data[0].Highlights["title"])
+	}
+}
+
+func TestQueryJobsBySalaryRange(t *testing.T) {
+	router := getTestRouter()
+	r := regexp.MustCompile(`"InsertedID":"(.+)"`)
+	companyID := primitive.NewObjectID().Hex()
+	// rawJob pays 2000.34 - 300000.21 THB
+	createJob(router, r, companyID)
+
+	query := func(params string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/jobs/query?companyID="+companyID+"&"+params, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// bands which overlap the job's band
+	for _, params := range []string{
+		"minSalary=250000",
+		"maxSalary=5000",
+		"minSalary=1000&maxSalary=2500",
+		"minSalary=100000&maxSalary=200000&currency=THB",
+	} {
+		w := query(params)
+		assert.Equal(t, http.StatusOK, w.Code, params)
+	}
+
+	// bands which do not
+	for _, params := range []string{
+		"minSalary=400000",
+		"maxSalary=1000",
+		"minSalary=400000&maxSalary=500000&currency=thb",
+	} {
+		w := query(params)
+		assert.Equal(t, http.StatusNotFound, w.Code, params)
+	}
+
+	w := query("minSalary=100000&currency=THB")
+	var page struct {
+		Data []struct {
+			ConvertedSalary struct {
+				Currency  string  `json:"currency"`
+				MinSalary float64 `json:"minSalary"`
+			} `json:"convertedSalary"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	if assert.Len(t, page.Data, 1) {
+		assert.Equal(t, "THB", page.Data[0].ConvertedSalary.Currency)
+		assert.Equal(t, 2000.34, page.Data[0].ConvertedSalary.MinSalary)
+	}
+}
+
+func TestQueryJobsInvalidSalaryRange(t *testing.T) {
+	router := getTestRouter()
+
+	for _, params := range []string{
+		"minSalary=abc",
+		"minSalary=-1",
+		"minSalary=2000&maxSalary=1000",
+		"currency=BAHT",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/jobs/query?"+params, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, params)
+	}
+}
+
+// Test that drafts are only seen by their company and take no applications until published,
+// and that jobs close once their positions are filled.
+func TestJobLifecycle(t *testing.T) {
+	router := getTestRouter()
+	company := insertCompany(t, "Lifecycle Corp", "hr@lifecycle.example.com")
+	seeker := insertJobSeeker(t, "lifecycle")
+
+	job := rawJob("Lifecycle job", company.ID.Hex())
+	job["status"] = "draft"
+	// the draft is published a week after the open date it was written with
+	job["postOpenDate"] = time.Now().Add(-7 * 24 * time.Hour)
+	w := resumeRequest(router, "POST", "/jobs/", company.ID, "company", job)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	jobID := regexp.MustCompile(`"InsertedID":"(.+)"`).FindStringSubmatch(w.Body.String())[1]
+
+	getJob := func() schema.Job {
+		w := resumeRequest(router, "GET", "/jobs/"+jobID, company.ID, "company", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var saved schema.Job
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &saved))
+		return saved
+	}
+	changeStatus := func(status string) *httptest.ResponseRecorder {
+		return resumeRequest(router, "PUT", "/jobs/"+jobID+"/status", company.ID, "company", map[string]string{"status": status})
+	}
+	apply := func() *httptest.ResponseRecorder {
+		return resumeRequest(router, "POST", "/apply/", seeker.ID, "jobSeeker", map[string]any{
+			"applicantID": seeker.ID,
+			"jobID":       jobID,
+			"status":      "PENDING",
+		})
+	}
+
+	// drafts are invisible to job seekers
+	assert.Equal(t, schema.JobDraft, getJob().Status)
+	w = resumeRequest(router, "GET", "/jobs/"+jobID, seeker.ID, "jobSeeker", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = resumeRequest(router, "GET", "/jobs/query?companyID="+company.ID.Hex(), seeker.ID, "jobSeeker", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = resumeRequest(router, "GET", "/jobs/query?status=draft&companyID="+company.ID.Hex(), seeker.ID, "jobSeeker", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = resumeRequest(router, "GET", "/jobs/query?companyID="+company.ID.Hex(), company.ID, "company", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusBadRequest, apply().Code)
+
+	w = changeStatus("published")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	published := getJob()
+	assert.Equal(t, schema.JobPublished, published.Status)
+	// it opens now, so that saved search digests list it
+	assert.WithinDuration(t, time.Now(), published.PostOpenDate, time.Minute)
+
+	w = apply()
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	appID := regexp.MustCompile(`"InsertedID":"(.+)"`).FindStringSubmatch(w.Body.String())[1]
+	t.Cleanup(func() { deleteJobApplication(appID, router) })
+
+	// the only position is filled
+	for _, status := range []string{"SCREENING", "INTERVIEW", "OFFER", "ACCEPTED"} {
+		w = resumeRequest(router, "PUT", "/apply/"+appID, company.ID, "company", map[string]string{"status": status})
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	closed := getJob()
+	assert.Equal(t, schema.JobClosed, closed.Status)
+	assert.Equal(t, schema.ClosedWhenFilled, closed.ClosedReason)
+	assert.Equal(t, http.StatusBadRequest, apply().Code)
+
+	// closed jobs can be reopened, or archived for good
+	assert.Equal(t, http.StatusOK, changeStatus("published").Code)
+	assert.Empty(t, getJob().ClosedReason)
+	assert.Equal(t, http.StatusBadRequest, changeStatus("archived").Code, "published jobs are closed first")
+	assert.Equal(t, http.StatusOK, changeStatus("closed").Code)
+	assert.Equal(t, http.StatusOK, changeStatus("archived").Code)
+	assert.Equal(t, http.StatusBadRequest, changeStatus("published").Code)
+	assert.NotNil(t, getJob().ArchivedAt)
+}
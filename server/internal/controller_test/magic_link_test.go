@@ -0,0 +1,77 @@
+package controller_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/email"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMagicLinkLogin(t *testing.T) {
+	router := getTestRouter()
+	r := regexp.MustCompile(`"InsertedID":"(.+)"`)
+	address := "hr@magic-link.example.com"
+	createUserWithEmail(router, r, "Magic HR", address)
+
+	w := requestMagicLink(router, address)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	// the link is sent in the background
+	var sent []email.Message
+	assert.Eventually(t, func() bool {
+		sent = email.GetTransport().(*email.MemoryTransport).SentTo(address)
+		return len(sent) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	if len(sent) != 1 {
+		return
+	}
+	link := regexp.MustCompile(`/auth/magic-link/verify\?token=\S+`).FindString(sent[0].Body)
+	assert.NotEmpty(t, link)
+
+	w = verifyMagicLink(router, link)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Contains(t, w.Header().Get("Location"), "/callback?step=login&token=")
+	assert.Contains(t, w.Header().Get("Set-Cookie"), "refresh_token=")
+
+	// every link works once
+	w = verifyMagicLink(router, link)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Contains(t, w.Header().Get("Location"), "/login?error=")
+
+	w = verifyMagicLink(router, "/auth/magic-link/verify?token="+url.QueryEscape("not.a.token"))
+	assert.Contains(t, w.Header().Get("Location"), "/login?error=")
+}
+
+func TestMagicLinkRateLimit(t *testing.T) {
+	router := getTestRouter()
+	// unknown emails are limited the same way, so the limit does not tell whether an account exists
+	address := "nobody@magic-link.example.com"
+	for i := 0; i < 3; i++ {
+		w := requestMagicLink(router, address)
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	}
+	w := requestMagicLink(router, address)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Empty(t, email.GetTransport().(*email.MemoryTransport).SentTo(address))
+}
+
+func requestMagicLink(router http.Handler, address string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/auth/magic-link", bytes.NewReader([]byte(`{"email":"`+address+`"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func verifyMagicLink(router http.Handler, link string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", link, nil)
+	router.ServeHTTP(w, req)
+	return w
+}
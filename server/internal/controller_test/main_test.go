@@ -16,6 +16,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/controller"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/email"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/outbox"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -28,11 +30,22 @@ var globalApplicantID string
 func TestMain(m *testing.M) {
 	db := database.GetDatabase()
 	os.Setenv("ENABLE_AUTH", "false")
+	// keep sent emails in memory, see deliveredEmails
+	os.Setenv("EMAIL_TRANSPORT", email.TransportMemory)
 
 	collections := []string{
 		"job_applications",
 		"jobs",
 		"users",
+		"saved_searches",
+		"email_outbox",
+		"sessions",
+		"magic_links",
+		"organizations",
+		"cohorts",
+		"endorsements",
+		"resumes",
+		"files",
 	}
 
 	createMockCollections(db, collections)
@@ -113,9 +126,17 @@ func getTestRouter() *gin.Engine {
 }
 
 func createUser(router *gin.Engine, r *regexp.Regexp, username string) string {
+	return createUserWithEmail(router, r, username, "")
+}
+
+func createUserWithEmail(router *gin.Engine, r *regexp.Regexp, username string, address string) string {
 	w := httptest.NewRecorder()
 
-	body, _ := json.Marshal(rawUser(username))
+	raw := rawUser(username)
+	if address != "" {
+		raw["email"] = address
+	}
+	body, _ := json.Marshal(raw)
 
 	req, _ := http.NewRequest("POST", "/users/", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
@@ -126,3 +147,14 @@ func createUser(router *gin.Engine, r *regexp.Regexp, username string) string {
 	userID := userIDMatches[1]
 	return userID
 }
+
+// deliveredEmails delivers everything waiting in the outbox
+// and returns the emails which were sent to address.
+func deliveredEmails(t *testing.T, address string) []email.Message {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := outbox.DeliverDue(ctx); err != nil {
+		t.Fatalf("delivering outbox: %v", err)
+	}
+	return email.GetTransport().(*email.MemoryTransport).SentTo(address)
+}
@@ -0,0 +1,119 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func organizationRequest(router *gin.Engine, method, path string, userID primitive.ObjectID, body any) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	raw, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, path, bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-Id", userID.Hex())
+	req.Header.Set("X-User-Role", "company")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func insertCompany(t *testing.T, name, address string) schema.User {
+	user := schema.User{
+		ID:        primitive.NewObjectID(),
+		Name:      name,
+		Email:     address,
+		Role:      schema.RoleCompany,
+		CreatedAt: time.Now(),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := database.GetDatabase().Collection("users").InsertOne(ctx, user)
+	assert.NoError(t, err)
+	return user
+}
+
+func TestOrganizationInvitation(t *testing.T) {
+	router := getTestRouter()
+	founder := insertCompany(t, "Invite Corp", "owner@invite.example.com")
+	recruiter := insertCompany(t, "Invited Recruiter", "recruiter@invite.example.com")
+	orgPath := "/organizations/" + founder.ID.Hex()
+
+	// a company account is the owner of its organization before inviting anyone
+	w := organizationRequest(router, "GET", "/organizations/mine", founder.ID, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), founder.ID.Hex())
+
+	w = organizationRequest(router, "POST", orgPath+"/invitations", founder.ID, map[string]any{
+		"email": "Recruiter@Invite.example.com",
+		"role":  "recruiter",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NotContains(t, w.Body.String(), "tokenHash")
+
+	sent := deliveredEmails(t, "recruiter@invite.example.com")
+	if !assert.Len(t, sent, 1) {
+		return
+	}
+	token := regexp.MustCompile(`token=([0-9a-f]{64})`).FindStringSubmatch(sent[0].Body)
+	if !assert.Len(t, token, 2) {
+		return
+	}
+
+	// the token is only for the invited email
+	w = organizationRequest(router, "POST", "/organizations/invitations/accept", founder.ID, map[string]any{"token": token[1]})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = organizationRequest(router, "POST", "/organizations/invitations/accept", recruiter.ID, map[string]any{"token": token[1]})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var org schema.Organization
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &org))
+	assert.True(t, org.HasRole(recruiter.ID, schema.OrgRecruiter))
+	assert.Empty(t, org.Invitations)
+
+	// every invitation works once
+	w = organizationRequest(router, "POST", "/organizations/invitations/accept", recruiter.ID, map[string]any{"token": token[1]})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// the recruiter now works for the organization
+	w = organizationRequest(router, "GET", "/organizations/mine", recruiter.ID, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), founder.ID.Hex())
+}
+
+func TestOrganizationRemoveMember(t *testing.T) {
+	router := getTestRouter()
+	founder := insertCompany(t, "Remove Corp", "owner@remove.example.com")
+	viewer := insertCompany(t, "Viewer", "viewer@remove.example.com")
+	orgPath := "/organizations/" + founder.ID.Hex()
+
+	org := schema.PersonalOrganization(founder)
+	org.Members = append(org.Members, schema.OrganizationMember{UserID: viewer.ID, Role: schema.OrgViewer, JoinedAt: time.Now()})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := database.GetDatabase().Collection("organizations").InsertOne(ctx, org)
+	assert.NoError(t, err)
+
+	// viewers cannot remove others
+	w := organizationRequest(router, "DELETE", orgPath+"/members/"+founder.ID.Hex(), viewer.ID, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// the last owner cannot leave
+	w = organizationRequest(router, "DELETE", orgPath+"/members/"+founder.ID.Hex(), founder.ID, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = organizationRequest(router, "DELETE", orgPath+"/members/"+viewer.ID.Hex(), founder.ID, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = organizationRequest(router, "DELETE", orgPath+"/members/"+viewer.ID.Hex(), founder.ID, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,67 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestOutboxRetryDeadMessage(t *testing.T) {
+	router := getTestRouter()
+	admin := primitive.NewObjectID()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	dead := schema.OutboxMessage{
+		ID:            primitive.NewObjectID(),
+		To:            "dead@example.com",
+		Subject:       "Job Deletion Notice",
+		Body:          "gone",
+		Status:        schema.OutboxDead,
+		Attempts:      8,
+		LastError:     "connection refused",
+		NextAttemptAt: time.Now(),
+		CreatedAt:     time.Now(),
+	}
+	_, err := repository.InsertOne(ctx, dead)
+	assert.NoError(t, err)
+
+	w := adminRequest(router, "GET", "/admin/outbox?status=DEAD", admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), dead.ID.Hex())
+
+	w = adminRequest(router, "GET", "/admin/outbox?status=LOST", admin)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	retryPath := "/admin/outbox/" + dead.ID.Hex() + "/retry"
+	w = adminRequest(router, "POST", retryPath, admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var retried schema.OutboxMessage
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &retried))
+	assert.Equal(t, schema.OutboxPending, retried.Status)
+	assert.Equal(t, 0, retried.Attempts)
+
+	// it is no longer dead, so it cannot be retried again
+	w = adminRequest(router, "POST", retryPath, admin)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = adminRequest(router, "POST", "/admin/outbox/"+primitive.NewObjectID().Hex()+"/retry", admin)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func adminRequest(router http.Handler, method, path string, adminID primitive.ObjectID) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	req.Header.Set("X-User-Id", adminID.Hex())
+	req.Header.Set("X-User-Role", "admin")
+	router.ServeHTTP(w, req)
+	return w
+}
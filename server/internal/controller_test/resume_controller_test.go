@@ -0,0 +1,155 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func resumeRequest(router *gin.Engine, method, path string, userID primitive.ObjectID, role string, body any) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	raw, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, path, bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-Id", userID.Hex())
+	req.Header.Set("X-User-Role", role)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func insertJobSeeker(t *testing.T, name string) schema.User {
+	user := schema.User{
+		ID:        primitive.NewObjectID(),
+		Name:      name,
+		Email:     name + "@resume.example.com",
+		Role:      schema.RoleJobSeeker,
+		UserInfo:  &schema.JobSeekerInfo{FullName: name, Location: "Bangkok", Phone: "0812345678"},
+		CreatedAt: time.Now(),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := database.GetDatabase().Collection("users").InsertOne(ctx, user)
+	assert.NoError(t, err)
+	return user
+}
+
+func createResume(t *testing.T, router *gin.Engine, owner primitive.ObjectID, title string) schema.Resume {
+	w := resumeRequest(router, "POST", "/resumes/", owner, "jobSeeker", map[string]any{
+		"title":      title,
+		"experience": []map[string]any{{"company": "Acme", "title": "Intern", "startDate": "2024-06"}},
+		"skills":     []string{"Go"},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var cv schema.Resume
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &cv))
+	return cv
+}
+
+func TestResumeVersions(t *testing.T) {
+	router := getTestRouter()
+	seeker := insertJobSeeker(t, "versioned")
+
+	first := createResume(t, router, seeker.ID, "First")
+	second := createResume(t, router, seeker.ID, "Second")
+	assert.Equal(t, 1, first.Version)
+	assert.Equal(t, 2, second.Version)
+	assert.NotEqual(t, first.FileID, second.FileID)
+
+	w := resumeRequest(router, "GET", "/resumes/", seeker.ID, "jobSeeker", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resumes []schema.Resume
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resumes))
+	assert.Len(t, resumes, 2)
+	assert.Equal(t, "Second", resumes[0].Title)
+
+	w = resumeRequest(router, "GET", "/resumes/"+first.ID.Hex()+"/pdf", seeker.ID, "jobSeeker", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.True(t, bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF-")))
+
+	// the rendered PDF is one of the resume files of the job seeker
+	w = resumeRequest(router, "GET", "/files/user/"+seeker.ID.Hex(), seeker.ID, "jobSeeker", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "resume-v2.pdf")
+
+	// but it cannot be deleted, as applications keep pointing to it
+	w = resumeRequest(router, "DELETE", "/files/"+first.FileID.Hex(), seeker.ID, "jobSeeker", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = resumeRequest(router, "GET", "/resumes/"+first.ID.Hex()+"/pdf", seeker.ID, "jobSeeker", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestResumeRejectsInvalidSections(t *testing.T) {
+	router := getTestRouter()
+	seeker := insertJobSeeker(t, "invalid")
+
+	w := resumeRequest(router, "POST", "/resumes/", seeker.ID, "jobSeeker", map[string]any{
+		"title":      "Backwards",
+		"experience": []map[string]any{{"company": "Acme", "title": "Intern", "startDate": "2024-06", "endDate": "2023-01"}},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestApplyWithResume(t *testing.T) {
+	router := getTestRouter()
+	seeker := insertJobSeeker(t, "applicant")
+	other := insertJobSeeker(t, "other")
+	company := insertCompany(t, "Resume Corp", "hr@resume.example.com")
+	job := schema.Job{
+		ID:                  primitive.NewObjectID(),
+		CompanyID:           company.ID,
+		Title:               "Go developer",
+		Status:              schema.JobPublished,
+		PostOpenDate:        time.Now().Add(-time.Hour),
+		ApplicationDeadline: time.Now().Add(24 * time.Hour),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := database.GetDatabase().Collection("jobs").InsertOne(ctx, job)
+	assert.NoError(t, err)
+
+	cv := createResume(t, router, seeker.ID, "For Resume Corp")
+	othersCV := createResume(t, router, other.ID, "Not mine")
+
+	apply := func(resumeID primitive.ObjectID) *httptest.ResponseRecorder {
+		return resumeRequest(router, "POST", "/apply/", seeker.ID, "jobSeeker", map[string]any{
+			"applicantID": seeker.ID,
+			"jobID":       job.ID,
+			"status":      "PENDING",
+			"resumeID":    resumeID,
+		})
+	}
+
+	// applications can only be sent with a resume of the applicant
+	w := apply(othersCV.ID)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = apply(cv.ID)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var inserted struct{ InsertedID primitive.ObjectID }
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &inserted))
+
+	// a later version does not change the resume the company sees
+	createResume(t, router, seeker.ID, "Newer")
+
+	w = resumeRequest(router, "GET", "/apply/"+inserted.InsertedID.Hex()+"/resume", company.ID, "company", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var seen schema.Resume
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &seen))
+	assert.Equal(t, cv.ID, seen.ID)
+	assert.Equal(t, "Go", seen.Skills[0])
+
+	w = resumeRequest(router, "GET", "/apply/"+inserted.InsertedID.Hex()+"/resume?format=pdf", company.ID, "company", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF-")))
+}
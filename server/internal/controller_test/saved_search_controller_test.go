@@ -0,0 +1,145 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func savedSearchRequest(router *gin.Engine, method, path string, userID primitive.ObjectID, body any) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	var reader *bytes.Reader
+	if body != nil {
+		raw, _ := json.Marshal(body)
+		reader = bytes.NewReader(raw)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, _ := http.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-Id", userID.Hex())
+	req.Header.Set("X-User-Role", "jobSeeker")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSavedSearchCRUD(t *testing.T) {
+	router := getTestRouter()
+	owner := primitive.NewObjectID()
+
+	w := savedSearchRequest(router, "POST", "/saved-searches/", owner, map[string]any{
+		"name":      "Go jobs",
+		"params":    map[string]string{"q": "golang", "minSalary": "30000", "currency": "THB"},
+		"frequency": "daily",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created schema.SavedSearch
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, owner, created.UserID)
+	assert.True(t, created.Subscribed)
+	assert.Empty(t, created.UnsubscribeToken, "the token must only be sent by email")
+	path := "/saved-searches/" + created.ID.Hex()
+
+	w = savedSearchRequest(router, "GET", "/saved-searches/", owner, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), created.ID.Hex())
+
+	w = savedSearchRequest(router, "PUT", path, owner, map[string]any{"frequency": "weekly"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var updated schema.SavedSearch
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, schema.DigestWeekly, updated.Frequency)
+	assert.Equal(t, "golang", updated.Params["q"])
+
+	// other users cannot see that it exists
+	w = savedSearchRequest(router, "GET", path, primitive.NewObjectID(), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = savedSearchRequest(router, "DELETE", path, owner, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = savedSearchRequest(router, "GET", path, owner, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSavedSearchRejectsInvalidParams(t *testing.T) {
+	router := getTestRouter()
+	owner := primitive.NewObjectID()
+
+	for _, params := range []map[string]string{
+		{"unknown": "value"},
+		{"minSalary": "lots"},
+		{"limit": "10"},
+	} {
+		w := savedSearchRequest(router, "POST", "/saved-searches/", owner, map[string]any{
+			"name":      "Invalid",
+			"params":    params,
+			"frequency": "daily",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code, params)
+	}
+
+	w := savedSearchRequest(router, "POST", "/saved-searches/", owner, map[string]any{
+		"name":      "Invalid",
+		"frequency": "hourly",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSavedSearchUnsubscribe(t *testing.T) {
+	router := getTestRouter()
+	owner := primitive.NewObjectID()
+
+	w := savedSearchRequest(router, "POST", "/saved-searches/", owner, map[string]any{
+		"name":      "Remote jobs",
+		"params":    map[string]string{"workArrangement": "remote"},
+		"frequency": "weekly",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created schema.SavedSearch
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var stored schema.SavedSearch
+	coll := database.GetDatabase().Collection("saved_searches")
+	assert.NoError(t, coll.FindOne(ctx, bson.M{"_id": created.ID}).Decode(&stored))
+
+	// opening the link only leads to the page which asks to confirm,
+	// as link scanners of mail servers open it too
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/saved-searches/unsubscribe?token="+stored.UnsubscribeToken, nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Contains(t, w.Header().Get("Location"), "/unsubscribe?token="+stored.UnsubscribeToken)
+	assert.NoError(t, coll.FindOne(ctx, bson.M{"_id": created.ID}).Decode(&stored))
+	assert.True(t, stored.Subscribed)
+
+	// the one-click unsubscribe of mail clients
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/saved-searches/unsubscribe?token="+stored.UnsubscribeToken,
+		strings.NewReader("List-Unsubscribe=One-Click"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.NoError(t, coll.FindOne(ctx, bson.M{"_id": created.ID}).Decode(&stored))
+	assert.False(t, stored.Subscribed)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/saved-searches/unsubscribe?token=not-a-token", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,162 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/auth"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/dto"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestListAndDeleteSessions(t *testing.T) {
+	router := getTestRouter()
+	owner := primitive.NewObjectID()
+	stranger := primitive.NewObjectID()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	laptop := newSession(owner, "Firefox on Linux")
+	phone := newSession(owner, "Safari on iPhone")
+	other := newSession(stranger, "Chrome on Windows")
+	for _, s := range []schema.Session{laptop, phone, other} {
+		_, err := repository.InsertOne(ctx, s)
+		assert.NoError(t, err)
+	}
+
+	w := sessionRequest(router, "GET", "/auth/sessions", owner)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var sessions []dto.Session
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &sessions))
+	assert.Len(t, sessions, 2)
+	assert.NotContains(t, w.Body.String(), other.ID.Hex())
+	// token ids never leave the server
+	assert.NotContains(t, w.Body.String(), laptop.RefreshJTI)
+
+	// someone else's session looks like it does not exist
+	w = sessionRequest(router, "DELETE", "/auth/sessions/"+other.ID.Hex(), owner)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// an access token the phone got before its latest rotation
+	oldAccess, err := auth.GetKeyManager().Sign(jwt.MapClaims{
+		"jti":    primitive.NewObjectID().Hex(),
+		"sid":    phone.ID.Hex(),
+		"userID": owner.Hex(),
+		"role":   "jobSeeker",
+		"type":   auth.TokenAccess,
+		"exp":    time.Now().Add(10 * time.Minute).Unix(),
+	})
+	assert.NoError(t, err)
+
+	w = sessionRequest(router, "DELETE", "/auth/sessions/"+phone.ID.Hex(), owner)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// every token of the session stops working, not only its latest one
+	t.Setenv("ENABLE_AUTH", "true")
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/auth/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+oldAccess)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	t.Setenv("ENABLE_AUTH", "false")
+
+	w = sessionRequest(router, "DELETE", "/auth/sessions/"+phone.ID.Hex(), owner)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = sessionRequest(router, "GET", "/auth/sessions", owner)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &sessions))
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, laptop.ID, sessions[0].ID)
+}
+
+func TestRefreshRacingAnotherRefresh(t *testing.T) {
+	router := getTestRouter()
+	user := insertJobSeeker(t, "racer")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session := newSession(user.ID, "Firefox on Linux")
+	_, err := repository.InsertOne(ctx, session)
+	assert.NoError(t, err)
+	refreshToken, err := auth.GetKeyManager().Sign(jwt.MapClaims{
+		"jti":    session.RefreshJTI,
+		"sid":    session.ID.Hex(),
+		"userID": user.ID.Hex(),
+		"type":   auth.TokenRefresh,
+		"exp":    session.ExpiresAt.Unix(),
+	})
+	assert.NoError(t, err)
+
+	refresh := func() (int, map[string]string) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/auth/refresh", nil)
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+		router.ServeHTTP(w, req)
+		var body map[string]string
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	code, first := refresh()
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotEmpty(t, first["refresh_token"])
+
+	// a page which loaded several things at once refreshes with the same token again
+	code, second := refresh()
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotEmpty(t, second["access_token"])
+	assert.NotContains(t, second, "refresh_token")
+	rotated, err := repository.FindOne[schema.Session](ctx, session.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, rotated.RevokedAt)
+
+	// once the grace period is over, the old token is a reuse
+	_, err = repository.UpdateOne[schema.Session](ctx,
+		bson.M{"_id": session.ID},
+		bson.M{"$set": bson.M{"rotatedAt": time.Now().Add(-time.Hour)}},
+	)
+	assert.NoError(t, err)
+	code, _ = refresh()
+	assert.Equal(t, http.StatusUnauthorized, code)
+	revoked, err := repository.FindOne[schema.Session](ctx, session.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, revoked.RevokedAt)
+	// along with the access tokens of the rotations and of the grace period
+	tokensRevoked, err := auth.GetRevocationStore().IsRevoked(ctx, auth.SessionTokenID(session.ID.Hex()))
+	assert.NoError(t, err)
+	assert.True(t, tokensRevoked)
+}
+
+func newSession(userID primitive.ObjectID, userAgent string) schema.Session {
+	now := time.Now()
+	return schema.Session{
+		ID:              primitive.NewObjectID(),
+		UserID:          userID,
+		UserAgent:       userAgent,
+		IP:              "203.0.113.7",
+		CreatedAt:       now,
+		LastUsedAt:      now,
+		ExpiresAt:       now.Add(24 * time.Hour),
+		RefreshJTI:      primitive.NewObjectID().Hex(),
+		AccessJTI:       primitive.NewObjectID().Hex(),
+		AccessExpiresAt: now.Add(15 * time.Minute),
+	}
+}
+
+func sessionRequest(router http.Handler, method, path string, userID primitive.ObjectID) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	req.Header.Set("X-User-Id", userID.Hex())
+	router.ServeHTTP(w, req)
+	return w
+}
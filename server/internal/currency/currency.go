@@ -0,0 +1,103 @@
+// Package currency converts amounts of money using a static exchange-rate table.
+package currency
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/config"
+)
+
+// defaultRatesFile is used when EXCHANGE_RATES_FILE is not set.
+const defaultRatesFile = "./config/exchange_rates.json"
+
+// Rates is an exchange-rate table. Every rate is the value of one unit
+// of that currency in the base currency.
+type Rates struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+var (
+	instance *Rates
+	once     sync.Once
+)
+
+// GetRates returns the table loaded from EXCHANGE_RATES_FILE.
+// If the file cannot be read, the table is empty and only converts
+// a currency to itself.
+func GetRates() *Rates {
+	once.Do(func() {
+		path := config.LoadEnv("EXCHANGE_RATES_FILE")
+		if path == "" || path == "false" {
+			path = defaultRatesFile
+		}
+		rates, err := Load(path)
+		if err != nil {
+			slog.Warn("Could not load exchange rates, salaries will not be converted: " + err.Error())
+			rates = &Rates{Rates: map[string]float64{}}
+		}
+		instance = rates
+	})
+	return instance
+}
+
+// Load reads an exchange-rate table from a JSON file.
+func Load(path string) (*Rates, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Rates
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	rates := make(map[string]float64, len(r.Rates))
+	for code, rate := range r.Rates {
+		if rate <= 0 {
+			return nil, fmt.Errorf("rate of %s must be positive", code)
+		}
+		rates[strings.ToUpper(code)] = rate
+	}
+	r.Base = strings.ToUpper(r.Base)
+	r.Rates = rates
+	return &r, nil
+}
+
+// Supports reports whether amounts in code can be converted.
+func (r *Rates) Supports(code string) bool {
+	_, ok := r.Rates[strings.ToUpper(code)]
+	return ok
+}
+
+// Currencies returns every currency code in the table.
+func (r *Rates) Currencies() []string {
+	codes := make([]string, 0, len(r.Rates))
+	for code := range r.Rates {
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+// Convert converts amount from one currency to another, rounded to 2 decimals.
+func (r *Rates) Convert(amount float64, from string, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return amount, nil
+	}
+	fromRate, ok := r.Rates[from]
+	if !ok {
+		return 0, fmt.Errorf("unknown currency: %s", from)
+	}
+	toRate, ok := r.Rates[to]
+	if !ok {
+		return 0, fmt.Errorf("unknown currency: %s", to)
+	}
+	return math.Round(amount*fromRate/toRate*100) / 100, nil
+}
@@ -0,0 +1,63 @@
+package currency
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeRates(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadAndConvert(t *testing.T) {
+	rates, err := Load(writeRates(t, `{"base": "thb", "rates": {"THB": 1, "usd": 36.5}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "THB", rates.Base)
+	assert.True(t, rates.Supports("usd"))
+
+	thb, err := rates.Convert(1000, "USD", "THB")
+	assert.NoError(t, err)
+	assert.Equal(t, 36500.0, thb)
+
+	usd, err := rates.Convert(36500, "thb", "usd")
+	assert.NoError(t, err)
+	assert.Equal(t, 1000.0, usd)
+}
+
+func TestConvertSameCurrencyWithoutRate(t *testing.T) {
+	rates := &Rates{Rates: map[string]float64{}}
+	amount, err := rates.Convert(123.456, "XYZ", "xyz")
+	assert.NoError(t, err)
+	assert.Equal(t, 123.456, amount)
+}
+
+func TestConvertUnknownCurrency(t *testing.T) {
+	rates, err := Load(writeRates(t, `{"base": "THB", "rates": {"THB": 1}}`))
+	assert.NoError(t, err)
+	_, err = rates.Convert(1, "USD", "THB")
+	assert.Error(t, err)
+}
+
+func TestLoadRejectsInvalidRates(t *testing.T) {
+	_, err := Load(writeRates(t, `{"base": "THB", "rates": {"USD": 0}}`))
+	assert.Error(t, err)
+
+	_, err = Load(writeRates(t, `not json`))
+	assert.Error(t, err)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestDefaultRatesFileIsValid(t *testing.T) {
+	rates, err := Load(filepath.Join("..", "..", "config", "exchange_rates.json"))
+	assert.NoError(t, err)
+	assert.True(t, rates.Supports(rates.Base))
+}
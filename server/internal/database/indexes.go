@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JobTextIndexName is the name of the text index used by job search.
+const JobTextIndexName = "job_text_search"
+
+// deletedAtIndex lets the purger find soft-deleted documents, which are few, so it is sparse.
+var deletedAtIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: "deletedAt", Value: 1}},
+	Options: options.Index().SetSparse(true),
+}
+
+// indexes lists the indexes every collection needs, keyed by collection name.
+// CreateMany is a no-op for indexes which already exist with the same spec.
+var indexes = map[string][]mongo.IndexModel{
+	"jobs": {
+		{
+			Keys: bson.D{
+				{Key: "title", Value: "text"},
+				{Key: "jobSummary", Value: "text"},
+				{Key: "requiredSkills", Value: "text"},
+				{Key: "jobDescription", Value: "text"},
+			},
+			Options: options.Index().
+				SetName(JobTextIndexName).
+				// a match in the title counts more than one buried in the description
+				SetWeights(bson.D{
+					{Key: "title", Value: 10},
+					{Key: "jobSummary", Value: 5},
+					{Key: "requiredSkills", Value: 5},
+					{Key: "jobDescription", Value: 1},
+				}),
+		},
+		// the lifecycle scheduler looks for jobs in a status whose date has come
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "postOpenDate", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "applicationDeadline", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "closedAt", Value: 1}}},
+		deletedAtIndex,
+	},
+	"job_applications": {deletedAtIndex},
+	"notes":            {deletedAtIndex},
+	"saved_searches": {
+		{Keys: bson.D{{Key: "userID", Value: 1}}},
+		{Keys: bson.D{{Key: "subscribed", Value: 1}, {Key: "nextRunAt", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "unsubscribeToken", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	},
+	"revoked_tokens": {
+		// documents are deleted once the token they revoke has expired
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	},
+	"users": {
+		// an OAuth account can only be linked to one user
+		{
+			Keys: bson.D{
+				{Key: "identities.provider", Value: 1},
+				{Key: "identities.subject", Value: 1},
+			},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"identities.subject": bson.M{"$exists": true}}),
+		},
+		{Keys: bson.D{{Key: "email", Value: 1}}},
+		deletedAtIndex,
+	},
+	"organizations": {
+		{Keys: bson.D{{Key: "members.userID", Value: 1}}},
+		{Keys: bson.D{{Key: "invitations.tokenHash", Value: 1}}},
+	},
+	"cohorts": {
+		{Keys: bson.D{{Key: "facultyID", Value: 1}}},
+		{Keys: bson.D{{Key: "students.userID", Value: 1}}},
+	},
+	"endorsements": {
+		{Keys: bson.D{{Key: "studentID", Value: 1}}},
+		// a faculty member endorses a student once per cohort
+		{
+			Keys:    bson.D{{Key: "cohortID", Value: 1}, {Key: "studentID", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	},
+	"resumes": {
+		// versions are numbered per user
+		{
+			Keys:    bson.D{{Key: "userID", Value: 1}, {Key: "version", Value: -1}},
+			Options: options.Index().SetUnique(true),
+		},
+	},
+	"magic_links": {
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "createdAt", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	},
+	"sessions": {
+		{Keys: bson.D{{Key: "userID", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	},
+	"audit_events": {
+		// the audit log is read newest first, by actor, by target or by time
+		{Keys: bson.D{{Key: "actorID", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "targetID", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "createdAt", Value: -1}}},
+	},
+	"email_outbox": {
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+	},
+}
+
+// ensureIndexes creates all indexes listed in indexes.
+func ensureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	db := GetDatabase()
+	for collection, models := range indexes {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			return fmt.Errorf("creating indexes on %s: %w", collection, err)
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ package database
 import (
 	"context"
 	"log"
+	"log/slog"
 	"os"
 	"time"
 
@@ -22,6 +23,10 @@ func init() {
 	if err := connectToMongoDB(); err != nil {
 		log.Fatal("Could not connect to MongoDB")
 	}
+	// the server still works without indexes, only slower
+	if err := ensureIndexes(); err != nil {
+		slog.Warn("Could not create indexes: " + err.Error())
+	}
 }
 
 // GetConnection returns the instance of mongo client for uses in other packages.
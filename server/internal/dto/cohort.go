@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Cohort is the request body for updating a cohort.
+type Cohort struct {
+	Name *string `bson:"name,omitempty" json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+}
+
+// CohortStudent is the request body for adding a job seeker to a cohort.
+type CohortStudent struct {
+	Email string `json:"email" binding:"required,email,max=255"`
+}
+
+// CohortConsent is the request body for a student giving or withdrawing consent.
+type CohortConsent struct {
+	Consent *bool `json:"consent" binding:"required"`
+}
+
+// Endorsement is the request body for endorsing a student.
+type Endorsement struct {
+	Comment string `json:"comment" binding:"required,min=1,max=1000"`
+}
+
+// StudentCohort is a cohort as its students see it, without the other students.
+type StudentCohort struct {
+	ID          primitive.ObjectID `json:"id"`
+	Name        string             `json:"name"`
+	FacultyID   primitive.ObjectID `json:"facultyID"`
+	FacultyName string             `json:"facultyName"`
+	AddedAt     time.Time          `json:"addedAt"`
+	ConsentedAt *time.Time         `json:"consentedAt,omitempty"`
+}
+
+// EndorsementWithFaculty is an endorsement together with the name of the faculty member who wrote it.
+type EndorsementWithFaculty struct {
+	schema.Endorsement `bson:",inline"`
+	FacultyName        string `json:"facultyName"`
+}
@@ -3,29 +3,77 @@ package dto
 import (
 	"time"
 
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Job struct {
-	ID                  *primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Title               *string             `bson:"title,omitempty" json:"title,omitempty"`
-	Location            *string             `bson:"location,omitempty" json:"location,omitempty"`
-	WorkType            *string             `bson:"workType,omitempty" json:"workType,omitempty"`
-	WorkArrangement     *string             `bson:"workArrangement,omitempty" json:"workArrangement,omitempty"`
-	Currency            *string             `bson:"currency,omitempty" json:"currency,omitempty"`
-	MinSalary           *float64            `bson:"minSalary,omitempty" json:"minSalary,omitempty"`
-	MaxSalary           *float64            `bson:"maxSalary,omitempty" json:"maxSalary,omitempty"`
-	JobDescription      *string             `bson:"jobDescription,omitempty" json:"jobDescription,omitempty"`
-	JobSummary          *string             `bson:"jobSummary,omitempty" json:"jobSummary,omitempty"`
-	RequiredSkills      *string             `bson:"requiredSkills,omitempty" json:"requiredSkills,omitempty"`
-	ExperienceLevel     *string             `bson:"experienceLevel,omitempty" json:"experienceLevel,omitempty"`
-	Education           *string             `bson:"education,omitempty" json:"education,omitempty"`
-	NiceToHave          *string             `bson:"niceToHave,omitempty" json:"niceToHave,omitempty"`
-	Questions           *string             `bson:"questions,omitempty" json:"questions,omitempty"`
-	PostOpenDate        *time.Time          `bson:"postOpenDate,omitempty" json:"postOpenDate,omitempty"`
-	ApplicationDeadline *time.Time          `bson:"applicationDeadline,omitempty" json:"applicationDeadline,omitempty"`
-	NumberOfPositions   *int                `bson:"numberOfPositions,omitempty" json:"numberOfPositions,omitempty"`
-	Visibility          *string             `bson:"visibility,omitempty" json:"visibility,omitempty"`
-	EmailNotifications  *bool               `bson:"emailNotifications,omitempty" json:"emailNotifications,omitempty"`
-	AutoReject          *bool               `bson:"autoReject,omitempty" json:"autoReject,omitempty"`
+	ID                  *primitive.ObjectID        `bson:"_id,omitempty" json:"id,omitempty"`
+	Title               *string                    `bson:"title,omitempty" json:"title,omitempty"`
+	Location            *string                    `bson:"location,omitempty" json:"location,omitempty"`
+	WorkType            *string                    `bson:"workType,omitempty" json:"workType,omitempty"`
+	WorkArrangement     *string                    `bson:"workArrangement,omitempty" json:"workArrangement,omitempty"`
+	Currency            *string                    `bson:"currency,omitempty" json:"currency,omitempty"`
+	MinSalary           *float64                   `bson:"minSalary,omitempty" json:"minSalary,omitempty"`
+	MaxSalary           *float64                   `bson:"maxSalary,omitempty" json:"maxSalary,omitempty"`
+	JobDescription      *string                    `bson:"jobDescription,omitempty" json:"jobDescription,omitempty"`
+	JobSummary          *string                    `bson:"jobSummary,omitempty" json:"jobSummary,omitempty"`
+	RequiredSkills      *string                    `bson:"requiredSkills,omitempty" json:"requiredSkills,omitempty"`
+	ExperienceLevel     *string                    `bson:"experienceLevel,omitempty" json:"experienceLevel,omitempty"`
+	Education           *string                    `bson:"education,omitempty" json:"education,omitempty"`
+	NiceToHave          *string                    `bson:"niceToHave,omitempty" json:"niceToHave,omitempty"`
+	Questions           *schema.ScreeningQuestions `bson:"questions,omitempty" json:"questions,omitempty"`
+	PostOpenDate        *time.Time                 `bson:"postOpenDate,omitempty" json:"postOpenDate,omitempty"`
+	ApplicationDeadline *time.Time                 `bson:"applicationDeadline,omitempty" json:"applicationDeadline,omitempty"`
+	NumberOfPositions   *int                       `bson:"numberOfPositions,omitempty" json:"numberOfPositions,omitempty"`
+	Visibility          *string                    `bson:"visibility,omitempty" json:"visibility,omitempty"`
+	EmailNotifications  *bool                      `bson:"emailNotifications,omitempty" json:"emailNotifications,omitempty"`
+	AutoReject          *bool                      `bson:"autoReject,omitempty" json:"autoReject,omitempty"`
+	KnockoutRules       *schema.KnockoutRules      `bson:"knockoutRules,omitempty" json:"knockoutRules,omitempty"`
+	RejectionEmail      *schema.RejectionEmail     `bson:"rejectionEmail,omitempty" json:"rejectionEmail,omitempty"`
+}
+
+// ValidatePartial validates the questions and knockout rules of an update, and gives new questions an ID.
+// The update shares its questions with fields, so they are saved with their IDs.
+// Rules are only checked against the questions when both are updated together;
+// rules on a question the job no longer has are skipped when applications are evaluated.
+func (j Job) ValidatePartial(fields map[string]any) error {
+	questions, hasQuestions := fields["questions"].(schema.ScreeningQuestions)
+	if hasQuestions {
+		if err := questions.Validate(); err != nil {
+			return err
+		}
+	}
+	if rules, ok := fields["knockoutRules"].(schema.KnockoutRules); ok {
+		if err := rules.Validate(); err != nil {
+			return err
+		}
+		if hasQuestions {
+			return rules.ValidateAgainst(questions)
+		}
+	}
+	return nil
+}
+
+// JobStatusChange is the request body for moving a job to another status.
+type JobStatusChange struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// JobQueryResult is one job returned by /jobs/query.
+// Score and Highlights are only set for full-text searches: Highlights maps a
+// searched field to HTML snippets with the matched words in <mark>.
+// ConvertedSalary is only set when the query asked for a currency.
+type JobQueryResult struct {
+	schema.Job      `bson:",inline"`
+	Score           float64             `bson:"score,omitempty" json:"score,omitempty"`
+	Highlights      map[string][]string `bson:"-" json:"highlights,omitempty"`
+	ConvertedSalary *SalaryBand         `bson:"-" json:"convertedSalary,omitempty"`
+}
+
+// SalaryBand is a salary range in one currency.
+type SalaryBand struct {
+	Currency  string  `json:"currency"`
+	MinSalary float64 `json:"minSalary"`
+	MaxSalary float64 `json:"maxSalary"`
 }
@@ -8,3 +8,10 @@ type JobApplication struct {
 	Status    *string    `bson:"status,omitempty" json:"status,omitempty"`
 	CreatedAt *time.Time `bson:"createdAt,omitempty" json:"createdAt,omitempty"`
 }
+
+// ApplicationStatusChange is the request body for moving an application
+// to another status.
+type ApplicationStatusChange struct {
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason" binding:"omitempty,max=1000"`
+}
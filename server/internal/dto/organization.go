@@ -0,0 +1,12 @@
+package dto
+
+// OrganizationInvitation is the request body for inviting someone to an organization.
+type OrganizationInvitation struct {
+	Email string `json:"email" binding:"required,email,max=255"`
+	Role  string `json:"role" binding:"required,oneof=owner recruiter viewer"`
+}
+
+// AcceptOrganizationInvitation is the request body for joining an organization.
+type AcceptOrganizationInvitation struct {
+	Token string `json:"token" binding:"required,len=64,hexadecimal"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+// SavedSearch is the request body for updating a saved search.
+type SavedSearch struct {
+	Name       *string           `bson:"name,omitempty" json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	Params     map[string]string `bson:"params,omitempty" json:"params,omitempty" binding:"omitempty,max=20"`
+	Frequency  *string           `bson:"frequency,omitempty" json:"frequency,omitempty" binding:"omitempty,oneof=daily weekly"`
+	Subscribed *bool             `bson:"subscribed,omitempty" json:"subscribed,omitempty"`
+}
@@ -0,0 +1,10 @@
+package dto
+
+import "github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+
+// Session is a session as listed to its user.
+type Session struct {
+	schema.Session
+	// Current is true for the session of the request.
+	Current bool `json:"current"`
+}
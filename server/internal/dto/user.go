@@ -1,14 +1,22 @@
 package dto
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+)
 
 type User struct {
-	Email     *string    `bson:"email,omitempty" json:"email,omitempty"`
-	Name      *string    `bson:"name,omitempty" json:"name,omitempty"`
-	AvatarURL *string    `bson:"avatarURL,omitempty" json:"avatarURL,omitempty"`
-	Role      *string    `bson:"role,omitempty" json:"role,omitempty"`
-	Verified  *bool      `bson:"verified,omitempty" json:"verified,omitempty"`
-	UserInfo  *any       `bson:"userInfo,omitempty" json:"userInfo,omitempty"`
-	Banned    *bool      `bson:"banned,omitempty" json:"banned,omitempty"`
-	UpdatedAt *time.Time `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
+	Email     *string `bson:"email,omitempty" json:"email,omitempty"`
+	Name      *string `bson:"name,omitempty" json:"name,omitempty"`
+	AvatarURL *string `bson:"avatarURL,omitempty" json:"avatarURL,omitempty"`
+	Role      *string `bson:"role,omitempty" json:"role,omitempty"`
+	Verified  *bool   `bson:"verified,omitempty" json:"verified,omitempty"`
+	Locale    *string `bson:"locale,omitempty" json:"locale,omitempty" binding:"omitempty,oneof=en th"`
+	// UserInfo is the profile as sent, which UserController decodes into Profile by the role of the user.
+	UserInfo  *json.RawMessage `bson:"-" json:"userInfo,omitempty"`
+	Profile   *schema.UserInfo `bson:"userInfo,omitempty" json:"-"`
+	Banned    *bool            `bson:"banned,omitempty" json:"banned,omitempty"`
+	UpdatedAt *time.Time       `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
 }
@@ -3,50 +3,42 @@ package email
 import (
 	"errors"
 	"fmt"
-	"html"
 	"log/slog"
-	"net/smtp"
 	"strings"
 
 	"github.com/lnwdevelopers007/job-applier-3000/server/config"
 )
 
-// Send sends email to an address with a specified subject and body.
-func Send(to, subject, body string) error {
-
-	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
-		return errors.New("headers must not contain newlines")
+// ValidateHeaders checks that the values of headers, like the recipient and subject,
+// cannot inject extra headers.
+func ValidateHeaders(values ...string) error {
+	for _, value := range values {
+		if strings.ContainsAny(value, "\r\n") {
+			return errors.New("headers must not contain newlines")
+		}
 	}
+	return nil
+}
 
-	from := config.LoadEnv("EMAIL")
-	pass := config.LoadEnv("EMAIL_PASSWORD")
-
-	// Construct the message safely
-	// Note: Callers should sanitize individual untrusted fields before constructing the body
-	msg := []byte(fmt.Sprintf(
-		"To: %s\r\n"+
-			"Subject: %s\r\n"+
-			"MIME-Version: 1.0\r\n"+
-			"Content-Type: text/plain; charset=\"UTF-8\"\r\n"+
-			"\r\n"+
-			"%s\r\n",
-		to, subject, html.EscapeString(body),
-	))
-
-	smtpHost := config.LoadEnv("EMAIL_PROVIDER")
-	smtpPort := config.LoadEnv("EMAIL_PROVIDER_PORT")
+// Send sends msg through the transport selected by EMAIL_TRANSPORT.
+// msg.From defaults to the EMAIL account.
+func Send(msg Message) error {
 
-	auth := smtp.PlainAuth("", from, pass, smtpHost)
+	if err := ValidateHeaders(msg.To, msg.Subject, msg.UnsubscribeURL); err != nil {
+		return err
+	}
 
-	err := smtp.SendMail(smtpHost+":"+smtpPort, auth, from, []string{to}, msg)
-	if err != nil {
+	if msg.From == "" {
+		msg.From = config.LoadEnv("EMAIL")
+	}
+	if err := GetTransport().Send(msg); err != nil {
 		slog.Error("failed to send email: " + err.Error())
 		return errors.New("failed to send email")
 	}
 
 	logMsg := "Email sent to: %s subject: %s successfully 🎉"
 
-	slog.Info(fmt.Sprint(logMsg, to, subject))
+	slog.Info(fmt.Sprint(logMsg, msg.To, msg.Subject))
 	return nil
 }
 
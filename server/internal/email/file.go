@@ -0,0 +1,37 @@
+package email
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileTransport writes every email as an .eml file into a directory,
+// so that emails can be read during local development without a mail server.
+type FileTransport struct {
+	dir string
+}
+
+// NewFileTransport returns a transport that drops emails into dir, creating it if needed.
+func NewFileTransport(dir string) (FileTransport, error) {
+	if dir == "" || dir == "false" {
+		return FileTransport{}, errors.New("EMAIL_DROP_DIR is not set")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return FileTransport{}, err
+	}
+	return FileTransport{dir: dir}, nil
+}
+
+func (t FileTransport) Send(msg Message) error {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	// the timestamp first keeps the files in the order they were sent
+	name := time.Now().UTC().Format("20060102T150405.000000000") + "-" + hex.EncodeToString(suffix) + ".eml"
+	return os.WriteFile(filepath.Join(t.dir, name), msg.Bytes(), 0o640)
+}
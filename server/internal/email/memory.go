@@ -0,0 +1,45 @@
+package email
+
+import "sync"
+
+// MemoryTransport keeps sent emails in memory, so that tests can check what was sent.
+type MemoryTransport struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
+
+func (t *MemoryTransport) Send(msg Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sent = append(t.sent, msg)
+	return nil
+}
+
+// Messages returns the emails sent so far, oldest first.
+func (t *MemoryTransport) Messages() []Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Message(nil), t.sent...)
+}
+
+// SentTo returns the emails sent so far to one address, oldest first.
+func (t *MemoryTransport) SentTo(to string) []Message {
+	var res []Message
+	for _, msg := range t.Messages() {
+		if msg.To == to {
+			res = append(res, msg)
+		}
+	}
+	return res
+}
+
+// Reset forgets all sent emails.
+func (t *MemoryTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sent = nil
+}
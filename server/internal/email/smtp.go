@@ -0,0 +1,25 @@
+package email
+
+import (
+	"net/smtp"
+)
+
+// SMTPTransport sends emails through an SMTP server with PLAIN auth.
+type SMTPTransport struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPTransport returns a transport which logs in to host:port as username.
+func NewSMTPTransport(host, port, username, password string) SMTPTransport {
+	return SMTPTransport{
+		addr: host + ":" + port,
+		auth: smtp.PlainAuth("", username, password, host),
+		from: username,
+	}
+}
+
+func (t SMTPTransport) Send(msg Message) error {
+	return smtp.SendMail(t.addr, t.auth, t.from, []string{msg.To}, msg.Bytes())
+}
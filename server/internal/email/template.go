@@ -0,0 +1,202 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+// Every kind of email has a <name>.txt and a <name>.html template in each locale directory.
+// The .txt template is the plain-text body and defines the "subject" template.
+// The .html template defines "content", which is rendered inside the locale's layout.html.
+//
+//go:embed templates
+var templateFS embed.FS
+
+// DefaultLocale is used for users without a locale, and for locales we have no templates for.
+const DefaultLocale = "en"
+
+// TemplateData is the data of one kind of email. Its type decides which template renders it.
+type TemplateData interface {
+	templateName() string
+}
+
+// JobDeleted tells an applicant that a job they applied to was deleted.
+type JobDeleted struct {
+	JobTitle string
+	Reason   string
+}
+
+// NewApplicant tells a company that someone applied to one of their jobs.
+type NewApplicant struct {
+	ApplicantName string
+	JobTitle      string
+}
+
+// ApplicationStatusChanged tells an applicant that their application moved to Status.
+type ApplicationStatusChanged struct {
+	ApplicantName string
+	JobTitle      string
+	Status        string
+}
+
+// ApplicationRejected tells an applicant that their application was rejected for not meeting
+// the requirements of the job, with an optional Message of the company.
+type ApplicationRejected struct {
+	ApplicantName string
+	JobTitle      string
+	CompanyName   string
+	Message       string
+}
+
+// AccountDeleted tells a user that an admin deleted their account.
+type AccountDeleted struct {
+	Name string
+}
+
+// AccountVerification tells a user that an admin (un)verified their account.
+type AccountVerification struct {
+	Name     string
+	Verified bool
+}
+
+// RoleChanged tells a user that an admin changed their role.
+type RoleChanged struct {
+	Name string
+	Role string
+}
+
+// SavedSearchDigest lists new jobs matching a saved search.
+type SavedSearchDigest struct {
+	SearchName     string
+	Jobs           []DigestJob
+	UnsubscribeURL string
+}
+
+// MagicLink lets a user log in without a password by opening URL.
+type MagicLink struct {
+	Name         string
+	URL          string
+	ValidMinutes int
+}
+
+// OrganizationInvitation invites someone to join an organization as a member with Role.
+type OrganizationInvitation struct {
+	OrganizationName string
+	InviterName      string
+	Role             string
+	URL              string
+	ValidDays        int
+}
+
+// DigestJob is one job listed in a SavedSearchDigest.
+type DigestJob struct {
+	Title    string
+	Location string
+	URL      string
+}
+
+func (JobDeleted) templateName() string               { return "job_deleted" }
+func (NewApplicant) templateName() string             { return "new_applicant" }
+func (ApplicationStatusChanged) templateName() string { return "application_status" }
+func (ApplicationRejected) templateName() string      { return "application_rejected" }
+func (AccountDeleted) templateName() string           { return "account_deleted" }
+func (AccountVerification) templateName() string      { return "account_verification" }
+func (RoleChanged) templateName() string              { return "role_changed" }
+func (SavedSearchDigest) templateName() string        { return "saved_search_digest" }
+func (MagicLink) templateName() string                { return "magic_link" }
+func (OrganizationInvitation) templateName() string   { return "organization_invitation" }
+
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+var (
+	// registry maps locale, then template name, to its templates.
+	registry     map[string]map[string]emailTemplate
+	registryErr  error
+	registryOnce sync.Once
+)
+
+// Compose renders the email for data in the recipient's locale.
+func Compose(to, locale string, data TemplateData) (Message, error) {
+	registryOnce.Do(func() {
+		registry, registryErr = loadTemplates(templateFS, "templates")
+	})
+	if registryErr != nil {
+		return Message{}, registryErr
+	}
+
+	name := data.templateName()
+	tmpl, ok := registry[locale][name]
+	if !ok {
+		tmpl, ok = registry[DefaultLocale][name]
+	}
+	if !ok {
+		return Message{}, fmt.Errorf("no email template %q", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To: to,
+		// a subject is one line, whatever the data contained
+		Subject:  strings.Join(strings.Fields(subject.String()), " "),
+		Body:     text.String(),
+		HTMLBody: html.String(),
+	}, nil
+}
+
+// loadTemplates parses every locale directory under root.
+func loadTemplates(fsys fs.FS, root string) (map[string]map[string]emailTemplate, error) {
+	locales, err := fs.ReadDir(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]map[string]emailTemplate)
+	for _, locale := range locales {
+		if !locale.IsDir() {
+			continue
+		}
+		dir := path.Join(root, locale.Name())
+		texts, err := fs.Glob(fsys, path.Join(dir, "*.txt"))
+		if err != nil {
+			return nil, err
+		}
+
+		res[locale.Name()] = make(map[string]emailTemplate)
+		for _, textFile := range texts {
+			name := strings.TrimSuffix(path.Base(textFile), ".txt")
+			text, err := texttemplate.ParseFS(fsys, textFile)
+			if err != nil {
+				return nil, err
+			}
+			if text.Lookup("subject") == nil {
+				return nil, fmt.Errorf("%s does not define a subject", textFile)
+			}
+			html, err := htmltemplate.ParseFS(fsys, path.Join(dir, "layout.html"), path.Join(dir, name+".html"))
+			if err != nil {
+				return nil, err
+			}
+			res[locale.Name()][name] = emailTemplate{text: text, html: html}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,139 @@
+package email
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// one sample of every kind of email
+var allTemplateData = []TemplateData{
+	JobDeleted{JobTitle: "Backend Developer", Reason: "position filled"},
+	NewApplicant{ApplicantName: "Somchai", JobTitle: "Backend Developer"},
+	ApplicationStatusChanged{ApplicantName: "Somchai", JobTitle: "Backend Developer", Status: "INTERVIEW"},
+	ApplicationRejected{ApplicantName: "Somchai", JobTitle: "Backend Developer", CompanyName: "Acme", Message: "Thank you!"},
+	AccountDeleted{Name: "Somchai"},
+	AccountVerification{Name: "Somchai", Verified: true},
+	RoleChanged{Name: "Somchai", Role: "company"},
+	SavedSearchDigest{
+		SearchName:     "Go jobs",
+		Jobs:           []DigestJob{{Title: "Backend Developer", Location: "Bangkok", URL: "http://localhost/app/jobs/1"}},
+		UnsubscribeURL: "http://localhost/saved-searches/unsubscribe?token=abc",
+	},
+	MagicLink{Name: "Somchai", URL: "http://localhost/auth/magic-link/verify?token=abc", ValidMinutes: 15},
+	OrganizationInvitation{
+		OrganizationName: "Acme",
+		InviterName:      "Somchai",
+		Role:             "recruiter",
+		URL:              "http://localhost/company/invitations?token=abc",
+		ValidDays:        7,
+	},
+}
+
+func TestEveryTemplateRendersInEveryLocale(t *testing.T) {
+	for _, locale := range []string{"en", "th"} {
+		for _, data := range allTemplateData {
+			msg, err := Compose("someone@example.com", locale, data)
+			if !assert.NoError(t, err, "%s in %s", data.templateName(), locale) {
+				continue
+			}
+			assert.NotEmpty(t, msg.Subject, "%s in %s", data.templateName(), locale)
+			assert.NotContains(t, msg.Subject, "\n")
+			assert.NotEmpty(t, msg.Body)
+			assert.Contains(t, msg.HTMLBody, "<html lang=\""+locale+"\">")
+			// the .txt template must not leak its subject into the body
+			assert.False(t, strings.HasPrefix(msg.Body, "\n"), "%s in %s starts with a blank line", data.templateName(), locale)
+		}
+	}
+}
+
+func TestComposeFallsBackToDefaultLocale(t *testing.T) {
+	en, err := Compose("someone@example.com", "en", AccountDeleted{Name: "Somchai"})
+	assert.NoError(t, err)
+	for _, locale := range []string{"", "fr"} {
+		msg, err := Compose("someone@example.com", locale, AccountDeleted{Name: "Somchai"})
+		assert.NoError(t, err)
+		assert.Equal(t, en, msg)
+	}
+
+	th, err := Compose("someone@example.com", "th", AccountDeleted{Name: "Somchai"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, en.Subject, th.Subject)
+}
+
+func TestApplicationStatusSubjects(t *testing.T) {
+	msg, err := Compose("someone@example.com", "en", ApplicationStatusChanged{ApplicantName: "A", JobTitle: "Dev", Status: "SCREENING"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Your job application is being reviewed", msg.Subject)
+	assert.True(t, strings.HasPrefix(msg.Body, "Hello A,\n\nYour application for the job \"Dev\" has moved to screening."))
+
+	msg, err = Compose("someone@example.com", "en", ApplicationStatusChanged{ApplicantName: "A", JobTitle: "Dev", Status: "ON_HOLD"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Your job application status has been updated", msg.Subject)
+	assert.Contains(t, msg.Body, "updated to: ON_HOLD")
+}
+
+func TestHTMLBodyEscapesData(t *testing.T) {
+	msg, err := Compose("someone@example.com", "en", JobDeleted{JobTitle: "<script>alert(1)</script>"})
+	assert.NoError(t, err)
+	assert.NotContains(t, msg.HTMLBody, "<script>")
+	assert.Contains(t, msg.HTMLBody, "&lt;script&gt;")
+	assert.Contains(t, msg.Body, "No reason provided.")
+}
+
+func TestMultipartMessage(t *testing.T) {
+	msg, err := Compose("someone@example.com", "th", RoleChanged{Name: "สมชาย", Role: "company"})
+	assert.NoError(t, err)
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(msg.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, msg.Subject, subject)
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	var types, contents []string
+	for {
+		part, err := parts.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		content, _ := io.ReadAll(quotedprintable.NewReader(part))
+		types = append(types, strings.Split(part.Header.Get("Content-Type"), ";")[0])
+		// line breaks are sent as CRLF
+		contents = append(contents, strings.ReplaceAll(string(content), "\r\n", "\n"))
+	}
+	assert.Equal(t, []string{"text/plain", "text/html"}, types)
+	if assert.Len(t, contents, 2) {
+		assert.Equal(t, msg.Body, contents[0])
+		assert.Equal(t, msg.HTMLBody, contents[1])
+	}
+}
+
+func TestApplicationRejectedMessageIsOptional(t *testing.T) {
+	msg, err := Compose("someone@example.com", "en", ApplicationRejected{ApplicantName: "A", JobTitle: "Dev", CompanyName: "Acme"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Update on your application for Dev", msg.Subject)
+	assert.NotContains(t, msg.Body, "A note from")
+
+	msg, err = Compose("someone@example.com", "en", ApplicationRejected{ApplicantName: "A", JobTitle: "Dev", CompanyName: "Acme", Message: "Keep <going>"})
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Body, "A note from Acme:\nKeep <going>")
+	assert.Contains(t, msg.HTMLBody, "Keep &lt;going&gt;")
+}
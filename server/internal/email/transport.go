@@ -0,0 +1,116 @@
+package email
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"sync"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/config"
+)
+
+// Transport delivers a composed email.
+type Transport interface {
+	Send(msg Message) error
+}
+
+// Message is one email to deliver.
+// It is sent as multipart/alternative when it has an HTMLBody, and as plain text otherwise.
+// With an UnsubscribeURL, mail clients offer a one-click unsubscribe (RFC 8058)
+// which POSTs to it, so it must unsubscribe on POST and never on GET.
+type Message struct {
+	From           string
+	To             string
+	Subject        string
+	Body           string
+	HTMLBody       string
+	UnsubscribeURL string
+}
+
+// Bytes renders msg as an RFC 5322 message.
+func (msg Message) Bytes() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\n",
+		msg.From, msg.To, mime.BEncoding.Encode("UTF-8", msg.Subject))
+	if msg.UnsubscribeURL != "" {
+		fmt.Fprintf(&b, "List-Unsubscribe: <%s>\r\nList-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n", msg.UnsubscribeURL)
+	}
+
+	if msg.HTMLBody == "" {
+		b.WriteString("Content-Type: text/plain; charset=\"UTF-8\"\r\n")
+		b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		writeQuotedPrintable(&b, msg.Body)
+		return b.Bytes()
+	}
+
+	parts := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+	// clients show the last part they understand, so the richest one goes last
+	writePart(parts, "text/plain", msg.Body)
+	writePart(parts, "text/html", msg.HTMLBody)
+	parts.Close()
+	return b.Bytes()
+}
+
+func writePart(parts *multipart.Writer, contentType, content string) {
+	w, _ := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType + "; charset=\"UTF-8\""},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	writeQuotedPrintable(w, content)
+}
+
+func writeQuotedPrintable(w io.Writer, content string) {
+	qp := quotedprintable.NewWriter(w)
+	qp.Write([]byte(content))
+	qp.Close()
+}
+
+// Supported values of the EMAIL_TRANSPORT environment variable.
+const (
+	TransportSMTP   = "smtp"
+	TransportFile   = "file"
+	TransportMemory = "memory"
+)
+
+var (
+	instance Transport
+	once     sync.Once
+)
+
+// GetTransport returns the transport selected by EMAIL_TRANSPORT.
+func GetTransport() Transport {
+	once.Do(func() {
+		transport, err := newTransport(config.LoadEnv("EMAIL_TRANSPORT"))
+		if err != nil {
+			log.Fatal("Could not initialize email transport: " + err.Error())
+		}
+		instance = transport
+	})
+	return instance
+}
+
+func newTransport(name string) (Transport, error) {
+	switch name {
+	case TransportSMTP, "", "false":
+		// "false" is what config.LoadEnv returns when there is no .env file.
+		return NewSMTPTransport(
+			config.LoadEnv("EMAIL_PROVIDER"),
+			config.LoadEnv("EMAIL_PROVIDER_PORT"),
+			config.LoadEnv("EMAIL"),
+			config.LoadEnv("EMAIL_PASSWORD"),
+		), nil
+	case TransportFile:
+		return NewFileTransport(config.LoadEnv("EMAIL_DROP_DIR"))
+	case TransportMemory:
+		return NewMemoryTransport(), nil
+	default:
+		return nil, errors.New("unknown email transport: " + name)
+	}
+}
@@ -0,0 +1,69 @@
+package email
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileTransportWritesEml(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	transport, err := NewFileTransport(dir)
+	assert.NoError(t, err)
+
+	msg := Message{From: "noreply@example.com", To: "someone@example.com", Subject: "Hello", Body: "hi"}
+	assert.NoError(t, transport.Send(msg))
+
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	if !assert.Len(t, files, 1) {
+		return
+	}
+	assert.True(t, strings.HasSuffix(files[0].Name(), ".eml"))
+
+	content, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "To: someone@example.com\r\n")
+	assert.Contains(t, string(content), "Subject: Hello\r\n")
+	assert.Contains(t, string(content), "Content-Type: text/plain")
+	assert.True(t, strings.HasSuffix(string(content), "\r\n\r\nhi"))
+}
+
+func TestMessageWithUnsubscribeURL(t *testing.T) {
+	msg := Message{To: "someone@example.com", Subject: "Digest", Body: "hi", UnsubscribeURL: "http://localhost/unsubscribe?token=abc"}
+	content := string(msg.Bytes())
+	assert.Contains(t, content, "List-Unsubscribe: <http://localhost/unsubscribe?token=abc>\r\n")
+	assert.Contains(t, content, "List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+
+	assert.NotContains(t, string(Message{To: "someone@example.com", Body: "hi"}.Bytes()), "List-Unsubscribe")
+}
+
+func TestFileTransportNeedsDirectory(t *testing.T) {
+	_, err := NewFileTransport("")
+	assert.Error(t, err)
+}
+
+func TestMemoryTransportRecordsMessages(t *testing.T) {
+	transport := NewMemoryTransport()
+	assert.NoError(t, transport.Send(Message{To: "a@example.com", Subject: "one"}))
+	assert.NoError(t, transport.Send(Message{To: "b@example.com", Subject: "two"}))
+	assert.NoError(t, transport.Send(Message{To: "a@example.com", Subject: "three"}))
+
+	assert.Len(t, transport.Messages(), 3)
+	sent := transport.SentTo("a@example.com")
+	if assert.Len(t, sent, 2) {
+		assert.Equal(t, "one", sent[0].Subject)
+		assert.Equal(t, "three", sent[1].Subject)
+	}
+
+	transport.Reset()
+	assert.Empty(t, transport.Messages())
+}
+
+func TestNewTransportRejectsUnknownName(t *testing.T) {
+	_, err := newTransport("pigeon")
+	assert.Error(t, err)
+}
@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/authz"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -62,8 +64,9 @@ func TestAccessControlMiddleware_BannedUser(t *testing.T) {
 		c.Set("role", bannedUser.Role)
 		c.Next()
 	})
-	router.Use(AccessControlMiddleware())
-	router.GET("/test", func(c *gin.Context) {
+	perms := NewPermissions()
+	router.Use(AccessControlMiddleware(perms))
+	perms.Routes(&router.RouterGroup).GET("/test", Allow(AllRoles...), func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "success"})
 	})
 
@@ -90,8 +93,9 @@ func TestAccessControlMiddleware_NotBannedUser(t *testing.T) {
 		c.Set("role", user.Role)
 		c.Next()
 	})
-	router.Use(AccessControlMiddleware())
-	router.GET("/test", func(c *gin.Context) {
+	perms := NewPermissions()
+	router.Use(AccessControlMiddleware(perms))
+	perms.Routes(&router.RouterGroup).GET("/test", Allow(AllRoles...), func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "success"})
 	})
 
@@ -113,6 +117,7 @@ func TestAccessControlMiddleware_RolePermissions(t *testing.T) {
 		userRole       string
 		method         string
 		path           string
+		permission     Permission
 		expectedStatus int
 	}{
 		// Job routes
@@ -121,6 +126,7 @@ func TestAccessControlMiddleware_RolePermissions(t *testing.T) {
 			userRole:       "company",
 			method:         "POST",
 			path:           "/jobs/",
+			permission:     Allow("company", "admin"),
 			expectedStatus: http.StatusOK,
 		},
 		{
@@ -128,6 +134,7 @@ func TestAccessControlMiddleware_RolePermissions(t *testing.T) {
 			userRole:       "jobSeeker",
 			method:         "POST",
 			path:           "/jobs/",
+			permission:     Allow("company", "admin"),
 			expectedStatus: http.StatusForbidden,
 		},
 		{
@@ -135,6 +142,7 @@ func TestAccessControlMiddleware_RolePermissions(t *testing.T) {
 			userRole:       "admin",
 			method:         "POST",
 			path:           "/jobs/",
+			permission:     Allow("company", "admin"),
 			expectedStatus: http.StatusOK,
 		},
 		// Application routes
@@ -143,6 +151,7 @@ func TestAccessControlMiddleware_RolePermissions(t *testing.T) {
 			userRole:       "jobSeeker",
 			method:         "POST",
 			path:           "/apply/",
+			permission:     Allow("jobSeeker", "admin"),
 			expectedStatus: http.StatusOK,
 		},
 		{
@@ -150,6 +159,7 @@ func TestAccessControlMiddleware_RolePermissions(t *testing.T) {
 			userRole:       "company",
 			method:         "POST",
 			path:           "/apply/",
+			permission:     Allow("jobSeeker", "admin"),
 			expectedStatus: http.StatusForbidden,
 		},
 		// User routes (admin only)
@@ -158,6 +168,7 @@ func TestAccessControlMiddleware_RolePermissions(t *testing.T) {
 			userRole:       "admin",
 			method:         "GET",
 			path:           "/users/",
+			permission:     Allow("admin"),
 			expectedStatus: http.StatusOK,
 		},
 		{
@@ -165,6 +176,7 @@ func TestAccessControlMiddleware_RolePermissions(t *testing.T) {
 			userRole:       "jobSeeker",
 			method:         "GET",
 			path:           "/users/",
+			permission:     Allow("admin"),
 			expectedStatus: http.StatusForbidden,
 		},
 		{
@@ -172,6 +184,7 @@ func TestAccessControlMiddleware_RolePermissions(t *testing.T) {
 			userRole:       "company",
 			method:         "GET",
 			path:           "/users/",
+			permission:     Allow("admin"),
 			expectedStatus: http.StatusForbidden,
 		},
 	}
@@ -186,8 +199,9 @@ func TestAccessControlMiddleware_RolePermissions(t *testing.T) {
 				c.Set("role", user.Role)
 				c.Next()
 			})
-			router.Use(AccessControlMiddleware())
-			router.Handle(tt.method, tt.path, func(c *gin.Context) {
+			perms := NewPermissions()
+			router.Use(AccessControlMiddleware(perms))
+			perms.Routes(&router.RouterGroup).Handle(tt.method, tt.path, tt.permission, func(c *gin.Context) {
 				c.JSON(http.StatusOK, gin.H{"message": "success"})
 			})
 
@@ -206,9 +220,20 @@ func TestAccessControlMiddleware_OwnershipCheck(t *testing.T) {
 	setupTestDB(t)
 	defer teardownTestDB(t)
 
-	// Create company user and their job
+	// Create company user, the members of their organization and their job
 	company := createTestUser(t, "company", false)
+	recruiter := createTestUser(t, "company", false)
+	viewer := createTestUser(t, "company", false)
 	otherCompany := createTestUser(t, "company", false)
+	org := schema.Organization{
+		ID:   company.ID,
+		Name: "Test Company",
+		Members: []schema.OrganizationMember{
+			{UserID: company.ID, Role: schema.OrgOwner},
+			{UserID: recruiter.ID, Role: schema.OrgRecruiter},
+			{UserID: viewer.ID, Role: schema.OrgViewer},
+		},
+	}
 
 	job := schema.Job{
 		ID:        primitive.NewObjectID(),
@@ -222,6 +247,12 @@ func TestAccessControlMiddleware_OwnershipCheck(t *testing.T) {
 
 	_, err := db.Collection("jobs").InsertOne(ctx, job)
 	assert.NoError(t, err)
+	_, err = db.Collection("organizations").InsertOne(ctx, org)
+	assert.NoError(t, err)
+	authz.Register(authz.Job, authz.JobPolicy{
+		FindJob:       repository.FindOne[schema.Job],
+		Organizations: authz.OrganizationPolicy{FindOrganization: repository.FindOne[schema.Organization]},
+	})
 
 	tests := []struct {
 		name           string
@@ -235,6 +266,18 @@ func TestAccessControlMiddleware_OwnershipCheck(t *testing.T) {
 			jobID:          job.ID,
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:           "Recruiter of the company can update its job",
+			user:           recruiter,
+			jobID:          job.ID,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Viewer of the company cannot update its job",
+			user:           viewer,
+			jobID:          job.ID,
+			expectedStatus: http.StatusForbidden,
+		},
 		{
 			name:           "Non-owner company cannot update job",
 			user:           otherCompany,
@@ -251,8 +294,9 @@ func TestAccessControlMiddleware_OwnershipCheck(t *testing.T) {
 				c.Set("role", tt.user.Role)
 				c.Next()
 			})
-			router.Use(AccessControlMiddleware())
-			router.PUT("/jobs/:id", func(c *gin.Context) {
+			perms := NewPermissions()
+			router.Use(AccessControlMiddleware(perms))
+			perms.Routes(&router.RouterGroup).PUT("/jobs/:id", Allow("company", "admin").Owned(authz.Job, "id"), func(c *gin.Context) {
 				c.JSON(http.StatusOK, gin.H{"message": "success"})
 			})
 
@@ -270,7 +314,7 @@ func TestAccessControlMiddleware_DisabledAuth(t *testing.T) {
 	os.Setenv("ENABLE_AUTH", "false")
 
 	router := gin.New()
-	router.Use(AccessControlMiddleware())
+	router.Use(AccessControlMiddleware(NewPermissions()))
 	router.GET("/test", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "success"})
 	})
@@ -296,8 +340,9 @@ func TestAccessControlMiddleware_PublicRoutes(t *testing.T) {
 	for _, path := range publicPaths {
 		t.Run("Public route: "+path, func(t *testing.T) {
 			router := gin.New()
-			router.Use(AccessControlMiddleware())
-			router.GET(path, func(c *gin.Context) {
+			perms := NewPermissions()
+			router.Use(AccessControlMiddleware(perms))
+			perms.Routes(&router.RouterGroup).GET(path, Public, func(c *gin.Context) {
 				c.JSON(http.StatusOK, gin.H{"message": "success"})
 			})
 
@@ -310,75 +355,78 @@ func TestAccessControlMiddleware_PublicRoutes(t *testing.T) {
 	}
 }
 
-func TestMatchRoutePattern(t *testing.T) {
-	tests := []struct {
-		name           string
-		method         string
-		path           string
-		expectedKey    string
-		shouldMatch    bool
-	}{
-		{
-			name:        "Match job by ID",
-			method:      "GET",
-			path:        "/jobs/507f1f77bcf86cd799439011",
-			expectedKey: "GET:/jobs/:id",
-			shouldMatch: true,
-		},
-		{
-			name:        "Match file download",
-			method:      "GET",
-			path:        "/files/download/507f1f77bcf86cd799439011",
-			expectedKey: "GET:/files/download/:id",
-			shouldMatch: true,
-		},
-		{
-			name:        "Match complex file route",
-			method:      "GET",
-			path:        "/files/application/507f1f77bcf86cd799439011/download/507f191e810c19729de860ea",
-			expectedKey: "GET:/files/application/:applicationId/download/:fileId",
-			shouldMatch: true,
-		},
-	}
+func TestAccessControlMiddleware_UnlistedRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("ENABLE_AUTH", "true")
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			key, _, found := matchRoutePattern(tt.method, tt.path)
-			assert.Equal(t, tt.shouldMatch, found)
-			if found {
-				assert.Equal(t, tt.expectedKey, key)
-			}
-		})
-	}
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("role", "admin")
+		c.Next()
+	})
+	router.Use(AccessControlMiddleware(NewPermissions()))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "access_denied")
 }
 
-func TestExtractIDFromPath(t *testing.T) {
-	tests := []struct {
-		path     string
-		prefix   string
-		expected string
-	}{
-		{
-			path:     "/jobs/507f1f77bcf86cd799439011",
-			prefix:   "/jobs/",
-			expected: "507f1f77bcf86cd799439011",
-		},
-		{
-			path:     "/files/download/507f1f77bcf86cd799439011",
-			prefix:   "/files/download/",
-			expected: "507f1f77bcf86cd799439011",
-		},
-		{
-			path:     "/apply/507f1f77bcf86cd799439011",
-			prefix:   "/apply/",
-			expected: "507f1f77bcf86cd799439011",
-		},
-	}
+func TestRoutesMatchFullPath(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := func(c *gin.Context) {}
 
-	for _, tt := range tests {
-		t.Run(tt.path, func(t *testing.T) {
-			result := extractIDFromPath(tt.path, tt.prefix)
-			assert.Equal(t, tt.expected, result)
+	var fullPath string
+	router := gin.New()
+	perms := NewPermissions()
+	protected := perms.Routes(&router.RouterGroup).Group("/", func(c *gin.Context) { fullPath = c.FullPath() })
+	protected.Group("/jobs").POST("/", Allow("company"), handler)
+	protected.Group("/files").GET("/application/:applicationId/download/:fileId", Allow("company"), handler)
+	protected.Group("/admin").POST("/outbox/:id/retry", Allow("admin"), handler)
+
+	routes := router.Routes()
+	assert.Len(t, routes, 3)
+	for _, route := range routes {
+		t.Run(route.Method+" "+route.Path, func(t *testing.T) {
+			_, found := perms.Lookup(route.Method, route.Path)
+			assert.True(t, found)
 		})
 	}
-}
\ No newline at end of file
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/files/application/507f1f77bcf86cd799439011/download/507f191e810c19729de860ea", nil))
+	perm, found := perms.Lookup("GET", fullPath)
+	assert.True(t, found)
+	assert.True(t, perm.IsRoleAllowed("company"))
+}
+
+func TestPermissionsCheck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := func(c *gin.Context) {}
+
+	router := gin.New()
+	perms := NewPermissions()
+	routes := perms.Routes(&router.RouterGroup)
+	routes.GET("/health", Public, handler)
+	routes.GET("/jobs/:id", Allow(AllRoles...), handler)
+	routes.PUT("/jobs/:id", Allow("company", "admin").Owned(authz.Job, "id"), handler)
+	authz.Register(authz.Job, authz.JobPolicy{})
+	assert.NoError(t, perms.Check(router.Routes()))
+
+	// registered past the permissions
+	router.GET("/sneaky", handler)
+	// no role can use it
+	routes.DELETE("/jobs/:id", Allow(), handler)
+	// nothing tells who owns a poll
+	routes.PUT("/polls/:id", Allow("company").Owned(authz.Kind("poll"), "id"), handler)
+
+	err := perms.Check(router.Routes())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "GET /sneaky has no permission")
+	assert.Contains(t, err.Error(), "DELETE /jobs/:id allows no role")
+	assert.Contains(t, err.Error(), "PUT /polls/:id requires ownership, but poll has no policy")
+}
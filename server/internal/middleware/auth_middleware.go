@@ -1,18 +1,17 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/auth"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
-
 // AuthMiddleware validates JWT token and extracts user information
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -53,43 +52,38 @@ func AuthMiddleware() gin.HandlerFunc {
 
 			tokenString = parts[1]
 		}
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return jwtSecret, nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := auth.ParseToken(tokenString, auth.TokenAccess)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
 			c.Abort()
 			return
 		}
 
-		// Check if token is blacklisted
-		if auth.IsBlacklisted(tokenString) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+		// Check if token or the session which issued it was revoked, on this server or any other
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+		revocations := auth.GetRevocationStore()
+		revoked, err := revocations.IsRevoked(ctx, auth.TokenID(claims.ID, tokenString))
+		if err == nil && !revoked && claims.SessionID != "" {
+			revoked, err = revocations.IsRevoked(ctx, auth.SessionTokenID(claims.SessionID))
+		}
+		cancel()
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "could not check token"})
 			c.Abort()
 			return
 		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
 			c.Abort()
 			return
 		}
 
 		// Extract claims
-		userID, _ := claims["userID"].(string)
-		role, _ := claims["role"].(string)
-		email, _ := claims["email"].(string)
-		name, _ := claims["name"].(string)
-
-		c.Set("userID", userID)
-		c.Set("role", role)
-		c.Set("email", email)
-		c.Set("name", name)
+		c.Set("userID", claims.UserID)
+		c.Set("role", claims.Role)
+		c.Set("email", claims.Email)
+		c.Set("name", claims.Name)
+		c.Set("sessionID", claims.SessionID)
 
 		c.Next()
 	}
@@ -1,122 +1,157 @@
 package middleware
 
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/authz"
+)
+
+// AllRoles are the roles a user can have.
+var AllRoles = []string{"jobSeeker", "company", "faculty", "admin"}
+
 // Permission defines the access control rules for a route
 type Permission struct {
 	AllowedRoles     []string
 	RequireOwnership bool // For resources like "company can only edit own jobs"
+	// Resource is checked by its authz policy, IDParam names the path or query parameter with its ID.
+	Resource authz.Kind
+	IDParam  string
+	// public routes need no login, signedIn routes only need one.
+	// Neither is checked by AccessControlMiddleware.
+	public   bool
+	signedIn bool
+}
+
+var (
+	// Public routes are open to everyone.
+	Public = Permission{public: true}
+	// SignedIn routes are open to every logged in user, banned or not.
+	// Their group must use AuthMiddleware.
+	SignedIn = Permission{signedIn: true}
+)
+
+// Allow returns the permission of a route open to users with one of roles.
+func Allow(roles ...string) Permission {
+	return Permission{AllowedRoles: roles}
 }
 
-// RoutePermissions defines RBAC rules for each endpoint
-// Format: "METHOD:PATH_PATTERN" -> Permission
-var RoutePermissions = map[string]Permission{
-	// ===== Job Routes =====
-	"POST:/jobs/": {
-		AllowedRoles: []string{"company", "admin"},
-	},
-	"GET:/jobs/": {
-		AllowedRoles: []string{"jobSeeker", "company", "faculty", "admin"},
-	},
-	"GET:/jobs/query": {
-		AllowedRoles: []string{"jobSeeker", "company", "faculty", "admin"},
-	},
-	"GET:/jobs/:id": {
-		AllowedRoles: []string{"jobSeeker", "company", "faculty", "admin"},
-	},
-	"PUT:/jobs/:id": {
-		AllowedRoles:     []string{"company", "admin"},
-		RequireOwnership: true, // Company can only update their own jobs
-	},
-	"DELETE:/jobs/:id": {
-		AllowedRoles:     []string{"company", "admin"},
-		RequireOwnership: true, // Company can only delete their own jobs
-	},
-
-	// ===== Job Application Routes =====
-	"POST:/apply/": {
-		AllowedRoles: []string{"jobSeeker", "admin"},
-	},
-	"GET:/apply/": {
-		AllowedRoles: []string{"jobSeeker", "company", "admin"},
-	},
-	"GET:/apply/query": {
-		AllowedRoles: []string{"jobSeeker", "company", "admin"},
-	},
-	"GET:/apply/:id": {
-		AllowedRoles:     []string{"jobSeeker", "company", "admin"},
-		RequireOwnership: true, // Users can only view their own applications
-	},
-	"PUT:/apply/:id": {
-		AllowedRoles: []string{"company", "admin"}, // Only company/admin can update status
-	},
-	"DELETE:/apply/:id": {
-		AllowedRoles:     []string{"jobSeeker", "admin"},
-		RequireOwnership: true, // Job seekers can only delete their own applications
-	},
-
-	// ===== File Routes =====
-	"POST:/files/upload": {
-		AllowedRoles: []string{"jobSeeker", "company", "admin"},
-	},
-	"GET:/files/download/:id": {
-		AllowedRoles:     []string{"jobSeeker", "company", "admin"},
-		RequireOwnership: true, // Users can only download their own files
-	},
-	"GET:/files/user/:userId": {
-		AllowedRoles:     []string{"jobSeeker", "company", "admin"},
-		RequireOwnership: true, // Users can only list their own files
-	},
-	"DELETE:/files/:id": {
-		AllowedRoles:     []string{"jobSeeker", "company", "admin"},
-		RequireOwnership: true, // Users can only delete their own files
-	},
-	"GET:/files/application/:applicationId": {
-		AllowedRoles: []string{"company", "admin"}, // Company can view applicant files for their jobs
-	},
-	"GET:/files/application/:applicationId/download/:fileId": {
-		AllowedRoles: []string{"company", "admin"}, // Company can download applicant files
-	},
-
-	// ===== User Routes =====
-	// Only admin can list all users
-	"GET:/users/": {
-		AllowedRoles: []string{"admin"},
-	},
-	// Query endpoint - with role-based viewing enforcement
-	"GET:/users/query": {
-		AllowedRoles:     []string{"admin", "jobSeeker", "company", "faculty"},
-		RequireOwnership: true, // Will check role-based viewing in checkOwnership
-	},
-	// View specific user with role-based access control
-	"GET:/users/:id": {
-		AllowedRoles:     []string{"admin", "jobSeeker", "company", "faculty"},
-		RequireOwnership: true, // Enforced with role-based viewing in checkOwnership
-	},
-	"POST:/users/": {
-		AllowedRoles: []string{"admin"},
-	},
-	// Update user - must be own profile (or admin)
-	"PUT:/users/:id": {
-		AllowedRoles:     []string{"admin", "jobSeeker", "company", "faculty"},
-		RequireOwnership: true,
-	},
-	// Admin actions
-	"PATCH:/users/:id/verify": {
-		AllowedRoles: []string{"admin"},
-	},
-	"PATCH:/users/:id/role": {
-		AllowedRoles: []string{"admin"},
-	},
-	"DELETE:/users/:id": {
-		AllowedRoles: []string{"admin"},
-	},
+// Owned returns p, restricted to users whom the authz policy of resource lets act on it.
+// The ID of the resource is in the path parameter idParam, or else in the query parameter idParam.
+func (p Permission) Owned(resource authz.Kind, idParam string) Permission {
+	p.RequireOwnership = true
+	p.Resource = resource
+	p.IDParam = idParam
+	return p
 }
 
 // IsRoleAllowed checks if a role is allowed for a given permission
 func (p Permission) IsRoleAllowed(role string) bool {
-	for _, allowedRole := range p.AllowedRoles {
-		if allowedRole == role {
-			return true
+	return slices.Contains(p.AllowedRoles, role)
+}
+
+// Permissions holds the permission of every route, keyed by method and route path as in c.FullPath().
+type Permissions struct {
+	routes map[string]Permission
+}
+
+// NewPermissions returns an empty set of permissions, filled by registering routes through Routes.
+func NewPermissions() *Permissions {
+	return &Permissions{routes: make(map[string]Permission)}
+}
+
+func routeKey(method, fullPath string) string {
+	return method + ":" + fullPath
+}
+
+// Lookup returns the permission of a route.
+func (p *Permissions) Lookup(method, fullPath string) (Permission, bool) {
+	perm, ok := p.routes[routeKey(method, fullPath)]
+	return perm, ok
+}
+
+// Check verifies that every route of the router has a permission, and that the
+// resource of every owned route has an authz policy. Call it once all routes are registered:
+// a route added without a permission would otherwise only be noticed when it denies everyone.
+func (p *Permissions) Check(routes gin.RoutesInfo) error {
+	var problems []string
+	for _, route := range routes {
+		if route.Method == http.MethodHead || route.Method == http.MethodOptions {
+			continue
+		}
+		perm, ok := p.Lookup(route.Method, route.Path)
+		switch {
+		case !ok:
+			problems = append(problems, route.Method+" "+route.Path+" has no permission")
+		case perm.public || perm.signedIn:
+		case len(perm.AllowedRoles) == 0:
+			problems = append(problems, route.Method+" "+route.Path+" allows no role")
+		case perm.RequireOwnership && !authz.Registered(perm.Resource):
+			problems = append(problems, route.Method+" "+route.Path+" requires ownership, but "+string(perm.Resource)+" has no policy")
 		}
 	}
-	return false
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("route permissions: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Routes registers routes of a group together with their permission.
+type Routes struct {
+	group *gin.RouterGroup
+	perms *Permissions
+}
+
+// Routes returns the routes of group, whose permissions are kept in p.
+func (p *Permissions) Routes(group *gin.RouterGroup) Routes {
+	return Routes{group: group, perms: p}
+}
+
+// Group creates a subgroup, like gin's RouterGroup.Group.
+func (r Routes) Group(relativePath string, handlers ...gin.HandlerFunc) Routes {
+	return Routes{group: r.group.Group(relativePath, handlers...), perms: r.perms}
+}
+
+// Handle registers a route with its permission.
+func (r Routes) Handle(method, relativePath string, perm Permission, handlers ...gin.HandlerFunc) {
+	r.group.Handle(method, relativePath, handlers...)
+	r.perms.routes[routeKey(method, joinPaths(r.group.BasePath(), relativePath))] = perm
+}
+
+func (r Routes) GET(relativePath string, perm Permission, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodGet, relativePath, perm, handlers...)
+}
+
+func (r Routes) POST(relativePath string, perm Permission, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPost, relativePath, perm, handlers...)
+}
+
+func (r Routes) PUT(relativePath string, perm Permission, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPut, relativePath, perm, handlers...)
+}
+
+func (r Routes) PATCH(relativePath string, perm Permission, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPatch, relativePath, perm, handlers...)
+}
+
+func (r Routes) DELETE(relativePath string, perm Permission, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodDelete, relativePath, perm, handlers...)
+}
+
+// joinPaths joins paths the way gin does, so that the result matches c.FullPath().
+func joinPaths(absolutePath, relativePath string) string {
+	if relativePath == "" {
+		return absolutePath
+	}
+	finalPath := path.Join(absolutePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(finalPath, "/") {
+		return finalPath + "/"
+	}
+	return finalPath
 }
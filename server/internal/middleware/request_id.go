@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RequestIDHeader carries the ID of a request, in the request if a proxy already gave it one, and in the response.
+	RequestIDHeader = "X-Request-Id"
+	// RequestIDKey is where RequestID stores the ID in the gin context.
+	RequestIDKey = "requestID"
+)
+
+// requestIDPattern limits the IDs taken from clients, which end up in logs and audit events.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID gives every request an ID, so that what happened during it can be traced.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Set(RequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
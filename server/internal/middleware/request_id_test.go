@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(RequestIDKey))
+	})
+
+	get := func(header string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		if header != "" {
+			req.Header.Set(RequestIDHeader, header)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// a new ID is made up when the request has none
+	w := get("")
+	assert.Len(t, w.Body.String(), 32)
+	assert.Equal(t, w.Body.String(), w.Header().Get(RequestIDHeader))
+	assert.NotEqual(t, w.Body.String(), get("").Body.String())
+
+	// the ID of a proxy is kept
+	assert.Equal(t, "edge-42.abc", get("edge-42.abc").Body.String())
+
+	// but not when it could be used to forge log lines
+	for _, bad := range []string{"a b", "x\ny", strings.Repeat("a", 65)} {
+		assert.NotEqual(t, bad, get(bad).Body.String())
+	}
+}
@@ -0,0 +1,55 @@
+package migration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/storage"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// MoveFileContentToBlobStore copies the inline Content of every file document
+// into the configured blob store, then replaces Content with a StorageKey.
+// A file is only unset after its content is safely stored, so an interrupted run
+// can simply be started again: the blob of a file it stored but did not unset yet
+// is replaced, as BlobStore.Put replaces existing blobs.
+func MoveFileContentToBlobStore(ctx context.Context) (int, error) {
+	collection := database.GetDatabase().Collection(schema.File{}.GetCollectionName())
+	store := storage.GetBlobStore()
+
+	filter := bson.M{
+		"content":    bson.M{"$exists": true},
+		"storageKey": bson.M{"$exists": false},
+	}
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	migrated := 0
+	for cursor.Next(ctx) {
+		var file schema.File
+		if err := cursor.Decode(&file); err != nil {
+			return migrated, err
+		}
+
+		key := file.ID.Hex()
+		if _, err := store.Put(ctx, key, bytes.NewReader(file.Content)); err != nil {
+			return migrated, fmt.Errorf("store content of file %s: %w", key, err)
+		}
+
+		update := bson.M{
+			"$set":   bson.M{"storageKey": key},
+			"$unset": bson.M{"content": ""},
+		}
+		if _, err := collection.UpdateByID(ctx, file.ID, update); err != nil {
+			return migrated, fmt.Errorf("update file %s: %w", key, err)
+		}
+		migrated++
+	}
+	return migrated, cursor.Err()
+}
@@ -0,0 +1,46 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TypeJobQuestions replaces the free text questions of every job with the
+// ScreeningQuestions they are read as, so that they can be edited one by one.
+// Jobs are read the same way before and after, so applications keep answering the same questions.
+func TypeJobQuestions(ctx context.Context) (int, error) {
+	collection := database.GetDatabase().Collection(schema.Job{}.GetCollectionName())
+
+	cursor, err := collection.Find(ctx, bson.M{"questions": bson.M{"$type": "string"}})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	migrated := 0
+	for cursor.Next(ctx) {
+		var job struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			Questions string             `bson:"questions"`
+		}
+		if err := cursor.Decode(&job); err != nil {
+			return migrated, err
+		}
+
+		questions := schema.LegacyQuestions(job.Questions)
+		if questions == nil {
+			questions = schema.ScreeningQuestions{}
+		}
+		update := bson.M{"$set": bson.M{"questions": questions}}
+		if _, err := collection.UpdateByID(ctx, job.ID, update); err != nil {
+			return migrated, fmt.Errorf("update job %s: %w", job.ID.Hex(), err)
+		}
+		migrated++
+	}
+	return migrated, cursor.Err()
+}
@@ -0,0 +1,55 @@
+package migration
+
+import (
+	"context"
+	"time"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// SetJobStatus gives every job created before jobs had a lifecycle the status its dates imply:
+// scheduled before it opens, closed after its deadline, published in between.
+// Closed jobs are archived by the lifecycle scheduler later on, like any other.
+func SetJobStatus(ctx context.Context) (int, error) {
+	collection := database.GetDatabase().Collection(schema.Job{}.GetCollectionName())
+	now := time.Now()
+	withoutStatus := func(filter bson.M) bson.M {
+		filter["status"] = bson.M{"$in": bson.A{nil, ""}}
+		return filter
+	}
+
+	migrated := 0
+	scheduled, err := collection.UpdateMany(ctx,
+		withoutStatus(bson.M{"postOpenDate": bson.M{"$gt": now}}),
+		bson.M{"$set": bson.M{"status": schema.JobScheduled}},
+	)
+	if err != nil {
+		return migrated, err
+	}
+	migrated += int(scheduled.ModifiedCount)
+
+	// jobs closed at their deadline, which is when they stopped accepting applications
+	closed, err := collection.UpdateMany(ctx,
+		withoutStatus(bson.M{"applicationDeadline": bson.M{"$lt": now}}),
+		bson.A{bson.M{"$set": bson.M{
+			"status":       schema.JobClosed,
+			"closedAt":     "$applicationDeadline",
+			"closedReason": schema.ClosedAtDeadline,
+		}}},
+	)
+	if err != nil {
+		return migrated, err
+	}
+	migrated += int(closed.ModifiedCount)
+
+	published, err := collection.UpdateMany(ctx,
+		withoutStatus(bson.M{}),
+		bson.M{"$set": bson.M{"status": schema.JobPublished}},
+	)
+	if err != nil {
+		return migrated, err
+	}
+	return migrated + int(published.ModifiedCount), nil
+}
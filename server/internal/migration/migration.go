@@ -0,0 +1,48 @@
+// Package migration contains one-off data migrations.
+// Run one with `./server -migrate <name>`.
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+)
+
+// Migration migrates existing documents and reports how many it changed.
+// Every migration must be safe to run more than once.
+type Migration func(ctx context.Context) (migrated int, err error)
+
+var migrations = map[string]Migration{
+	"file-content":  MoveFileContentToBlobStore,
+	"job-questions": TypeJobQuestions,
+	"job-status":    SetJobStatus,
+	"user-info":     TypeUserInfo,
+}
+
+// Names returns the names of all registered migrations.
+func Names() []string {
+	names := make([]string, 0, len(migrations))
+	for name := range migrations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run runs the migration registered under name.
+func Run(ctx context.Context, name string) error {
+	migrate, ok := migrations[name]
+	if !ok {
+		return fmt.Errorf("unknown migration %q, available: %v", name, Names())
+	}
+
+	slog.Info("Migration started: " + name)
+	migrated, err := migrate(ctx)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Migration %s failed after %d documents: %s", name, migrated, err.Error()))
+		return err
+	}
+	slog.Info(fmt.Sprintf("Migration %s finished: %d documents migrated", name, migrated))
+	return nil
+}
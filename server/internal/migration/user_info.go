@@ -0,0 +1,107 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TypeUserInfo rewrites the userInfo of every user into the shape of its typed profile:
+// a document instead of an array of key-value pairs, job seeker skills as a list
+// instead of a comma separated string, and without the IDs companies used to repeat in it.
+// Users already in shape are left alone.
+func TypeUserInfo(ctx context.Context) (int, error) {
+	collection := database.GetDatabase().Collection(schema.User{}.GetCollectionName())
+
+	cursor, err := collection.Find(ctx, bson.M{"userInfo": bson.M{"$exists": true}})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	migrated := 0
+	for cursor.Next(ctx) {
+		var user struct {
+			ID       primitive.ObjectID `bson:"_id"`
+			Role     string             `bson:"role"`
+			UserInfo any                `bson:"userInfo"`
+		}
+		if err := cursor.Decode(&user); err != nil {
+			return migrated, err
+		}
+
+		info, changed := typedUserInfo(user.Role, user.UserInfo)
+		if !changed {
+			continue
+		}
+		update := bson.M{"$set": bson.M{"userInfo": info}}
+		if _, err := collection.UpdateByID(ctx, user.ID, update); err != nil {
+			return migrated, fmt.Errorf("update user %s: %w", user.ID.Hex(), err)
+		}
+		migrated++
+	}
+	return migrated, cursor.Err()
+}
+
+// typedUserInfo returns info in the shape of the profile of role, and whether that changed it.
+func typedUserInfo(role string, info any) (bson.M, bool) {
+	doc, ok := asDocument(info)
+	changed := false
+	if !ok {
+		list, isList := info.(bson.A)
+		if !isList {
+			return nil, false
+		}
+		// documents written from a decoded primitive.D hold [{Key, Value}, ...]
+		doc = bson.M{}
+		for _, item := range list {
+			pair, ok := asDocument(item)
+			if !ok {
+				continue
+			}
+			if key, ok := pair["Key"].(string); ok {
+				doc[key] = pair["Value"]
+			}
+		}
+		changed = true
+	}
+
+	switch role {
+	case schema.RoleJobSeeker:
+		if skills, ok := doc["skills"].(string); ok {
+			list := bson.A{}
+			for _, skill := range schema.SplitSkills(skills) {
+				list = append(list, skill)
+			}
+			doc["skills"] = list
+			changed = true
+		}
+	case schema.RoleCompany:
+		for _, key := range []string{"_id", "userID"} {
+			if _, ok := doc[key]; ok {
+				delete(doc, key)
+				changed = true
+			}
+		}
+	}
+	return doc, changed
+}
+
+// asDocument returns value as a bson.M if it is an embedded document.
+func asDocument(value any) (bson.M, bool) {
+	switch v := value.(type) {
+	case bson.M:
+		return v, true
+	case bson.D:
+		doc := bson.M{}
+		for _, e := range v {
+			doc[e.Key] = e.Value
+		}
+		return doc, true
+	}
+	return nil, false
+}
@@ -0,0 +1,179 @@
+// Package outbox queues outgoing emails in the database and delivers them in the background,
+// so that a slow or failing mail server never blocks or fails a request.
+//
+// Messages are queued right after the change they are about has been saved, not in the same
+// transaction: transactions need a replica set, and the server also runs on a standalone MongoDB.
+// So an email is queued at most once per change, and never about a change which was not saved,
+// but it is lost when queueing fails or the server stops between saving the change and queueing.
+// Callers log such failures, the change itself stands.
+//
+// A queued message is delivered at least once: it is retried until it is sent or dead, and a worker
+// which dies between sending it and recording that leaves it to be sent again once its lease ends.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/email"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/repository"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// MaxAttempts is how many times a message is tried before it is moved to the dead letters.
+	MaxAttempts = 8
+	// pollInterval is how often the worker looks for messages when the outbox is empty.
+	pollInterval = 10 * time.Second
+	// sendLease is how long a message is hidden from other workers while it is being sent.
+	// A message whose worker died mid-send is retried once the lease ends.
+	sendLease = 2 * time.Minute
+	// firstRetryDelay doubles after every failed attempt, up to maxRetryDelay.
+	firstRetryDelay = 30 * time.Second
+	maxRetryDelay   = 2 * time.Hour
+)
+
+// ErrNotDead is returned by Retry for messages which are not dead letters.
+var ErrNotDead = errors.New("only dead messages can be retried")
+
+// Enqueue queues messages for delivery. Call it right after the change
+// the messages are about has been saved, and only log when it fails,
+// as the change cannot be undone anymore (see the package doc).
+func Enqueue(ctx context.Context, messages ...email.Message) error {
+	return EnqueueAt(ctx, time.Now(), messages...)
+}
+
+// EnqueueAt queues messages which are not to be sent before sendAt.
+func EnqueueAt(ctx context.Context, sendAt time.Time, messages ...email.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	docs := make([]schema.OutboxMessage, 0, len(messages))
+	for _, m := range messages {
+		// reject bad headers now, retrying them would never help
+		if err := email.ValidateHeaders(m.To, m.Subject, m.UnsubscribeURL); err != nil {
+			return err
+		}
+		docs = append(docs, schema.OutboxMessage{
+			To:             m.To,
+			Subject:        m.Subject,
+			Body:           m.Body,
+			HTMLBody:       m.HTMLBody,
+			UnsubscribeURL: m.UnsubscribeURL,
+			Status:         schema.OutboxPending,
+			NextAttemptAt:  sendAt,
+			CreatedAt:      now,
+		})
+	}
+	_, err := repository.InsertMany(ctx, docs)
+	return err
+}
+
+// RunWorker delivers queued messages until ctx is cancelled.
+func RunWorker(ctx context.Context) {
+	for {
+		if _, err := DeliverDue(ctx); err != nil {
+			slog.Error("Outbox worker: " + err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// DeliverDue tries to send every message which is due, and returns how many it tried.
+func DeliverDue(ctx context.Context) (int, error) {
+	tried := 0
+	for ctx.Err() == nil {
+		delivered, err := deliverNext(ctx)
+		if err != nil || !delivered {
+			return tried, err
+		}
+		tried++
+	}
+	return tried, ctx.Err()
+}
+
+// deliverNext tries to send the next message which is due.
+// It reports whether there was such a message.
+func deliverNext(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, sendLease)
+	defer cancel()
+
+	now := time.Now()
+	// Claim the message by pushing its next attempt past the lease,
+	// so that no other worker picks it up while we are sending it.
+	msg, err := repository.FindOneAndUpdate[schema.OutboxMessage](ctx,
+		bson.M{"status": schema.OutboxPending, "nextAttemptAt": bson.M{"$lte": now}},
+		bson.M{
+			"$set": bson.M{"nextAttemptAt": now.Add(sendLease)},
+			"$inc": bson.M{"attempts": 1},
+		},
+		options.FindOneAndUpdate().SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}),
+	)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	update := bson.M{"status": schema.OutboxSent, "sentAt": time.Now(), "lastError": ""}
+	sendErr := email.Send(email.Message{
+		To:             msg.To,
+		Subject:        msg.Subject,
+		Body:           msg.Body,
+		HTMLBody:       msg.HTMLBody,
+		UnsubscribeURL: msg.UnsubscribeURL,
+	})
+	if sendErr != nil {
+		update = bson.M{"lastError": sendErr.Error()}
+		if msg.Attempts >= MaxAttempts {
+			update["status"] = schema.OutboxDead
+			slog.Warn(fmt.Sprintf("Outbox message %s is dead after %d attempts: %s", msg.ID.Hex(), msg.Attempts, sendErr))
+		} else {
+			update["nextAttemptAt"] = time.Now().Add(retryDelay(msg.Attempts))
+		}
+	}
+
+	_, err = repository.UpdateOne[schema.OutboxMessage](ctx, bson.M{"_id": msg.ID}, bson.M{"$set": update})
+	return true, err
+}
+
+// retryDelay returns how long to wait after the given number of failed attempts.
+func retryDelay(attempts int) time.Duration {
+	delay := firstRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+// Retry queues a dead message again, with a fresh set of attempts.
+func Retry(ctx context.Context, id primitive.ObjectID) (schema.OutboxMessage, error) {
+	msg, err := repository.FindOneAndUpdate[schema.OutboxMessage](ctx,
+		bson.M{"_id": id, "status": schema.OutboxDead},
+		bson.M{"$set": bson.M{
+			"status":        schema.OutboxPending,
+			"attempts":      0,
+			"nextAttemptAt": time.Now(),
+		}},
+	)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, findErr := repository.FindOne[schema.OutboxMessage](ctx, id); findErr == nil {
+			return msg, ErrNotDead
+		}
+	}
+	return msg, err
+}
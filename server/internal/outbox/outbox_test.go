@@ -0,0 +1,15 @@
+package outbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, retryDelay(1))
+	assert.Equal(t, time.Minute, retryDelay(2))
+	assert.Equal(t, 4*time.Minute, retryDelay(4))
+	assert.Equal(t, maxRetryDelay, retryDelay(MaxAttempts+20))
+}
@@ -10,6 +10,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// DeleteOne removes a document for good, schema.SoftDeletable ones are deleted with SoftDelete instead.
 func DeleteOne[T schema.CollectionEntity](ctx context.Context, objID primitive.ObjectID) (*mongo.DeleteResult, error) {
 	db := database.GetDatabase()
 	var collEn T
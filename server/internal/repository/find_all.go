@@ -10,6 +10,7 @@ import (
 )
 
 // findAll finds all document which matched the filter from a collection.
+// Like every read, it leaves soft-deleted documents out, see notDeleted.
 // note: opts is an optional parameter.
 func FindAll[T schema.CollectionEntity](
 	ctx context.Context,
@@ -18,7 +19,7 @@ func FindAll[T schema.CollectionEntity](
 ) ([]T, error) {
 	var collEn T
 	collection := database.GetDatabase().Collection(collEn.GetCollectionName())
-	cursor, err := collection.Find(ctx, filter, opts...)
+	cursor, err := collection.Find(ctx, notDeleted[T](filter), opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -16,7 +16,7 @@ func FindOne[T schema.CollectionEntity](
 	var result T
 
 	collection := database.GetDatabase().Collection(result.GetCollectionName())
-	filter := bson.M{"_id": primitiveObjectID}
+	filter := notDeleted[T](bson.M{"_id": primitiveObjectID})
 
 	if err := collection.FindOne(ctx, filter).Decode(&result); err != nil {
 		return result, err
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FindOneAndUpdate atomically applies update to the first document matching filter
+// and returns the document as it is after the update.
+// It returns mongo.ErrNoDocuments when nothing matched.
+func FindOneAndUpdate[T schema.CollectionEntity](
+	ctx context.Context,
+	filter bson.M,
+	update bson.M,
+	opts ...*options.FindOneAndUpdateOptions,
+) (T, error) {
+	var result T
+	collection := database.GetDatabase().Collection(result.GetCollectionName())
+
+	findOpts := options.MergeFindOneAndUpdateOptions(opts...)
+	findOpts.SetReturnDocument(options.After)
+
+	err := collection.FindOneAndUpdate(ctx, notDeleted[T](filter), update, findOpts).Decode(&result)
+	return result, err
+}
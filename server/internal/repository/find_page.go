@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DefaultPageLimit is used when the client does not ask for a page size.
+	DefaultPageLimit = 20
+	// MaxPageLimit is the largest page size a client may request.
+	MaxPageLimit = 100
+)
+
+// ErrInvalidCursor is returned when a cursor token cannot be decoded
+// or was issued for a different sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageRequest describes which page of a collection to fetch.
+// SortField defaults to "_id". SortOrder is 1 (ascending) or -1 (descending).
+// _id is always used as the tie-breaker so that pages never overlap.
+type PageRequest struct {
+	Limit     int
+	Cursor    string
+	SortField string
+	SortOrder int
+}
+
+// Page is one page of results plus the token for fetching the next one.
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+}
+
+// pageCursor is the decoded form of the opaque cursor token.
+type pageCursor struct {
+	SortField string             `bson:"f"`
+	SortOrder int                `bson:"o"`
+	Value     any                `bson:"v,omitempty"`
+	ID        primitive.ObjectID `bson:"id"`
+}
+
+// FindPage finds one page of documents which matched the filter from a collection.
+// It is the paginated variant of FindAll.
+// note: opts is an optional parameter, but its sort and limit are overridden by page.
+func FindPage[T schema.CollectionEntity](
+	ctx context.Context,
+	filter bson.M,
+	page PageRequest,
+	opts ...*options.FindOptions,
+) (Page[T], error) {
+	page = normalizePageRequest(page)
+	filter = notDeleted[T](filter)
+
+	if page.Cursor != "" {
+		cur, err := decodeCursor(page)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		filter = bson.M{"$and": bson.A{filter, cursorFilter(page, cur)}}
+	}
+
+	findOpts := options.MergeFindOptions(opts...)
+	findOpts.SetSort(pageSort(page))
+	// fetch one extra document to know whether there is a next page
+	findOpts.SetLimit(int64(page.Limit + 1))
+
+	var collEn T
+	collection := database.GetDatabase().Collection(collEn.GetCollectionName())
+	cursor, err := collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	return readPage[T](ctx, cursor, page)
+}
+
+// readPage decodes up to page.Limit documents from cursor.
+// The query behind cursor must ask for one extra document so that HasMore can be set.
+func readPage[T any](ctx context.Context, cursor *mongo.Cursor, page PageRequest) (Page[T], error) {
+	defer cursor.Close(ctx)
+
+	result := Page[T]{Data: make([]T, 0, page.Limit)}
+	var last bson.Raw
+	for cursor.Next(ctx) {
+		if len(result.Data) == page.Limit {
+			result.HasMore = true
+			break
+		}
+		var item T
+		if err := cursor.Decode(&item); err != nil {
+			return Page[T]{}, err
+		}
+		result.Data = append(result.Data, item)
+		last = cursor.Current
+	}
+	if err := cursor.Err(); err != nil {
+		return Page[T]{}, err
+	}
+
+	if result.HasMore {
+		var err error
+		result.NextCursor, err = encodeCursor(page, last)
+		if err != nil {
+			return Page[T]{}, err
+		}
+	}
+	return result, nil
+}
+
+func normalizePageRequest(page PageRequest) PageRequest {
+	if page.Limit <= 0 {
+		page.Limit = DefaultPageLimit
+	}
+	if page.Limit > MaxPageLimit {
+		page.Limit = MaxPageLimit
+	}
+	if page.SortField == "" {
+		page.SortField = "_id"
+	}
+	if page.SortOrder != -1 {
+		page.SortOrder = 1
+	}
+	return page
+}
+
+func pageSort(page PageRequest) bson.D {
+	if page.SortField == "_id" {
+		return bson.D{{Key: "_id", Value: page.SortOrder}}
+	}
+	return bson.D{
+		{Key: page.SortField, Value: page.SortOrder},
+		{Key: "_id", Value: page.SortOrder},
+	}
+}
+
+// cursorFilter matches every document that comes after cur in the page's sort order.
+func cursorFilter(page PageRequest, cur pageCursor) bson.M {
+	op := "$gt"
+	if page.SortOrder == -1 {
+		op = "$lt"
+	}
+	if page.SortField == "_id" {
+		return bson.M{"_id": bson.M{op: cur.ID}}
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{page.SortField: bson.M{op: cur.Value}},
+		bson.M{page.SortField: cur.Value, "_id": bson.M{op: cur.ID}},
+	}}
+}
+
+func encodeCursor(page PageRequest, last bson.Raw) (string, error) {
+	id, ok := last.Lookup("_id").ObjectIDOK()
+	if !ok {
+		return "", errors.New("cannot paginate documents without an ObjectID _id")
+	}
+	cur := pageCursor{SortField: page.SortField, SortOrder: page.SortOrder, ID: id}
+	if page.SortField != "_id" {
+		if val, err := last.LookupErr(page.SortField); err == nil {
+			cur.Value = val
+		}
+	}
+	raw, err := bson.Marshal(cur)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeCursor decodes the cursor of page and checks that it was issued for the same sort.
+func decodeCursor(page PageRequest) (pageCursor, error) {
+	var cur pageCursor
+	raw, err := base64.RawURLEncoding.DecodeString(page.Cursor)
+	if err != nil {
+		return cur, ErrInvalidCursor
+	}
+	if err := bson.Unmarshal(raw, &cur); err != nil {
+		return cur, ErrInvalidCursor
+	}
+	if cur.SortField != page.SortField || cur.SortOrder != page.SortOrder {
+		return cur, ErrInvalidCursor
+	}
+	return cur, nil
+}
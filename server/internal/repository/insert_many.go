@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// InsertMany inserts every document of raws into one collection.
+func InsertMany[T schema.CollectionEntity](ctx context.Context, raws []T) (*mongo.InsertManyResult, error) {
+	docs := make([]any, len(raws))
+	for i, raw := range raws {
+		docs[i] = raw
+	}
+
+	collection := database.GetDatabase().Collection((*new(T)).GetCollectionName())
+	return collection.InsertMany(ctx, docs)
+}
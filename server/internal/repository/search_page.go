@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TextScoreField is the field SearchPage stores each document's relevance score in.
+const TextScoreField = "score"
+
+// textIndexNotFound is MongoDB's error code for a $text query without a text index.
+const textIndexNotFound = 27
+
+// SearchPage finds one page of documents which matched both the filter and a
+// full-text search, most relevant first. The collection must have a text index.
+// Each document gets its relevance score in TextScoreField,
+// so T should have a field tagged `bson:"score"` to read it.
+func SearchPage[T schema.CollectionEntity](
+	ctx context.Context,
+	filter bson.M,
+	text string,
+	page PageRequest,
+) (Page[T], error) {
+	page.SortField, page.SortOrder = TextScoreField, -1
+	page = normalizePageRequest(page)
+
+	match := bson.M{"$text": bson.M{"$search": text}}
+	for k, v := range notDeleted[T](filter) {
+		match[k] = v
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$addFields", Value: bson.M{TextScoreField: bson.M{"$meta": "textScore"}}}},
+	}
+
+	if page.Cursor != "" {
+		cur, err := decodeCursor(page)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: cursorFilter(page, cur)}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: pageSort(page)}},
+		// fetch one extra document to know whether there is a next page
+		bson.D{{Key: "$limit", Value: page.Limit + 1}},
+	)
+
+	var collEn T
+	collection := database.GetDatabase().Collection(collEn.GetCollectionName())
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	return readPage[T](ctx, cursor, page)
+}
+
+// IsTextIndexMissing reports whether err was caused by searching a collection
+// that has no text index, so the caller can fall back to another kind of search.
+func IsTextIndexMissing(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(textIndexNotFound)
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// deletedAtField is where schema.SoftDelete stores when a document was deleted.
+const deletedAtField = "deletedAt"
+
+// notDeleted returns filter with the condition that leaves soft-deleted documents out,
+// if T is schema.SoftDeletable. A filter which already says something about deletedAt
+// is returned as it is, that is how admins look for deleted documents.
+func notDeleted[T schema.CollectionEntity](filter bson.M) bson.M {
+	if _, ok := any(*new(T)).(schema.SoftDeletable); !ok {
+		return filter
+	}
+	if _, ok := filter[deletedAtField]; ok {
+		return filter
+	}
+	result := make(bson.M, len(filter)+1)
+	for k, v := range filter {
+		result[k] = v
+	}
+	// matches documents which were never deleted, as well as restored ones
+	result[deletedAtField] = nil
+	return result
+}
+
+// onlyDeleted returns filter with the condition that only matches soft-deleted documents,
+// unless it already says something about deletedAt.
+func onlyDeleted(filter bson.M) bson.M {
+	if _, ok := filter[deletedAtField]; ok {
+		return filter
+	}
+	result := make(bson.M, len(filter)+1)
+	for k, v := range filter {
+		result[k] = v
+	}
+	result[deletedAtField] = bson.M{"$ne": nil}
+	return result
+}
+
+// SoftDelete marks every document matching filter as deleted at now by the user with ID by,
+// which may be primitive.NilObjectID when it is not known.
+// Documents deleted together share now, so that they can be restored together.
+func SoftDelete[T schema.SoftDeletable](
+	ctx context.Context,
+	filter bson.M,
+	by primitive.ObjectID,
+	now time.Time,
+) (*mongo.UpdateResult, error) {
+	return softDelete[T](ctx, filter, by, now)
+}
+
+// Delete deletes the document with id and reports whether there was one.
+// schema.SoftDeletable documents are soft-deleted by the user with ID by, others are removed for good.
+func Delete[T schema.CollectionEntity](
+	ctx context.Context,
+	id primitive.ObjectID,
+	by primitive.ObjectID,
+	now time.Time,
+) (bool, error) {
+	if _, ok := any(*new(T)).(schema.SoftDeletable); !ok {
+		res, err := DeleteOne[T](ctx, id)
+		if err != nil {
+			return false, err
+		}
+		return res.DeletedCount > 0, nil
+	}
+	res, err := softDelete[T](ctx, bson.M{"_id": id}, by, now)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+func softDelete[T schema.CollectionEntity](
+	ctx context.Context,
+	filter bson.M,
+	by primitive.ObjectID,
+	now time.Time,
+) (*mongo.UpdateResult, error) {
+	set := bson.M{deletedAtField: now}
+	if !by.IsZero() {
+		set["deletedBy"] = by
+	}
+	var collEn T
+	collection := database.GetDatabase().Collection(collEn.GetCollectionName())
+	return collection.UpdateMany(ctx, notDeleted[T](filter), bson.M{"$set": set})
+}
+
+// FindDeleted finds a soft-deleted document by its ID.
+func FindDeleted[T schema.SoftDeletable](ctx context.Context, id primitive.ObjectID) (T, error) {
+	var result T
+	collection := database.GetDatabase().Collection(result.GetCollectionName())
+	err := collection.FindOne(ctx, onlyDeleted(bson.M{"_id": id})).Decode(&result)
+	return result, err
+}
+
+// Restore clears the deletion mark of every soft-deleted document matching filter.
+func Restore[T schema.SoftDeletable](ctx context.Context, filter bson.M) (*mongo.UpdateResult, error) {
+	var collEn T
+	collection := database.GetDatabase().Collection(collEn.GetCollectionName())
+	return collection.UpdateMany(ctx, onlyDeleted(filter), bson.M{"$unset": bson.M{deletedAtField: "", "deletedBy": ""}})
+}
+
+// Purge permanently deletes the documents which were soft-deleted before the given time.
+func Purge[T schema.SoftDeletable](ctx context.Context, before time.Time) (int64, error) {
+	var collEn T
+	collection := database.GetDatabase().Collection(collEn.GetCollectionName())
+	res, err := collection.DeleteMany(ctx, bson.M{deletedAtField: bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
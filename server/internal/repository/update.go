@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
 	"strings"
 
@@ -12,6 +14,9 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// ErrInvalidUpdate wraps the error of the ValidatePartial method of a dto.
+var ErrInvalidUpdate = errors.New("invalid update")
+
 func Update[collectionEntity schema.CollectionEntity, dto any](
 	ctx context.Context, objID primitive.ObjectID, newData dto,
 ) (*mongo.UpdateResult, error) {
@@ -19,7 +24,7 @@ func Update[collectionEntity schema.CollectionEntity, dto any](
 	updateFields := buildUpdateMap(newData)
 	if v, ok := any(*new(dto)).(interface{ ValidatePartial(map[string]any) error }); ok {
 		if err := v.ValidatePartial(updateFields); err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %w", ErrInvalidUpdate, err)
 		}
 	}
 
@@ -29,7 +34,7 @@ func Update[collectionEntity schema.CollectionEntity, dto any](
 	var collEn collectionEntity
 	collection := db.Collection(collEn.GetCollectionName())
 
-	res, err := collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	res, err := collection.UpdateOne(ctx, notDeleted[collectionEntity](bson.M{"_id": objID}), update)
 	return res, err
 }
 
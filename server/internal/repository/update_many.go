@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UpdateMany applies a raw update document to every document matching filter.
+func UpdateMany[T schema.CollectionEntity](
+	ctx context.Context,
+	filter bson.M,
+	update bson.M,
+) (*mongo.UpdateResult, error) {
+	var collEn T
+	collection := database.GetDatabase().Collection(collEn.GetCollectionName())
+	return collection.UpdateMany(ctx, notDeleted[T](filter), update)
+}
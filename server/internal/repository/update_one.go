@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UpdateOne applies a raw update document to the first document matching filter.
+// Use it when Update's "$set every non-nil field" is not enough,
+// e.g. for conditional updates or "$push".
+func UpdateOne[T schema.CollectionEntity](
+	ctx context.Context,
+	filter bson.M,
+	update bson.M,
+	opts ...*options.UpdateOptions,
+) (*mongo.UpdateResult, error) {
+	var collEn T
+	collection := database.GetDatabase().Collection(collEn.GetCollectionName())
+	return collection.UpdateOne(ctx, notDeleted[T](filter), update, opts...)
+}
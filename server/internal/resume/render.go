@@ -0,0 +1,170 @@
+// Package resume renders the structured resumes of job seekers as PDF.
+package resume
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+)
+
+const (
+	margin     = 20.0 // mm
+	lineHeight = 5.5  // mm
+	textFont   = "NotoSansThai"
+)
+
+// notoSansThai is Noto Sans Thai, which covers Thai as well as Latin, unlike the built-in fonts.
+//
+//go:embed fonts/NotoSansThai-Regular.ttf
+var notoSansThai []byte
+
+// Render writes r as an A4 PDF to w, headed with the name and contact details of owner.
+// Text is printed in Noto Sans Thai, which is embedded into the PDF.
+// It does not shape text, so Thai vowels and tone marks above one another may overlap.
+func Render(w io.Writer, owner schema.User, r schema.Resume) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(textFont, "", notoSansThai)
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(true, margin)
+	pdf.SetCreationDate(r.CreatedAt)
+	pdf.SetTitle(r.Title, true)
+	pdf.SetAuthor(owner.Name, true)
+	pdf.AddPage()
+
+	name, contacts := header(owner)
+	pdf.SetFont(textFont, "", 22)
+	pdf.CellFormat(0, 10, name, "", 1, "L", false, 0, "")
+	if len(contacts) > 0 {
+		pdf.SetFont(textFont, "", 9)
+		pdf.MultiCell(0, lineHeight, strings.Join(contacts, "  |  "), "", "L", false)
+	}
+
+	if r.Summary != "" {
+		section(pdf, "Summary")
+		body(pdf, r.Summary)
+	}
+
+	if len(r.Experience) > 0 {
+		section(pdf, "Experience")
+		for _, work := range r.Experience {
+			end := work.EndDate
+			if end == "" {
+				end = "present"
+			}
+			entry(pdf, work.Title+", "+work.Company, work.StartDate+" - "+end)
+			body(pdf, work.Description)
+		}
+	}
+
+	if len(r.Education) > 0 {
+		section(pdf, "Education")
+		for _, edu := range r.Education {
+			entry(pdf, edu.Institution, years(edu.StartYear, edu.EndYear))
+			details := joinNonEmpty(", ", edu.Degree, edu.FieldOfStudy)
+			if edu.GPA != "" {
+				details = joinNonEmpty(", ", details, "GPA "+edu.GPA)
+			}
+			body(pdf, details)
+		}
+	}
+
+	if len(r.Skills) > 0 {
+		section(pdf, "Skills")
+		body(pdf, strings.Join(r.Skills, ", "))
+	}
+
+	if len(r.Projects) > 0 {
+		section(pdf, "Projects")
+		for _, project := range r.Projects {
+			entry(pdf, project.Name, project.URL)
+			body(pdf, project.Description)
+			if len(project.Technologies) > 0 {
+				body(pdf, "Built with "+strings.Join(project.Technologies, ", "))
+			}
+		}
+	}
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
+
+// header returns the name to print on top of the resume of owner and their contact details.
+func header(owner schema.User) (string, []string) {
+	name := owner.Name
+	contacts := []string{owner.Email}
+	if info, ok := owner.UserInfo.(*schema.JobSeekerInfo); ok {
+		if info.FullName != "" {
+			name = info.FullName
+		}
+		contacts = append(contacts, info.Phone, info.Location, info.LinkedIn, info.GitHub, info.Portfolio)
+	}
+	nonEmpty := contacts[:0]
+	for _, contact := range contacts {
+		if contact != "" {
+			nonEmpty = append(nonEmpty, contact)
+		}
+	}
+	return name, nonEmpty
+}
+
+// section prints the heading of a section, title is one of the English ones above.
+// Only the regular face is embedded, so the heading is made bold by outlining its glyphs too.
+func section(pdf *fpdf.Fpdf, title string) {
+	pdf.Ln(4)
+	pdf.SetFont(textFont, "", 13)
+	pdf.SetTextRenderingMode(2) // fill and stroke
+	pdf.CellFormat(0, 8, title, "B", 1, "L", false, 0, "")
+	pdf.SetTextRenderingMode(0)
+	pdf.Ln(1)
+}
+
+// entry prints title on the left and when, e.g. the dates, on the right of one line.
+func entry(pdf *fpdf.Fpdf, title, when string) {
+	width, _ := pdf.GetPageSize()
+	whenWidth := 0.0
+	if when != "" {
+		pdf.SetFont(textFont, "", 9)
+		whenWidth = pdf.GetStringWidth(when) + 2
+	}
+	pdf.SetFont(textFont, "", 11.5)
+	pdf.CellFormat(width-2*margin-whenWidth, lineHeight+1, title, "", 0, "L", false, 0, "")
+	pdf.SetFont(textFont, "", 9)
+	pdf.CellFormat(whenWidth, lineHeight+1, when, "", 1, "R", false, 0, "")
+}
+
+func body(pdf *fpdf.Fpdf, text string) {
+	if text == "" {
+		return
+	}
+	pdf.SetFont(textFont, "", 10.5)
+	pdf.MultiCell(0, lineHeight, text, "", "L", false)
+	pdf.Ln(1)
+}
+
+func years(start, end int) string {
+	switch {
+	case start == 0 && end == 0:
+		return ""
+	case end == 0:
+		return fmt.Sprintf("%d - present", start)
+	case start == 0:
+		return fmt.Sprint(end)
+	}
+	return fmt.Sprintf("%d - %d", start, end)
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, sep)
+}
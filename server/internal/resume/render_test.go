@@ -0,0 +1,84 @@
+package resume
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func testResume() schema.Resume {
+	return schema.Resume{
+		Version: 2,
+		Title:   "Backend developer",
+		Summary: "Go developer who likes databases and café au lait.",
+		Experience: []schema.WorkExperience{
+			{Company: "Acme", Title: "Intern", StartDate: "2024-06", EndDate: "2024-09", Description: "Built an outbox."},
+			{Company: "Initech", Title: "Developer", StartDate: "2025-01"},
+		},
+		Education: []schema.Education{{Institution: "Kasetsart University", Degree: "BEng", StartYear: 2021, EndYear: 2025}},
+		Skills:    []string{"Go", "MongoDB"},
+		Projects:  []schema.Project{{Name: "Job Applier 3000", URL: "https://example.com", Technologies: []string{"Go"}}},
+		CreatedAt: time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestRender(t *testing.T) {
+	owner := schema.User{
+		Name:     "Somchai",
+		Email:    "somchai@example.com",
+		UserInfo: &schema.JobSeekerInfo{FullName: "Somchai Jaidee", Phone: "0812345678", Location: "Bangkok"},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, Render(&out, owner, testResume()))
+	assert.True(t, bytes.HasPrefix(out.Bytes(), []byte("%PDF-")))
+	assert.True(t, bytes.HasSuffix(bytes.TrimSpace(out.Bytes()), []byte("%%EOF")))
+}
+
+func TestRenderEmptyResume(t *testing.T) {
+	// a resume with a title only, of a user without a profile yet, still renders
+	var out bytes.Buffer
+	assert.NoError(t, Render(&out, schema.User{Name: "สมชาย"}, schema.Resume{Title: "Draft"}))
+	assert.True(t, bytes.HasPrefix(out.Bytes(), []byte("%PDF-")))
+}
+
+func TestRenderThai(t *testing.T) {
+	owner := schema.User{
+		Name:     "Somchai",
+		Email:    "somchai@example.com",
+		UserInfo: &schema.JobSeekerInfo{FullName: "สมชาย ใจดี", Location: "กรุงเทพมหานคร"},
+	}
+	r := testResume()
+	r.Summary = "นักพัฒนา Go ที่ชอบฐานข้อมูล"
+
+	var out bytes.Buffer
+	assert.NoError(t, Render(&out, owner, r))
+	// the text is printed in the embedded font, which has the Thai glyphs
+	assert.True(t, bytes.Contains(out.Bytes(), []byte("/BaseFont /utf8notosansthai")))
+	assert.True(t, bytes.Contains(out.Bytes(), []byte("/FontFile2")))
+	assert.False(t, bytes.Contains(out.Bytes(), []byte("Helvetica")))
+}
+
+func TestHeader(t *testing.T) {
+	name, contacts := header(schema.User{
+		Name:     "Somchai",
+		Email:    "somchai@example.com",
+		UserInfo: &schema.JobSeekerInfo{FullName: "Somchai Jaidee", Location: "Bangkok"},
+	})
+	assert.Equal(t, "Somchai Jaidee", name)
+	assert.Equal(t, []string{"somchai@example.com", "Bangkok"}, contacts)
+
+	name, contacts = header(schema.User{Name: "Acme", Email: "hr@acme.com", UserInfo: &schema.CompanyInfo{Name: "Acme Inc."}})
+	assert.Equal(t, "Acme", name)
+	assert.Equal(t, []string{"hr@acme.com"}, contacts)
+}
+
+func TestYears(t *testing.T) {
+	assert.Equal(t, "", years(0, 0))
+	assert.Equal(t, "2021 - present", years(2021, 0))
+	assert.Equal(t, "2025", years(0, 2025))
+	assert.Equal(t, "2021 - 2025", years(2021, 2025))
+}
@@ -0,0 +1,81 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ApplicationStatus is a step in the job application lifecycle:
+// PENDING -> SCREENING -> INTERVIEW -> OFFER -> ACCEPTED.
+// An application can be REJECTED or WITHDRAWN at any step before it is closed.
+type ApplicationStatus string
+
+const (
+	StatusPending   ApplicationStatus = "PENDING"
+	StatusScreening ApplicationStatus = "SCREENING"
+	StatusInterview ApplicationStatus = "INTERVIEW"
+	StatusOffer     ApplicationStatus = "OFFER"
+	StatusAccepted  ApplicationStatus = "ACCEPTED"
+	StatusRejected  ApplicationStatus = "REJECTED"
+	StatusWithdrawn ApplicationStatus = "WITHDRAWN"
+)
+
+// applicationTransitions lists the statuses each status may move to.
+// Statuses without an entry are final.
+var applicationTransitions = map[ApplicationStatus][]ApplicationStatus{
+	StatusPending:   {StatusScreening, StatusRejected, StatusWithdrawn},
+	StatusScreening: {StatusInterview, StatusRejected, StatusWithdrawn},
+	StatusInterview: {StatusOffer, StatusRejected, StatusWithdrawn},
+	StatusOffer:     {StatusAccepted, StatusRejected, StatusWithdrawn},
+}
+
+// StatusChange is one entry of a job application's status history.
+type StatusChange struct {
+	From       ApplicationStatus  `bson:"from,omitempty" json:"from,omitempty"`
+	To         ApplicationStatus  `bson:"to" json:"to"`
+	ActorID    primitive.ObjectID `bson:"actorID" json:"actorID"`
+	ActorRole  string             `bson:"actorRole,omitempty" json:"actorRole,omitempty"`
+	Reason     string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	ReasonCode string             `bson:"reasonCode,omitempty" json:"reasonCode,omitempty"` // machine readable reason of automatic changes
+	ChangedAt  time.Time          `bson:"changedAt" json:"changedAt"`
+}
+
+// ParseApplicationStatus parses a status case-insensitively.
+func ParseApplicationStatus(s string) (ApplicationStatus, error) {
+	status := ApplicationStatus(strings.ToUpper(strings.TrimSpace(s)))
+	switch status {
+	case StatusPending, StatusScreening, StatusInterview, StatusOffer,
+		StatusAccepted, StatusRejected, StatusWithdrawn:
+		return status, nil
+	}
+	return "", fmt.Errorf("unknown application status: %s", s)
+}
+
+// IsFinal reports whether no further status change is possible.
+func (s ApplicationStatus) IsFinal() bool {
+	return len(applicationTransitions[s]) == 0
+}
+
+// CanTransitionTo reports whether an application may move from s to next.
+func (s ApplicationStatus) CanTransitionTo(next ApplicationStatus) bool {
+	for _, allowed := range applicationTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentStatus returns the lifecycle status of the application.
+// Applications created before the lifecycle existed may hold free-form
+// statuses; those are treated as PENDING.
+func (ja JobApplication) CurrentStatus() ApplicationStatus {
+	status, err := ParseApplicationStatus(string(ja.Status))
+	if err != nil {
+		return StatusPending
+	}
+	return status
+}
@@ -0,0 +1,63 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseApplicationStatusIsCaseInsensitive(t *testing.T) {
+	status, err := ParseApplicationStatus(" interview ")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusInterview, status)
+}
+
+func TestParseApplicationStatusUnknown(t *testing.T) {
+	_, err := ParseApplicationStatus("waiting for approval")
+	assert.Error(t, err)
+}
+
+func TestApplicationStatusHappyPath(t *testing.T) {
+	path := []ApplicationStatus{StatusPending, StatusScreening, StatusInterview, StatusOffer, StatusAccepted}
+	for i := 0; i < len(path)-1; i++ {
+		assert.True(t, path[i].CanTransitionTo(path[i+1]), "%s -> %s", path[i], path[i+1])
+	}
+}
+
+func TestApplicationStatusCannotSkipSteps(t *testing.T) {
+	assert.False(t, StatusPending.CanTransitionTo(StatusAccepted))
+	assert.False(t, StatusScreening.CanTransitionTo(StatusOffer))
+	assert.False(t, StatusOffer.CanTransitionTo(StatusPending))
+}
+
+func TestApplicationStatusCanBeClosedEarly(t *testing.T) {
+	for _, s := range []ApplicationStatus{StatusPending, StatusScreening, StatusInterview, StatusOffer} {
+		assert.True(t, s.CanTransitionTo(StatusRejected))
+		assert.True(t, s.CanTransitionTo(StatusWithdrawn))
+	}
+}
+
+func TestApplicationStatusFinalStates(t *testing.T) {
+	for _, s := range []ApplicationStatus{StatusAccepted, StatusRejected, StatusWithdrawn} {
+		assert.True(t, s.IsFinal())
+		assert.False(t, s.CanTransitionTo(StatusPending))
+	}
+	assert.False(t, StatusPending.IsFinal())
+}
+
+func TestLegacyStatusIsTreatedAsPending(t *testing.T) {
+	app := JobApplication{Status: "waiting for approval"}
+	assert.Equal(t, StatusPending, app.CurrentStatus())
+}
+
+func TestNewJobApplicationMustBePending(t *testing.T) {
+	app := JobApplication{ApplicantID: primitive.NewObjectID(), Status: "pending"}
+	assert.NoError(t, app.Validate())
+	assert.Equal(t, StatusPending, app.Status)
+	assert.Len(t, app.StatusHistory, 1)
+	assert.Equal(t, app.ApplicantID, app.StatusHistory[0].ActorID)
+
+	accepted := JobApplication{Status: StatusAccepted}
+	assert.Error(t, accepted.Validate())
+}
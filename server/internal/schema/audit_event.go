@@ -0,0 +1,51 @@
+package schema
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditAction is what an actor did in an AuditEvent.
+type AuditAction string
+
+const (
+	AuditVerify         AuditAction = "verify"
+	AuditUnverify       AuditAction = "unverify"
+	AuditRoleChange     AuditAction = "role_change"
+	AuditBan            AuditAction = "ban"
+	AuditUnban          AuditAction = "unban"
+	AuditDelete         AuditAction = "delete"
+	AuditRestore        AuditAction = "restore"
+	AuditLogin          AuditAction = "login"
+	AuditLinkIdentity   AuditAction = "link_identity"
+	AuditUnlinkIdentity AuditAction = "unlink_identity"
+	AuditRevokeSession  AuditAction = "revoke_session"
+)
+
+// AuditEvent records a privileged or security-relevant action.
+// Audit events are only ever inserted, never changed or deleted.
+type AuditEvent struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	ActorID    *primitive.ObjectID `bson:"actorID,omitempty" json:"actorID,omitempty"` // nil when nobody was signed in
+	ActorRole  string              `bson:"actorRole,omitempty" json:"actorRole,omitempty"`
+	Action     AuditAction         `bson:"action" json:"action"`
+	TargetType string              `bson:"targetType" json:"targetType"` // the collection of the target, e.g. users
+	TargetID   primitive.ObjectID  `bson:"targetID" json:"targetID"`
+	Changes    []AuditChange       `bson:"changes,omitempty" json:"changes,omitempty"`
+	Detail     string              `bson:"detail,omitempty" json:"detail,omitempty"` // e.g. the reason given, or the provider
+	IP         string              `bson:"ip,omitempty" json:"ip,omitempty"`
+	RequestID  string              `bson:"requestID,omitempty" json:"requestID,omitempty"`
+	CreatedAt  time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// AuditChange is one field of the target which the action changed.
+type AuditChange struct {
+	Field  string `bson:"field" json:"field"`
+	Before any    `bson:"before" json:"before"`
+	After  any    `bson:"after" json:"after"`
+}
+
+func (e AuditEvent) GetCollectionName() string {
+	return "audit_events"
+}
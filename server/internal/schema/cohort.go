@@ -0,0 +1,129 @@
+package schema
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Cohort is a group of job seekers followed by a faculty member, e.g. the students of a class.
+// The faculty member only sees a student's profile and applications once the student consents to it.
+type Cohort struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name      string             `bson:"name" json:"name" binding:"required,min=1,max=100"`
+	FacultyID primitive.ObjectID `bson:"facultyID" json:"facultyID"`
+	Students  []CohortStudent    `bson:"students" json:"students"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// CohortStudent is a job seeker in a cohort.
+type CohortStudent struct {
+	UserID  primitive.ObjectID `bson:"userID" json:"userID"`
+	AddedAt time.Time          `bson:"addedAt" json:"addedAt"`
+	// ConsentedAt is when the student agreed to share with the faculty member, nil until they do.
+	ConsentedAt *time.Time `bson:"consentedAt,omitempty" json:"consentedAt,omitempty"`
+}
+
+func (c Cohort) GetCollectionName() string {
+	return "cohorts"
+}
+
+// Student returns the student of the cohort with userID.
+func (c Cohort) Student(userID primitive.ObjectID) (CohortStudent, bool) {
+	for _, student := range c.Students {
+		if student.UserID == userID {
+			return student, true
+		}
+	}
+	return CohortStudent{}, false
+}
+
+// Consented returns the IDs of the students who consented to share with the faculty member.
+func (c Cohort) Consented() []primitive.ObjectID {
+	ids := []primitive.ObjectID{}
+	for _, student := range c.Students {
+		if student.ConsentedAt != nil {
+			ids = append(ids, student.UserID)
+		}
+	}
+	return ids
+}
+
+// Endorsement is a faculty member's recommendation of a student of their cohort,
+// shown to everyone who can view the student's profile.
+type Endorsement struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	StudentID primitive.ObjectID `bson:"studentID" json:"studentID"`
+	FacultyID primitive.ObjectID `bson:"facultyID" json:"facultyID"`
+	CohortID  primitive.ObjectID `bson:"cohortID" json:"cohortID"`
+	Comment   string             `bson:"comment" json:"comment"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+func (e Endorsement) GetCollectionName() string {
+	return "endorsements"
+}
+
+// StudentOutcome counts the applications of a student by status.
+type StudentOutcome struct {
+	StudentID    primitive.ObjectID        `json:"studentID"`
+	Name         string                    `json:"name,omitempty"`
+	Applications int                       `json:"applications"`
+	ByStatus     map[ApplicationStatus]int `json:"byStatus"`
+	// Placed tells whether the student accepted an offer.
+	Placed bool `json:"placed"`
+}
+
+// CohortOutcomes sums up the applications of the students of a cohort who consented to share them.
+type CohortOutcomes struct {
+	Students      int                       `json:"students"`
+	Consented     int                       `json:"consented"`
+	Applications  int                       `json:"applications"`
+	ByStatus      map[ApplicationStatus]int `json:"byStatus"`
+	Placed        int                       `json:"placed"`
+	PlacementRate float64                   `json:"placementRate"`
+	PerStudent    []StudentOutcome          `json:"perStudent"`
+}
+
+// SummarizeOutcomes counts applications by status for every consented student of cohort.
+// Applications of other students are ignored.
+func SummarizeOutcomes(cohort Cohort, applications []JobApplication) CohortOutcomes {
+	consented := cohort.Consented()
+	outcomes := CohortOutcomes{
+		Students:   len(cohort.Students),
+		Consented:  len(consented),
+		ByStatus:   map[ApplicationStatus]int{},
+		PerStudent: make([]StudentOutcome, 0, len(consented)),
+	}
+	index := make(map[primitive.ObjectID]int, len(consented))
+	for i, id := range consented {
+		index[id] = i
+		outcomes.PerStudent = append(outcomes.PerStudent, StudentOutcome{StudentID: id, ByStatus: map[ApplicationStatus]int{}})
+	}
+
+	for _, application := range applications {
+		i, ok := index[application.ApplicantID]
+		if !ok {
+			continue
+		}
+		student := &outcomes.PerStudent[i]
+		student.Applications++
+		student.ByStatus[application.Status]++
+		if application.Status == StatusAccepted {
+			student.Placed = true
+		}
+		outcomes.Applications++
+		outcomes.ByStatus[application.Status]++
+	}
+
+	for _, student := range outcomes.PerStudent {
+		if student.Placed {
+			outcomes.Placed++
+		}
+	}
+	if outcomes.Consented > 0 {
+		outcomes.PlacementRate = float64(outcomes.Placed) / float64(outcomes.Consented)
+	}
+	return outcomes
+}
@@ -0,0 +1,66 @@
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCohortConsented(t *testing.T) {
+	now := time.Now()
+	consenting, pending := primitive.NewObjectID(), primitive.NewObjectID()
+	cohort := Cohort{Students: []CohortStudent{
+		{UserID: consenting, ConsentedAt: &now},
+		{UserID: pending},
+	}}
+
+	assert.Equal(t, []primitive.ObjectID{consenting}, cohort.Consented())
+	student, ok := cohort.Student(pending)
+	assert.True(t, ok)
+	assert.Nil(t, student.ConsentedAt)
+	_, ok = cohort.Student(primitive.NewObjectID())
+	assert.False(t, ok)
+}
+
+func TestSummarizeOutcomes(t *testing.T) {
+	now := time.Now()
+	placed, searching, pending := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	cohort := Cohort{Students: []CohortStudent{
+		{UserID: placed, ConsentedAt: &now},
+		{UserID: searching, ConsentedAt: &now},
+		{UserID: pending},
+	}}
+	applications := []JobApplication{
+		{ApplicantID: placed, Status: StatusAccepted},
+		{ApplicantID: placed, Status: StatusRejected},
+		{ApplicantID: searching, Status: StatusInterview},
+		// students who have not consented are not counted
+		{ApplicantID: pending, Status: StatusAccepted},
+		{ApplicantID: primitive.NewObjectID(), Status: StatusPending},
+	}
+
+	outcomes := SummarizeOutcomes(cohort, applications)
+	assert.Equal(t, 3, outcomes.Students)
+	assert.Equal(t, 2, outcomes.Consented)
+	assert.Equal(t, 3, outcomes.Applications)
+	assert.Equal(t, map[ApplicationStatus]int{StatusAccepted: 1, StatusRejected: 1, StatusInterview: 1}, outcomes.ByStatus)
+	assert.Equal(t, 1, outcomes.Placed)
+	assert.InDelta(t, 0.5, outcomes.PlacementRate, 1e-9)
+
+	assert.Len(t, outcomes.PerStudent, 2)
+	assert.Equal(t, placed, outcomes.PerStudent[0].StudentID)
+	assert.True(t, outcomes.PerStudent[0].Placed)
+	assert.Equal(t, 2, outcomes.PerStudent[0].Applications)
+	assert.False(t, outcomes.PerStudent[1].Placed)
+}
+
+func TestSummarizeOutcomesWithoutConsent(t *testing.T) {
+	cohort := Cohort{Students: []CohortStudent{{UserID: primitive.NewObjectID()}}}
+
+	outcomes := SummarizeOutcomes(cohort, nil)
+	assert.Equal(t, 1, outcomes.Students)
+	assert.Zero(t, outcomes.PlacementRate)
+	assert.Empty(t, outcomes.PerStudent)
+}
@@ -18,7 +18,8 @@ const (
 type File struct {
 	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	UserID        primitive.ObjectID `bson:"userID" json:"userID" binding:"required"`
-	Content       []byte             `bson:"content" json:"-"` // Don't expose in JSON
+	Content       []byte             `bson:"content,omitempty" json:"-"`    // Legacy inline content, see migration.MoveFileContentToBlobStore
+	StorageKey    string             `bson:"storageKey,omitempty" json:"-"` // Key of the content in storage.BlobStore
 	FileExtension string             `bson:"fileExtension" json:"fileExtension" binding:"required"`
 	Filename      string             `bson:"filename" json:"filename" binding:"required"`
 	ContentType   string             `bson:"contentType" json:"contentType" binding:"required"`
@@ -23,13 +23,20 @@ type Job struct {
 	ExperienceLevel     string             `bson:"experienceLevel" json:"experienceLevel" binding:"required,min=1,max=200"`
 	Education           string             `bson:"education" json:"education" binding:"required,min=1,max=200"`
 	NiceToHave          string             `bson:"niceToHave" json:"niceToHave" binding:"omitempty,max=2000"`
-	Questions           string             `bson:"questions" json:"questions" binding:"omitempty,max=2000"`
+	Questions           ScreeningQuestions `bson:"questions" json:"questions" binding:"omitempty,max=50,dive"`
 	PostOpenDate        time.Time          `bson:"postOpenDate" json:"postOpenDate" binding:"required"`
 	ApplicationDeadline time.Time          `bson:"applicationDeadline" json:"applicationDeadline" binding:"required"`
 	NumberOfPositions   int                `bson:"numberOfPositions" json:"numberOfPositions" binding:"required,gte=1,lte=1000"`
 	Visibility          string             `bson:"visibility" json:"visibility" binding:"required,min=1,max=50"`
 	EmailNotifications  bool               `bson:"emailNotifications" json:"emailNotifications"`
 	AutoReject          bool               `bson:"autoReject" json:"autoReject"`
+	KnockoutRules       KnockoutRules      `bson:"knockoutRules,omitempty" json:"knockoutRules,omitempty" binding:"omitempty,max=20,dive"` // applied when AutoReject is on
+	RejectionEmail      RejectionEmail     `bson:"rejectionEmail" json:"rejectionEmail"`
+	Status              JobStatus          `bson:"status" json:"status"`
+	ClosedAt            *time.Time         `bson:"closedAt,omitempty" json:"closedAt,omitempty"`
+	ClosedReason        string             `bson:"closedReason,omitempty" json:"closedReason,omitempty"` // one of the Closed constants
+	ArchivedAt          *time.Time         `bson:"archivedAt,omitempty" json:"archivedAt,omitempty"`
+	SoftDelete          `bson:",inline"`
 }
 
 func (j Job) GetCollectionName() string {
@@ -46,7 +53,16 @@ func (j *Job) Validate() error {
 	if j.PostOpenDate.After(j.ApplicationDeadline) {
 		return fmt.Errorf("postOpenDate (%s) cannot be after applicationDeadline (%s)", j.PostOpenDate, j.ApplicationDeadline)
 	}
-	return nil
+	if err := j.initialStatus(time.Now()); err != nil {
+		return err
+	}
+	if err := j.Questions.Validate(); err != nil {
+		return err
+	}
+	if err := j.KnockoutRules.Validate(); err != nil {
+		return err
+	}
+	return j.KnockoutRules.ValidateAgainst(j.Questions)
 }
 
 func (j Job) ValidatePartial(fields map[string]any) error {
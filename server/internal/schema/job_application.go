@@ -1,17 +1,23 @@
 package schema
 
 import (
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type JobApplication struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	ApplicantID primitive.ObjectID `bson:"applicantID" json:"applicantID" binding:"required"`
-	JobID       primitive.ObjectID `bson:"jobID" json:"jobID" binding:"required"`
-	Status      string             `bson:"status" json:"status" binding:"required"`
-	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	ApplicantID   primitive.ObjectID  `bson:"applicantID" json:"applicantID" binding:"required"`
+	JobID         primitive.ObjectID  `bson:"jobID" json:"jobID" binding:"required"`
+	Status        ApplicationStatus   `bson:"status" json:"status" binding:"required"`
+	StatusHistory []StatusChange      `bson:"statusHistory" json:"statusHistory"`
+	ResumeID      *primitive.ObjectID `bson:"resumeID,omitempty" json:"resumeID,omitempty"` // the Resume version sent with the application
+	Answers       []ScreeningAnswer   `bson:"answers,omitempty" json:"answers,omitempty" binding:"omitempty,max=50,dive"`
+	Knockout      *KnockoutResult     `bson:"knockout,omitempty" json:"knockout,omitempty"` // outcome of the knockout rules, when the job auto-rejects
+	CreatedAt     time.Time           `bson:"createdAt" json:"createdAt"`
+	SoftDelete    `bson:",inline"`
 }
 
 type ApplicationWithApplicant struct {
@@ -22,3 +28,28 @@ type ApplicationWithApplicant struct {
 func (ja JobApplication) GetCollectionName() string {
 	return "job_applications"
 }
+
+// Validate is run before a new application is saved.
+// Every application starts as PENDING, and its history starts with that step.
+func (ja *JobApplication) Validate() error {
+	status, err := ParseApplicationStatus(string(ja.Status))
+	if err != nil {
+		return err
+	}
+	if status != StatusPending {
+		return fmt.Errorf("a new application must have status %s, got %s", StatusPending, ja.Status)
+	}
+	ja.Status = status
+
+	if ja.CreatedAt.IsZero() {
+		ja.CreatedAt = time.Now()
+	}
+	ja.StatusHistory = []StatusChange{{
+		To:        StatusPending,
+		ActorID:   ja.ApplicantID,
+		ActorRole: RoleJobSeeker,
+		ChangedAt: ja.CreatedAt,
+	}}
+	ja.Undelete()
+	return nil
+}
@@ -1,7 +1,68 @@
 package schema
 
+import "fmt"
+
+// JobSeekerInfo is the profile of a job seeker.
 type JobSeekerInfo struct {
-	Location string `bson:"location" json:"location" binding:"required,min=1,max=200"`
-	Phone    string `bson:"phone" json:"phone" binding:"required,min=8,max=20"`
-	LinkedIn string `bson:"linkedIn" json:"linkedIn" binding:"omitempty,url,max=200"`
+	FullName    string `bson:"fullName,omitempty" json:"fullName,omitempty" binding:"omitempty,max=200"`
+	Location    string `bson:"location" json:"location" binding:"required,min=1,max=200"`
+	Phone       string `bson:"phone" json:"phone" binding:"required,min=8,max=20"`
+	LinkedIn    string `bson:"linkedIn" json:"linkedIn" binding:"omitempty,url,max=200"`
+	DesiredRole string `bson:"desiredRole,omitempty" json:"desiredRole,omitempty" binding:"omitempty,max=200"`
+	AboutMe     string `bson:"aboutMe,omitempty" json:"aboutMe,omitempty" binding:"omitempty,max=5000"`
+	DateOfBirth string `bson:"dateOfBirth,omitempty" json:"dateOfBirth,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	Gender      string `bson:"gender,omitempty" json:"gender,omitempty" binding:"omitempty,max=50"`
+	Portfolio   string `bson:"portfolio,omitempty" json:"portfolio,omitempty" binding:"omitempty,url,max=200"`
+	GitHub      string `bson:"github,omitempty" json:"github,omitempty" binding:"omitempty,url,max=200"`
+
+	Skills            Skills           `bson:"skills,omitempty" json:"skills,omitempty" binding:"omitempty,max=100,dive,min=1,max=100"`
+	Education         []Education      `bson:"education,omitempty" json:"education,omitempty" binding:"omitempty,max=20,dive"`
+	WorkHistory       []WorkExperience `bson:"workHistory,omitempty" json:"workHistory,omitempty" binding:"omitempty,max=50,dive"`
+	ExpectedSalary    *ExpectedSalary  `bson:"expectedSalary,omitempty" json:"expectedSalary,omitempty"`
+	WorkAuthorization []string         `bson:"workAuthorization,omitempty" json:"workAuthorization,omitempty" binding:"omitempty,max=50,dive,len=2,alpha"` // countries (ISO 3166 alpha-2) they may work in
+}
+
+func (*JobSeekerInfo) ProfileRole() string {
+	return RoleJobSeeker
+}
+
+// Education is a degree a job seeker studied for. EndYear is empty while they still study.
+type Education struct {
+	Institution  string `bson:"institution" json:"institution" binding:"required,min=1,max=200"`
+	Degree       string `bson:"degree,omitempty" json:"degree,omitempty" binding:"omitempty,max=200"`
+	FieldOfStudy string `bson:"fieldOfStudy,omitempty" json:"fieldOfStudy,omitempty" binding:"omitempty,max=200"`
+	StartYear    int    `bson:"startYear,omitempty" json:"startYear,omitempty" binding:"omitempty,min=1900,max=2100"`
+	EndYear      int    `bson:"endYear,omitempty" json:"endYear,omitempty" binding:"omitempty,min=1900,max=2100,gtefield=StartYear"`
+	GPA          string `bson:"gpa,omitempty" json:"gpa,omitempty" binding:"omitempty,max=10"`
+}
+
+// WorkExperience is a job a job seeker held. Dates are months (2006-01), EndDate is empty for the current job.
+type WorkExperience struct {
+	Company     string `bson:"company" json:"company" binding:"required,min=1,max=200"`
+	Title       string `bson:"title" json:"title" binding:"required,min=1,max=200"`
+	StartDate   string `bson:"startDate" json:"startDate" binding:"required,datetime=2006-01"`
+	EndDate     string `bson:"endDate,omitempty" json:"endDate,omitempty" binding:"omitempty,datetime=2006-01"`
+	Description string `bson:"description,omitempty" json:"description,omitempty" binding:"omitempty,max=5000"`
+}
+
+// ExpectedSalary is the salary range a job seeker asks for. Max is empty when they name no upper bound.
+type ExpectedSalary struct {
+	Currency string  `bson:"currency" json:"currency" binding:"required,len=3"`
+	Min      float64 `bson:"min" json:"min" binding:"gte=0,lte=1000000000"`
+	Max      float64 `bson:"max,omitempty" json:"max,omitempty" binding:"omitempty,gtefield=Min,lte=1000000000"`
+}
+
+// Validate checks what binding rules cannot: that no job ended before it started.
+func (info *JobSeekerInfo) Validate() error {
+	return validateWorkHistory(info.WorkHistory)
+}
+
+func validateWorkHistory(history []WorkExperience) error {
+	for _, work := range history {
+		// months in 2006-01 format sort like the dates they are
+		if work.EndDate != "" && work.EndDate < work.StartDate {
+			return fmt.Errorf("work at %s ends (%s) before it starts (%s)", work.Company, work.EndDate, work.StartDate)
+		}
+	}
+	return nil
 }
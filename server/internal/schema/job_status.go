@@ -0,0 +1,90 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JobStatus is a step in the lifecycle of a job posting:
+// DRAFT -> SCHEDULED -> PUBLISHED -> CLOSED -> ARCHIVED.
+// Only published jobs are listed to job seekers and accept applications.
+type JobStatus string
+
+const (
+	JobDraft     JobStatus = "draft"
+	JobScheduled JobStatus = "scheduled"
+	JobPublished JobStatus = "published"
+	JobClosed    JobStatus = "closed"
+	JobArchived  JobStatus = "archived"
+)
+
+// Why a job was closed.
+const (
+	ClosedAtDeadline = "deadline"
+	ClosedWhenFilled = "filled"
+	ClosedByCompany  = "company"
+)
+
+// jobTransitions lists the statuses the company may move a job in each status to.
+// Publishing a job which does not open yet schedules it instead.
+// Statuses without an entry are final.
+var jobTransitions = map[JobStatus][]JobStatus{
+	JobDraft:     {JobPublished, JobArchived},
+	JobScheduled: {JobDraft, JobPublished},
+	JobPublished: {JobClosed},
+	JobClosed:    {JobPublished, JobArchived},
+}
+
+// ParseJobStatus parses a status case-insensitively.
+func ParseJobStatus(s string) (JobStatus, error) {
+	status := JobStatus(strings.ToLower(strings.TrimSpace(s)))
+	switch status {
+	case JobDraft, JobScheduled, JobPublished, JobClosed, JobArchived:
+		return status, nil
+	}
+	return "", fmt.Errorf("unknown job status: %s", s)
+}
+
+// CanTransitionTo reports whether the company may move a job from s to next.
+func (s JobStatus) CanTransitionTo(next JobStatus) bool {
+	for _, allowed := range jobTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPublic reports whether anyone may see a job in status s, not only the company which posts it.
+// Closed and archived jobs stay visible to the people who applied to them.
+func (s JobStatus) IsPublic() bool {
+	return s != JobDraft && s != JobScheduled
+}
+
+// PublishedStatus is the status of j once it is published at now: scheduled until it opens.
+func (j Job) PublishedStatus(now time.Time) JobStatus {
+	if j.PostOpenDate.After(now) {
+		return JobScheduled
+	}
+	return JobPublished
+}
+
+// AcceptsApplications reports whether j takes new applications at now.
+// The deadline is checked as well, as jobs are only closed once in a while.
+func (j Job) AcceptsApplications(now time.Time) bool {
+	return j.Status == JobPublished && !now.Before(j.PostOpenDate) && !now.After(j.ApplicationDeadline)
+}
+
+// initialStatus checks the status a new job is sent with. Jobs are published unless they are sent as drafts.
+func (j *Job) initialStatus(now time.Time) error {
+	switch j.Status {
+	case JobDraft:
+	case "", JobScheduled, JobPublished:
+		j.Status = j.PublishedStatus(now)
+	default:
+		return fmt.Errorf("a new job must be a %s or %s, got %s", JobDraft, JobPublished, j.Status)
+	}
+	j.ClosedAt, j.ClosedReason, j.ArchivedAt = nil, "", nil
+	return nil
+}
@@ -0,0 +1,74 @@
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJobStatus(t *testing.T) {
+	status, err := ParseJobStatus(" Published ")
+	assert.NoError(t, err)
+	assert.Equal(t, JobPublished, status)
+
+	_, err = ParseJobStatus("live")
+	assert.Error(t, err)
+}
+
+func TestJobTransitions(t *testing.T) {
+	assert.True(t, JobDraft.CanTransitionTo(JobPublished))
+	assert.True(t, JobScheduled.CanTransitionTo(JobDraft))
+	assert.True(t, JobPublished.CanTransitionTo(JobClosed))
+	assert.True(t, JobClosed.CanTransitionTo(JobPublished), "closed jobs can be reopened")
+	assert.True(t, JobClosed.CanTransitionTo(JobArchived))
+
+	assert.False(t, JobPublished.CanTransitionTo(JobDraft))
+	assert.False(t, JobPublished.CanTransitionTo(JobArchived), "jobs are closed before they are archived")
+	assert.False(t, JobArchived.CanTransitionTo(JobPublished))
+}
+
+func TestNewJobStatus(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		sent     JobStatus
+		opens    time.Time
+		expected JobStatus
+	}{
+		{"", now.Add(-time.Hour), JobPublished},
+		{"", now.Add(time.Hour), JobScheduled},
+		{JobPublished, now.Add(time.Hour), JobScheduled},
+		{JobScheduled, now.Add(-time.Hour), JobPublished},
+		{JobDraft, now.Add(-time.Hour), JobDraft},
+	}
+	for _, c := range cases {
+		closedAt := now
+		job := Job{Status: c.sent, PostOpenDate: c.opens, ApplicationDeadline: now.Add(24 * time.Hour), ClosedAt: &closedAt}
+		assert.NoError(t, job.Validate())
+		assert.Equal(t, c.expected, job.Status, "sent %q", c.sent)
+		assert.Nil(t, job.ClosedAt)
+	}
+
+	job := Job{Status: JobClosed, PostOpenDate: now, ApplicationDeadline: now}
+	assert.Error(t, job.Validate(), "new jobs cannot be closed")
+}
+
+func TestJobAcceptsApplications(t *testing.T) {
+	now := time.Now()
+	job := Job{Status: JobPublished, PostOpenDate: now.Add(-time.Hour), ApplicationDeadline: now.Add(time.Hour)}
+	assert.True(t, job.AcceptsApplications(now))
+	assert.False(t, job.AcceptsApplications(now.Add(2*time.Hour)), "past the deadline, even before the job is closed")
+
+	job.Status = JobDraft
+	assert.False(t, job.AcceptsApplications(now))
+	job.Status = JobClosed
+	assert.False(t, job.AcceptsApplications(now))
+}
+
+func TestJobStatusIsPublic(t *testing.T) {
+	assert.False(t, JobDraft.IsPublic())
+	assert.False(t, JobScheduled.IsPublic())
+	assert.True(t, JobPublished.IsPublic())
+	assert.True(t, JobClosed.IsPublic())
+	assert.True(t, JobArchived.IsPublic())
+}
@@ -0,0 +1,389 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// KnockoutRuleType is what a knockout rule checks.
+type KnockoutRuleType string
+
+const (
+	// RuleAnswer requires an accepted answer to a screening question:
+	// one of Values for yesNo ("yes" or "no"), choice and text questions, at least Min for number questions.
+	RuleAnswer KnockoutRuleType = "answer"
+	// RuleMinExperience requires at least Min years of work experience.
+	RuleMinExperience KnockoutRuleType = "minExperience"
+	// RuleLocation requires the applicant to live in one of Values.
+	RuleLocation KnockoutRuleType = "location"
+	// RuleWorkAuthorization requires the applicant to be allowed to work in one of the countries in Values.
+	RuleWorkAuthorization KnockoutRuleType = "workAuthorization"
+	// RuleResume requires the application to be sent with a resume.
+	RuleResume KnockoutRuleType = "resume"
+)
+
+// Machine readable reasons of failed knockout checks.
+const (
+	ReasonMissingAnswer     = "missing_answer"
+	ReasonAnswerNotAccepted = "answer_not_accepted"
+	ReasonNotEnoughYears    = "not_enough_experience"
+	ReasonLocation          = "location_not_accepted"
+	ReasonNotAuthorized     = "not_authorized_to_work"
+	ReasonMissingResume     = "missing_resume"
+	// ReasonQuestionRemoved is the reason a rule on a question the job no longer has was skipped.
+	ReasonQuestionRemoved = "question_removed"
+)
+
+// ActorSystem is the ActorRole of status changes nobody made by hand, such as auto-rejections.
+const ActorSystem = "system"
+
+// KnockoutRule is a requirement of a job. When the job has AutoReject on,
+// applications which fail any of its rules are rejected as soon as they are sent.
+type KnockoutRule struct {
+	Type KnockoutRuleType `bson:"type" json:"type" binding:"required,oneof=answer minExperience location workAuthorization resume"`
+	// QuestionID is the screening question of answer rules.
+	QuestionID string `bson:"questionID,omitempty" json:"questionID,omitempty" binding:"omitempty,max=50"`
+	// Values are the accepted answers, locations or countries (ISO 3166 alpha-2 codes).
+	Values []string `bson:"values,omitempty" json:"values,omitempty" binding:"omitempty,max=50,dive,min=1,max=200"`
+	// Min is the lowest accepted number answer, or years of experience.
+	Min *float64 `bson:"min,omitempty" json:"min,omitempty"`
+}
+
+// KnockoutRules are the knockout rules of a job.
+type KnockoutRules []KnockoutRule
+
+// Validate checks what binding rules cannot.
+func (rules KnockoutRules) Validate() error {
+	if len(rules) > 20 {
+		return errors.New("a job can have at most 20 knockout rules")
+	}
+	for i := range rules {
+		if err := rules[i].validate(); err != nil {
+			return fmt.Errorf("knockout rule %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// ValidateAgainst checks that every answer rule is about one of questions, and accepts possible answers to it.
+func (rules KnockoutRules) ValidateAgainst(questions ScreeningQuestions) error {
+	for i, rule := range rules {
+		if rule.Type != RuleAnswer {
+			continue
+		}
+		q, ok := questions.find(rule.QuestionID)
+		if !ok {
+			return fmt.Errorf("knockout rule %d: question %s is not a question of this job", i+1, rule.QuestionID)
+		}
+		if err := rule.validateFor(q); err != nil {
+			return fmt.Errorf("knockout rule %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (r *KnockoutRule) validate() error {
+	switch r.Type {
+	case RuleAnswer:
+		if r.QuestionID == "" {
+			return errors.New("answer rules need a questionID")
+		}
+		if len(r.Values) == 0 && r.Min == nil {
+			return errors.New("answer rules need the accepted values or a min")
+		}
+	case RuleMinExperience:
+		if r.Min == nil || *r.Min <= 0 || *r.Min > 50 || math.IsNaN(*r.Min) {
+			return errors.New("minExperience rules need a min between 0 and 50 years")
+		}
+	case RuleLocation:
+		if len(r.Values) == 0 {
+			return errors.New("location rules need the accepted locations")
+		}
+	case RuleWorkAuthorization:
+		if len(r.Values) == 0 {
+			return errors.New("workAuthorization rules need the accepted countries")
+		}
+		for i, country := range r.Values {
+			if len(country) != 2 {
+				return fmt.Errorf("%q is not a two letter country code", country)
+			}
+			r.Values[i] = strings.ToUpper(country)
+		}
+	case RuleResume:
+	default:
+		return fmt.Errorf("unknown knockout rule type %q", r.Type)
+	}
+
+	if r.Type != RuleAnswer {
+		r.QuestionID = ""
+	}
+	if r.Type == RuleMinExperience || r.Type == RuleResume {
+		r.Values = nil
+	}
+	if r.Type != RuleAnswer && r.Type != RuleMinExperience {
+		r.Min = nil
+	}
+	return nil
+}
+
+// validateFor checks that the answer rule r can be met by an answer to q.
+func (r KnockoutRule) validateFor(q ScreeningQuestion) error {
+	switch q.Type {
+	case QuestionNumber:
+		if r.Min == nil {
+			return fmt.Errorf("rules on the number question %q need a min", q.Prompt)
+		}
+	case QuestionYesNo:
+		for _, value := range r.Values {
+			if value != "yes" && value != "no" {
+				return fmt.Errorf("answers to %q are yes or no, not %q", q.Prompt, value)
+			}
+		}
+	case QuestionSingleChoice, QuestionMultiChoice:
+		for _, value := range r.Values {
+			if !slices.Contains(q.Options, value) {
+				return fmt.Errorf("%q is not an option of %q", value, q.Prompt)
+			}
+		}
+	}
+	if q.Type != QuestionNumber && len(r.Values) == 0 {
+		return fmt.Errorf("rules on %q need the accepted answers", q.Prompt)
+	}
+	return nil
+}
+
+func (qs ScreeningQuestions) find(id string) (ScreeningQuestion, bool) {
+	i := slices.IndexFunc(qs, func(q ScreeningQuestion) bool { return q.ID == id })
+	if i < 0 {
+		return ScreeningQuestion{}, false
+	}
+	return qs[i], true
+}
+
+// KnockoutCheck is the outcome of one knockout rule for an application.
+type KnockoutCheck struct {
+	Rule   KnockoutRule `bson:"rule" json:"rule"`
+	Passed bool         `bson:"passed" json:"passed"`
+	// Reason is one of the Reason constants, set when the check failed or was skipped.
+	Reason string `bson:"reason,omitempty" json:"reason,omitempty"`
+	// Detail explains the outcome to the company.
+	Detail string `bson:"detail" json:"detail"`
+}
+
+// KnockoutResult explains how an application fared against the knockout rules of its job.
+type KnockoutResult struct {
+	Passed      bool            `bson:"passed" json:"passed"`
+	Checks      []KnockoutCheck `bson:"checks" json:"checks"`
+	EvaluatedAt time.Time       `bson:"evaluatedAt" json:"evaluatedAt"`
+}
+
+// Reasons returns the reasons of the failed checks.
+func (r KnockoutResult) Reasons() []string {
+	var reasons []string
+	for _, check := range r.Checks {
+		if !check.Passed && !slices.Contains(reasons, check.Reason) {
+			reasons = append(reasons, check.Reason)
+		}
+	}
+	return reasons
+}
+
+// Applicant is what knockout rules know about the sender of an application:
+// their profile, if they have one, and the resume sent with the application, if any.
+type Applicant struct {
+	Profile *JobSeekerInfo
+	Resume  *Resume
+}
+
+// EvaluateKnockout checks app, which must have its answers checked by the questions of job, against the knockout rules of job.
+func EvaluateKnockout(job Job, app JobApplication, applicant Applicant, now time.Time) KnockoutResult {
+	result := KnockoutResult{Passed: true, Checks: []KnockoutCheck{}, EvaluatedAt: now}
+	for _, rule := range job.KnockoutRules {
+		check := KnockoutCheck{Rule: rule}
+		check.Passed, check.Reason, check.Detail = rule.evaluate(job.Questions, app, applicant, now)
+		result.Passed = result.Passed && check.Passed
+		result.Checks = append(result.Checks, check)
+	}
+	return result
+}
+
+func (r KnockoutRule) evaluate(questions ScreeningQuestions, app JobApplication, applicant Applicant, now time.Time) (bool, string, string) {
+	switch r.Type {
+	case RuleAnswer:
+		q, ok := questions.find(r.QuestionID)
+		if !ok {
+			// the question was removed after the rule was written, so nobody could meet it
+			return true, ReasonQuestionRemoved, "skipped: the question of this rule was removed from the job"
+		}
+		i := slices.IndexFunc(app.Answers, func(a ScreeningAnswer) bool { return a.QuestionID == q.ID })
+		if i < 0 {
+			return false, ReasonMissingAnswer, fmt.Sprintf("did not answer %q", q.Prompt)
+		}
+		answer := app.Answers[i]
+		if r.accepts(q.Type, answer) {
+			return true, "", fmt.Sprintf("answered %q with %s", q.Prompt, describeAnswer(answer))
+		}
+		return false, ReasonAnswerNotAccepted, fmt.Sprintf("answered %q with %s, accepted: %s", q.Prompt, describeAnswer(answer), r.describeAccepted())
+
+	case RuleMinExperience:
+		var history []WorkExperience
+		if applicant.Resume != nil {
+			history = applicant.Resume.Experience
+		} else if applicant.Profile != nil {
+			history = applicant.Profile.WorkHistory
+		}
+		years := YearsOfExperience(history, now)
+		if years >= *r.Min {
+			return true, "", fmt.Sprintf("has %.1f years of experience, at least %g required", years, *r.Min)
+		}
+		return false, ReasonNotEnoughYears, fmt.Sprintf("has %.1f years of experience, at least %g required", years, *r.Min)
+
+	case RuleLocation:
+		location := ""
+		if applicant.Profile != nil {
+			location = applicant.Profile.Location
+		}
+		for _, accepted := range r.Values {
+			if location != "" && strings.Contains(strings.ToLower(location), strings.ToLower(accepted)) {
+				return true, "", fmt.Sprintf("lives in %s", location)
+			}
+		}
+		if location == "" {
+			return false, ReasonLocation, "has no location in their profile, accepted: " + strings.Join(r.Values, ", ")
+		}
+		return false, ReasonLocation, fmt.Sprintf("lives in %s, accepted: %s", location, strings.Join(r.Values, ", "))
+
+	case RuleWorkAuthorization:
+		var countries []string
+		if applicant.Profile != nil {
+			countries = applicant.Profile.WorkAuthorization
+		}
+		for _, country := range countries {
+			if slices.Contains(r.Values, strings.ToUpper(country)) {
+				return true, "", "may work in " + strings.ToUpper(country)
+			}
+		}
+		return false, ReasonNotAuthorized, "may not work in " + strings.Join(r.Values, ", ")
+
+	case RuleResume:
+		if app.ResumeID != nil {
+			return true, "", "sent a resume"
+		}
+		return false, ReasonMissingResume, "sent no resume"
+	}
+	return true, "", ""
+}
+
+// accepts reports whether answer, to a question of type t, meets the answer rule r.
+func (r KnockoutRule) accepts(t QuestionType, answer ScreeningAnswer) bool {
+	switch t {
+	case QuestionNumber:
+		return answer.Number != nil && (r.Min == nil || *answer.Number >= *r.Min)
+	case QuestionYesNo:
+		if answer.Yes == nil {
+			return false
+		}
+		return slices.Contains(r.Values, map[bool]string{true: "yes", false: "no"}[*answer.Yes])
+	case QuestionSingleChoice, QuestionMultiChoice:
+		return slices.ContainsFunc(answer.Choices, func(choice string) bool { return slices.Contains(r.Values, choice) })
+	}
+	return slices.ContainsFunc(r.Values, func(value string) bool {
+		return strings.EqualFold(strings.TrimSpace(value), answer.Text)
+	})
+}
+
+func (r KnockoutRule) describeAccepted() string {
+	if r.Min != nil {
+		return fmt.Sprintf("at least %g", *r.Min)
+	}
+	return strings.Join(r.Values, ", ")
+}
+
+func describeAnswer(answer ScreeningAnswer) string {
+	switch {
+	case answer.Number != nil:
+		return fmt.Sprintf("%g", *answer.Number)
+	case answer.Yes != nil && *answer.Yes:
+		return "yes"
+	case answer.Yes != nil:
+		return "no"
+	case len(answer.Choices) > 0:
+		return strings.Join(answer.Choices, ", ")
+	}
+	return fmt.Sprintf("%q", answer.Text)
+}
+
+// YearsOfExperience returns the years covered by history, counting overlapping jobs once.
+// Jobs without an end date last until now.
+func YearsOfExperience(history []WorkExperience, now time.Time) float64 {
+	type span struct{ start, end time.Time }
+	var spans []span
+	for _, work := range history {
+		start, err := time.Parse("2006-01", work.StartDate)
+		if err != nil {
+			continue
+		}
+		end := now
+		if work.EndDate != "" {
+			if end, err = time.Parse("2006-01", work.EndDate); err != nil {
+				continue
+			}
+			// an end month counts as worked
+			end = end.AddDate(0, 1, 0)
+		}
+		if end.After(now) {
+			end = now
+		}
+		if end.After(start) {
+			spans = append(spans, span{start, end})
+		}
+	}
+	slices.SortFunc(spans, func(a, b span) int { return a.start.Compare(b.start) })
+
+	var total time.Duration
+	var current span
+	for i, s := range spans {
+		if i > 0 && !s.start.After(current.end) {
+			if s.end.After(current.end) {
+				current.end = s.end
+			}
+			continue
+		}
+		total += current.end.Sub(current.start)
+		current = s
+	}
+	total += current.end.Sub(current.start)
+	return total.Hours() / 24 / 365.25
+}
+
+// Reject moves a new application to REJECTED because it failed result, recording the failed reasons.
+func (ja *JobApplication) Reject(result KnockoutResult) {
+	var details []string
+	for _, check := range result.Checks {
+		if !check.Passed {
+			details = append(details, check.Detail)
+		}
+	}
+	ja.StatusHistory = append(ja.StatusHistory, StatusChange{
+		From:       ja.Status,
+		To:         StatusRejected,
+		ActorRole:  ActorSystem,
+		Reason:     "auto-rejected: " + strings.Join(details, "; "),
+		ReasonCode: strings.Join(result.Reasons(), ","),
+		ChangedAt:  result.EvaluatedAt,
+	})
+	ja.Status = StatusRejected
+}
+
+// RejectionEmail is the email sent to applicants a job auto-rejects.
+type RejectionEmail struct {
+	Send bool `bson:"send" json:"send"`
+	// DelayHours postpones the email, so that it does not arrive right after applying.
+	DelayHours int `bson:"delayHours" json:"delayHours" binding:"gte=0,lte=336"`
+	// Message is an optional note of the company added to the email.
+	Message string `bson:"message,omitempty" json:"message,omitempty" binding:"omitempty,max=2000"`
+}
@@ -0,0 +1,149 @@
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var evaluatedAt = time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+
+func knockoutJob(rules ...KnockoutRule) Job {
+	return Job{Questions: testQuestions(), AutoReject: true, KnockoutRules: rules}
+}
+
+func TestKnockoutRulesValidate(t *testing.T) {
+	rules := KnockoutRules{
+		{Type: RuleAnswer, QuestionID: "visa", Values: []string{"no"}},
+		{Type: RuleAnswer, QuestionID: "years", Min: ptr(2.0)},
+		{Type: RuleMinExperience, Min: ptr(1.5), Values: []string{"ignored"}},
+		{Type: RuleWorkAuthorization, Values: []string{"th"}},
+		{Type: RuleResume},
+	}
+	assert.NoError(t, rules.Validate())
+	assert.NoError(t, rules.ValidateAgainst(testQuestions()))
+	assert.Nil(t, rules[2].Values, "values are dropped from rules which do not use them")
+	assert.Equal(t, []string{"TH"}, rules[3].Values)
+}
+
+func TestKnockoutRulesValidateInvalid(t *testing.T) {
+	cases := map[string]KnockoutRule{
+		"answer without question": {Type: RuleAnswer, Values: []string{"yes"}},
+		"answer without values":   {Type: RuleAnswer, QuestionID: "visa"},
+		"unknown question":        {Type: RuleAnswer, QuestionID: "other", Values: []string{"yes"}},
+		"yes or no":               {Type: RuleAnswer, QuestionID: "visa", Values: []string{"maybe"}},
+		"not an option":           {Type: RuleAnswer, QuestionID: "shift", Values: []string{"evening"}},
+		"number without min":      {Type: RuleAnswer, QuestionID: "years", Values: []string{"3"}},
+		"experience without min":  {Type: RuleMinExperience},
+		"location without values": {Type: RuleLocation},
+		"country is not a code":   {Type: RuleWorkAuthorization, Values: []string{"Thailand"}},
+		"unknown type":            {Type: "age"},
+	}
+	for name, rule := range cases {
+		t.Run(name, func(t *testing.T) {
+			rules := KnockoutRules{rule}
+			err := rules.Validate()
+			if err == nil {
+				err = rules.ValidateAgainst(testQuestions())
+			}
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEvaluateKnockout(t *testing.T) {
+	resumeID := primitive.NewObjectID()
+	answers, err := testQuestions().Answer(validAnswers())
+	assert.NoError(t, err)
+	app := JobApplication{Answers: answers, ResumeID: &resumeID}
+	applicant := Applicant{Profile: &JobSeekerInfo{
+		Location:          "Bangkok, Thailand",
+		WorkAuthorization: []string{"th"},
+		WorkHistory:       []WorkExperience{{Company: "Acme", Title: "Dev", StartDate: "2020-01", EndDate: "2022-12"}},
+	}}
+	job := knockoutJob(
+		KnockoutRule{Type: RuleAnswer, QuestionID: "visa", Values: []string{"no"}},
+		KnockoutRule{Type: RuleAnswer, QuestionID: "shift", Values: []string{"day", "night"}},
+		KnockoutRule{Type: RuleAnswer, QuestionID: "years", Min: ptr(3.0)},
+		KnockoutRule{Type: RuleMinExperience, Min: ptr(2.0)},
+		KnockoutRule{Type: RuleLocation, Values: []string{"bangkok", "Chiang Mai"}},
+		KnockoutRule{Type: RuleWorkAuthorization, Values: []string{"TH"}},
+		KnockoutRule{Type: RuleResume},
+	)
+
+	result := EvaluateKnockout(job, app, applicant, evaluatedAt)
+	assert.True(t, result.Passed)
+	assert.Len(t, result.Checks, 7)
+	assert.Empty(t, result.Reasons())
+	assert.Equal(t, `answered "Need a visa?" with no`, result.Checks[0].Detail)
+	assert.Equal(t, "has 3.0 years of experience, at least 2 required", result.Checks[3].Detail)
+}
+
+func TestEvaluateKnockoutFailures(t *testing.T) {
+	app := JobApplication{Answers: []ScreeningAnswer{{QuestionID: "visa", Type: QuestionYesNo, Yes: ptr(true)}}}
+	job := knockoutJob(
+		KnockoutRule{Type: RuleAnswer, QuestionID: "visa", Values: []string{"no"}},
+		KnockoutRule{Type: RuleAnswer, QuestionID: "years", Min: ptr(3.0)},
+		KnockoutRule{Type: RuleMinExperience, Min: ptr(1.0)},
+		KnockoutRule{Type: RuleLocation, Values: []string{"Bangkok"}},
+		KnockoutRule{Type: RuleWorkAuthorization, Values: []string{"TH"}},
+		KnockoutRule{Type: RuleResume},
+	)
+
+	// an applicant without a profile fails everything about them
+	result := EvaluateKnockout(job, app, Applicant{}, evaluatedAt)
+	assert.False(t, result.Passed)
+	assert.Equal(t, []string{
+		ReasonAnswerNotAccepted, ReasonMissingAnswer, ReasonNotEnoughYears,
+		ReasonLocation, ReasonNotAuthorized, ReasonMissingResume,
+	}, result.Reasons())
+	assert.Equal(t, `answered "Need a visa?" with yes, accepted: no`, result.Checks[0].Detail)
+	assert.Equal(t, "has no location in their profile, accepted: Bangkok", result.Checks[3].Detail)
+}
+
+func TestEvaluateKnockoutUsesResumeExperience(t *testing.T) {
+	job := knockoutJob(KnockoutRule{Type: RuleMinExperience, Min: ptr(1.0)})
+	applicant := Applicant{
+		Profile: &JobSeekerInfo{},
+		Resume:  &Resume{Experience: []WorkExperience{{Company: "Acme", Title: "Dev", StartDate: "2024-06"}}},
+	}
+	assert.True(t, EvaluateKnockout(job, JobApplication{}, applicant, evaluatedAt).Passed)
+}
+
+func TestEvaluateKnockoutSkipsRemovedQuestions(t *testing.T) {
+	job := knockoutJob(KnockoutRule{Type: RuleAnswer, QuestionID: "removed", Values: []string{"yes"}})
+	result := EvaluateKnockout(job, JobApplication{}, Applicant{}, evaluatedAt)
+	assert.True(t, result.Passed)
+	assert.Equal(t, ReasonQuestionRemoved, result.Checks[0].Reason)
+}
+
+func TestYearsOfExperience(t *testing.T) {
+	history := []WorkExperience{
+		{StartDate: "2020-01", EndDate: "2020-12"},
+		// overlaps the first job, counted once
+		{StartDate: "2020-07", EndDate: "2021-06"},
+		{StartDate: "2025-01"},
+		{StartDate: "not a month"},
+	}
+	assert.InDelta(t, 1.5+1.04, YearsOfExperience(history, evaluatedAt), 0.01)
+	assert.Zero(t, YearsOfExperience(nil, evaluatedAt))
+}
+
+func TestReject(t *testing.T) {
+	app := JobApplication{ApplicantID: primitive.NewObjectID(), Status: StatusPending}
+	assert.NoError(t, app.Validate())
+
+	result := EvaluateKnockout(knockoutJob(KnockoutRule{Type: RuleResume}), app, Applicant{}, evaluatedAt)
+	app.Reject(result)
+	assert.Equal(t, StatusRejected, app.Status)
+	if assert.Len(t, app.StatusHistory, 2) {
+		change := app.StatusHistory[1]
+		assert.Equal(t, StatusPending, change.From)
+		assert.Equal(t, ActorSystem, change.ActorRole)
+		assert.Equal(t, ReasonMissingResume, change.ReasonCode)
+		assert.Equal(t, "auto-rejected: sent no resume", change.Reason)
+		assert.Equal(t, evaluatedAt, change.ChangedAt)
+	}
+}
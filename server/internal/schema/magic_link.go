@@ -0,0 +1,26 @@
+package schema
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MagicLink is a request for a login link sent by email.
+// Requests for unknown emails are kept as well, so that they count towards the rate limit.
+// It is removed by a TTL index once the link has expired.
+type MagicLink struct {
+	// ID is the jti of the link's token.
+	ID        string              `bson:"_id" json:"id"`
+	Email     string              `bson:"email" json:"email"`
+	UserID    *primitive.ObjectID `bson:"userID,omitempty" json:"userID,omitempty"`
+	IP        string              `bson:"ip" json:"ip"`
+	CreatedAt time.Time           `bson:"createdAt" json:"createdAt"`
+	ExpiresAt time.Time           `bson:"expiresAt" json:"expiresAt"`
+	// UsedAt is set once the link logged someone in, it cannot be used again.
+	UsedAt *time.Time `bson:"usedAt,omitempty" json:"usedAt,omitempty"`
+}
+
+func (m MagicLink) GetCollectionName() string {
+	return "magic_links"
+}
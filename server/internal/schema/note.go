@@ -11,6 +11,7 @@ type Note struct {
 	JobApplicationID primitive.ObjectID `bson:"jobApplicationID" json:"jobApplicationID" binding:"required"`
 	Content          string             `bson:"content" json:"content" binding:"required"`
 	Timestamp        time.Time          `bson:"timestamp" json:"timestamp" binding:"required"`
+	SoftDelete       `bson:",inline"`
 }
 
 func (n Note) GetCollectionName() string {
@@ -0,0 +1,115 @@
+package schema
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OrganizationRole is the sub-role of a member of an organization.
+// Owners manage the members, recruiters manage jobs and applicants, viewers can only look.
+type OrganizationRole string
+
+const (
+	OrgOwner     OrganizationRole = "owner"
+	OrgRecruiter OrganizationRole = "recruiter"
+	OrgViewer    OrganizationRole = "viewer"
+)
+
+// orgRoleRank orders the sub-roles, a higher one may do everything a lower one may.
+var orgRoleRank = map[OrganizationRole]int{
+	OrgViewer:    1,
+	OrgRecruiter: 2,
+	OrgOwner:     3,
+}
+
+// ParseOrganizationRole checks that s is a sub-role.
+func ParseOrganizationRole(s string) (OrganizationRole, error) {
+	role := OrganizationRole(s)
+	if orgRoleRank[role] == 0 {
+		return "", fmt.Errorf("unknown organization role: %s", s)
+	}
+	return role, nil
+}
+
+// AtLeast reports whether r may do everything min may.
+func (r OrganizationRole) AtLeast(min OrganizationRole) bool {
+	return orgRoleRank[r] >= orgRoleRank[min] && orgRoleRank[r] > 0
+}
+
+// Organization is a company which posts jobs, with the company accounts working for it.
+// Job.CompanyID is the ID of its organization.
+//
+// An organization has the ID of the company account which founded it, so that
+// its public profile is that account's, and jobs posted before organizations existed belong to it.
+// A company account without an organization is treated as the only owner of one, see PersonalOrganization.
+type Organization struct {
+	ID          primitive.ObjectID       `bson:"_id" json:"id"`
+	Name        string                   `bson:"name" json:"name"`
+	Members     []OrganizationMember     `bson:"members" json:"members"`
+	Invitations []OrganizationInvitation `bson:"invitations" json:"invitations"`
+	CreatedAt   time.Time                `bson:"createdAt" json:"createdAt"`
+}
+
+// OrganizationMember is a company account working for an organization.
+type OrganizationMember struct {
+	UserID   primitive.ObjectID `bson:"userID" json:"userID"`
+	Role     OrganizationRole   `bson:"role" json:"role"`
+	JoinedAt time.Time          `bson:"joinedAt" json:"joinedAt"`
+}
+
+// OrganizationInvitation invites the user with Email to join an organization.
+type OrganizationInvitation struct {
+	ID    primitive.ObjectID `bson:"id" json:"id"`
+	Email string             `bson:"email" json:"email"`
+	Role  OrganizationRole   `bson:"role" json:"role"`
+	// TokenHash is the SHA-256 of the token sent to Email, which accepts the invitation.
+	TokenHash string             `bson:"tokenHash" json:"-"`
+	InvitedBy primitive.ObjectID `bson:"invitedBy" json:"invitedBy"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	ExpiresAt time.Time          `bson:"expiresAt" json:"expiresAt"`
+}
+
+func (o Organization) GetCollectionName() string {
+	return "organizations"
+}
+
+// PersonalOrganization is the organization of a company account which has not founded one yet:
+// the account is its only member.
+func PersonalOrganization(user User) Organization {
+	return Organization{
+		ID:          user.ID,
+		Name:        user.Name,
+		Members:     []OrganizationMember{{UserID: user.ID, Role: OrgOwner, JoinedAt: user.CreatedAt}},
+		Invitations: []OrganizationInvitation{},
+		CreatedAt:   user.CreatedAt,
+	}
+}
+
+// RoleOf returns the sub-role of the member with userID.
+func (o Organization) RoleOf(userID primitive.ObjectID) (OrganizationRole, bool) {
+	for _, member := range o.Members {
+		if member.UserID == userID {
+			return member.Role, true
+		}
+	}
+	return "", false
+}
+
+// HasRole reports whether the user with userID is a member with at least sub-role min.
+func (o Organization) HasRole(userID primitive.ObjectID, min OrganizationRole) bool {
+	role, ok := o.RoleOf(userID)
+	return ok && role.AtLeast(min)
+}
+
+// Owners counts the members who are owners.
+func (o Organization) Owners() int {
+	owners := 0
+	for _, member := range o.Members {
+		if member.Role == OrgOwner {
+			owners++
+		}
+	}
+	return owners
+}
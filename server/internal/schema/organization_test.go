@@ -0,0 +1,55 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseOrganizationRole(t *testing.T) {
+	role, err := ParseOrganizationRole("recruiter")
+	assert.NoError(t, err)
+	assert.Equal(t, OrgRecruiter, role)
+
+	_, err = ParseOrganizationRole("admin")
+	assert.Error(t, err)
+	_, err = ParseOrganizationRole("")
+	assert.Error(t, err)
+}
+
+func TestOrganizationRoleAtLeast(t *testing.T) {
+	assert.True(t, OrgOwner.AtLeast(OrgRecruiter))
+	assert.True(t, OrgRecruiter.AtLeast(OrgRecruiter))
+	assert.False(t, OrgViewer.AtLeast(OrgRecruiter))
+	// an unknown sub-role may do nothing
+	assert.False(t, OrganizationRole("intern").AtLeast(OrganizationRole("")))
+}
+
+func TestOrganizationMembers(t *testing.T) {
+	owner, viewer := primitive.NewObjectID(), primitive.NewObjectID()
+	org := Organization{Members: []OrganizationMember{
+		{UserID: owner, Role: OrgOwner},
+		{UserID: viewer, Role: OrgViewer},
+	}}
+
+	role, ok := org.RoleOf(viewer)
+	assert.True(t, ok)
+	assert.Equal(t, OrgViewer, role)
+	_, ok = org.RoleOf(primitive.NewObjectID())
+	assert.False(t, ok)
+
+	assert.True(t, org.HasRole(owner, OrgRecruiter))
+	assert.False(t, org.HasRole(viewer, OrgRecruiter))
+	assert.Equal(t, 1, org.Owners())
+}
+
+func TestPersonalOrganization(t *testing.T) {
+	user := User{ID: primitive.NewObjectID(), Name: "Acme", Role: RoleCompany}
+	org := PersonalOrganization(user)
+
+	assert.Equal(t, user.ID, org.ID)
+	assert.Equal(t, "Acme", org.Name)
+	assert.True(t, org.HasRole(user.ID, OrgOwner))
+	assert.Equal(t, 1, org.Owners())
+}
@@ -0,0 +1,39 @@
+package schema
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OutboxStatus is the delivery state of a queued email.
+type OutboxStatus string
+
+const (
+	// OutboxPending messages are waiting to be (re)tried.
+	OutboxPending OutboxStatus = "PENDING"
+	// OutboxSent messages were delivered.
+	OutboxSent OutboxStatus = "SENT"
+	// OutboxDead messages failed too many times and are only retried by an admin.
+	OutboxDead OutboxStatus = "DEAD"
+)
+
+// OutboxMessage is an email waiting in the outbox to be delivered by the outbox worker.
+type OutboxMessage struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	To             string             `bson:"to" json:"to"`
+	Subject        string             `bson:"subject" json:"subject"`
+	Body           string             `bson:"body" json:"body"`
+	HTMLBody       string             `bson:"htmlBody,omitempty" json:"htmlBody,omitempty"`
+	UnsubscribeURL string             `bson:"unsubscribeURL,omitempty" json:"-"`
+	Status         OutboxStatus       `bson:"status" json:"status"`
+	Attempts       int                `bson:"attempts" json:"attempts"`
+	LastError      string             `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	NextAttemptAt  time.Time          `bson:"nextAttemptAt" json:"nextAttemptAt"`
+	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
+	SentAt         time.Time          `bson:"sentAt,omitempty" json:"sentAt,omitempty"`
+}
+
+func (m OutboxMessage) GetCollectionName() string {
+	return "email_outbox"
+}
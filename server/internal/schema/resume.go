@@ -0,0 +1,53 @@
+package schema
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Resume is one version of the structured CV of a job seeker.
+// A version never changes once saved: saving a resume again adds the next version,
+// so that an application keeps showing the resume it was sent with.
+type Resume struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID     primitive.ObjectID `bson:"userID" json:"userID"`
+	Version    int                `bson:"version" json:"version"`
+	Title      string             `bson:"title" json:"title" binding:"required,min=1,max=100"`
+	Summary    string             `bson:"summary,omitempty" json:"summary,omitempty" binding:"omitempty,max=2000"`
+	Experience []WorkExperience   `bson:"experience" json:"experience" binding:"max=50,dive"`
+	Education  []Education        `bson:"education" json:"education" binding:"max=20,dive"`
+	Skills     []string           `bson:"skills" json:"skills" binding:"max=100,dive,min=1,max=100"`
+	Projects   []Project          `bson:"projects" json:"projects" binding:"max=50,dive"`
+	FileID     primitive.ObjectID `bson:"fileID" json:"fileID"` // the rendered PDF, a File of CategoryResume
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// Project is something a job seeker built, listed on their resume.
+type Project struct {
+	Name         string   `bson:"name" json:"name" binding:"required,min=1,max=200"`
+	URL          string   `bson:"url,omitempty" json:"url,omitempty" binding:"omitempty,url,max=200"`
+	Description  string   `bson:"description,omitempty" json:"description,omitempty" binding:"omitempty,max=5000"`
+	Technologies []string `bson:"technologies,omitempty" json:"technologies,omitempty" binding:"omitempty,max=50,dive,min=1,max=100"`
+}
+
+func (r Resume) GetCollectionName() string {
+	return "resumes"
+}
+
+// Validate checks what binding rules cannot, and replaces missing sections with empty ones.
+func (r *Resume) Validate() error {
+	if r.Experience == nil {
+		r.Experience = []WorkExperience{}
+	}
+	if r.Education == nil {
+		r.Education = []Education{}
+	}
+	if r.Skills == nil {
+		r.Skills = []string{}
+	}
+	if r.Projects == nil {
+		r.Projects = []Project{}
+	}
+	return validateWorkHistory(r.Experience)
+}
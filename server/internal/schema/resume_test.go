@@ -0,0 +1,67 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func resumeValidPayload() map[string]any {
+	return map[string]any{
+		"title":   "Backend developer",
+		"summary": "Go developer who likes databases",
+		"experience": []map[string]any{
+			{"company": "Acme", "title": "Intern", "startDate": "2024-06", "endDate": "2024-09"},
+		},
+		"education": []map[string]any{
+			{"institution": "Kasetsart University", "startYear": 2021, "endYear": 2025},
+		},
+		"skills": []string{"Go", "MongoDB"},
+		"projects": []map[string]any{
+			{"name": "Job Applier 3000", "url": "https://github.com/lnwdevelopers007/job-applier-3000", "technologies": []string{"Go", "Svelte"}},
+		},
+	}
+}
+
+func TestValidResume(t *testing.T) {
+	resume, err := bindMockRequest[Resume](t, resumeValidPayload())
+	assert.NoError(t, err)
+	assert.NoError(t, resume.Validate())
+	assert.Equal(t, "Svelte", resume.Projects[0].Technologies[1])
+}
+
+func TestResumeMissingTitle(t *testing.T) {
+	payload := resumeValidPayload()
+	delete(payload, "title")
+	_, err := bindMockRequest[Resume](t, payload)
+	assert.Error(t, err)
+}
+
+func TestResumeInvalidSections(t *testing.T) {
+	cases := map[string]any{
+		"experience": []map[string]any{{"company": "Acme", "startDate": "2024-06"}},
+		"education":  []map[string]any{{"degree": "BEng"}},
+		"skills":     []string{""},
+		"projects":   []map[string]any{{"name": "Site", "url": "not a url"}},
+	}
+	for section, value := range cases {
+		t.Run(section, func(t *testing.T) {
+			payload := resumeValidPayload()
+			payload[section] = value
+			_, err := bindMockRequest[Resume](t, payload)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestResumeValidate(t *testing.T) {
+	resume := Resume{Title: "Empty"}
+	assert.NoError(t, resume.Validate())
+	assert.NotNil(t, resume.Experience)
+	assert.NotNil(t, resume.Education)
+	assert.NotNil(t, resume.Skills)
+	assert.NotNil(t, resume.Projects)
+
+	resume.Experience = []WorkExperience{{Company: "Acme", Title: "Intern", StartDate: "2024-06", EndDate: "2023-01"}}
+	assert.Error(t, resume.Validate())
+}
@@ -0,0 +1,16 @@
+package schema
+
+import "time"
+
+// RevokedToken is a JWT which must not be accepted anymore, e.g. after logout.
+// It is removed by a TTL index once the token would have expired anyway.
+type RevokedToken struct {
+	// ID is the token's jti.
+	ID        string    `bson:"_id" json:"id"`
+	ExpiresAt time.Time `bson:"expiresAt" json:"expiresAt"`
+	RevokedAt time.Time `bson:"revokedAt" json:"revokedAt"`
+}
+
+func (t RevokedToken) GetCollectionName() string {
+	return "revoked_tokens"
+}
@@ -0,0 +1,42 @@
+package schema
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DigestFrequency is how often a saved search is run and emailed to its owner.
+type DigestFrequency string
+
+const (
+	DigestDaily  DigestFrequency = "daily"
+	DigestWeekly DigestFrequency = "weekly"
+)
+
+// Period returns the time between two digests.
+func (f DigestFrequency) Period() time.Duration {
+	if f == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// SavedSearch is a job search a user wants to receive email digests for.
+// Params are /jobs/query parameters, e.g. {"q": "golang", "workType": "onsite"}.
+type SavedSearch struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID           primitive.ObjectID `bson:"userID" json:"userID"`
+	Name             string             `bson:"name" json:"name" binding:"required,min=1,max=100"`
+	Params           map[string]string  `bson:"params" json:"params" binding:"max=20"`
+	Frequency        DigestFrequency    `bson:"frequency" json:"frequency" binding:"required,oneof=daily weekly"`
+	Subscribed       bool               `bson:"subscribed" json:"subscribed"`
+	UnsubscribeToken string             `bson:"unsubscribeToken" json:"-"`
+	LastRunAt        time.Time          `bson:"lastRunAt" json:"lastRunAt"`
+	NextRunAt        time.Time          `bson:"nextRunAt" json:"nextRunAt"`
+	CreatedAt        time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+func (s SavedSearch) GetCollectionName() string {
+	return "saved_searches"
+}
@@ -0,0 +1,69 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// --- helpers ---
+
+func savedSearchValidPayload() map[string]any {
+	return map[string]any{
+		"name":      "Go jobs in Bangkok",
+		"params":    map[string]string{"q": "golang", "location": "Bangkok"},
+		"frequency": "daily",
+	}
+}
+
+func bindMockSavedSearch(t *testing.T, payload map[string]any) (SavedSearch, error) {
+	return bindMockRequest[SavedSearch](t, payload)
+}
+
+// --- valid case ---
+
+func TestValidSavedSearch(t *testing.T) {
+	search, err := bindMockSavedSearch(t, savedSearchValidPayload())
+	assert.NoError(t, err)
+	assert.Equal(t, DigestDaily, search.Frequency)
+	assert.Equal(t, "golang", search.Params["q"])
+}
+
+func TestSavedSearchWithoutParams(t *testing.T) {
+	payload := savedSearchValidPayload()
+	delete(payload, "params")
+	_, err := bindMockSavedSearch(t, payload)
+	assert.NoError(t, err)
+}
+
+// --- Name ---
+
+func TestSavedSearchMissingName(t *testing.T) {
+	payload := savedSearchValidPayload()
+	delete(payload, "name")
+	_, err := bindMockSavedSearch(t, payload)
+	assert.Error(t, err)
+}
+
+func TestSavedSearchNameTooLong(t *testing.T) {
+	payload := savedSearchValidPayload()
+	payload["name"] = strings.Repeat("a", 101)
+	_, err := bindMockSavedSearch(t, payload)
+	assert.Error(t, err)
+}
+
+// --- Frequency ---
+
+func TestSavedSearchInvalidFrequency(t *testing.T) {
+	payload := savedSearchValidPayload()
+	payload["frequency"] = "hourly"
+	_, err := bindMockSavedSearch(t, payload)
+	assert.Error(t, err)
+}
+
+func TestDigestFrequencyPeriod(t *testing.T) {
+	assert.Equal(t, 24*time.Hour, DigestDaily.Period())
+	assert.Equal(t, 7*24*time.Hour, DigestWeekly.Period())
+}
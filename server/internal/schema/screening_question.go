@@ -0,0 +1,285 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// QuestionType is the kind of answer a screening question expects.
+type QuestionType string
+
+const (
+	QuestionShortText    QuestionType = "shortText"
+	QuestionLongText     QuestionType = "longText"
+	QuestionSingleChoice QuestionType = "singleChoice"
+	QuestionMultiChoice  QuestionType = "multiChoice"
+	QuestionYesNo        QuestionType = "yesNo"
+	QuestionNumber       QuestionType = "number"
+)
+
+// maxLength is the longest answer allowed to a text question of type, and the default MaxLength.
+var maxLength = map[QuestionType]int{
+	QuestionShortText: 200,
+	QuestionLongText:  5000,
+}
+
+// ScreeningQuestion is a question applicants to a job answer when applying.
+type ScreeningQuestion struct {
+	// ID stays the same when the question is edited, new questions get one when the job is saved.
+	ID       string       `bson:"id" json:"id" binding:"omitempty,max=50"`
+	Prompt   string       `bson:"prompt" json:"prompt" binding:"required,min=1,max=500"`
+	Type     QuestionType `bson:"type" json:"type" binding:"required,oneof=shortText longText singleChoice multiChoice yesNo number"`
+	Required bool         `bson:"required" json:"required"`
+	// Options of singleChoice and multiChoice questions.
+	Options []string `bson:"options,omitempty" json:"options,omitempty" binding:"omitempty,max=50,dive,min=1,max=200"`
+	// Length limits of text answers in characters.
+	MinLength int `bson:"minLength,omitempty" json:"minLength,omitempty" binding:"gte=0,lte=5000"`
+	MaxLength int `bson:"maxLength,omitempty" json:"maxLength,omitempty" binding:"gte=0,lte=5000"`
+	// Limits of number answers.
+	Min *float64 `bson:"min,omitempty" json:"min,omitempty"`
+	Max *float64 `bson:"max,omitempty" json:"max,omitempty"`
+}
+
+// ScreeningQuestions are the questions of a job.
+// Jobs used to have one free text instead, which is read as one optional long text question per line.
+type ScreeningQuestions []ScreeningQuestion
+
+// LegacyQuestions turns the free text questions of a job into ScreeningQuestions.
+// The IDs only depend on the line, so that answers keep pointing at the same question.
+func LegacyQuestions(text string) ScreeningQuestions {
+	var questions ScreeningQuestions
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if utf8.RuneCountInString(line) > 500 {
+			line = string([]rune(line)[:500])
+		}
+		questions = append(questions, ScreeningQuestion{
+			ID:     fmt.Sprintf("q%d", len(questions)+1),
+			Prompt: line,
+			Type:   QuestionLongText,
+		})
+	}
+	return questions
+}
+
+func (qs *ScreeningQuestions) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	switch t {
+	case bsontype.String:
+		text, _, ok := bsoncore.ReadString(data)
+		if !ok {
+			return errors.New("invalid questions")
+		}
+		*qs = LegacyQuestions(text)
+		return nil
+	case bsontype.Null, bsontype.Undefined:
+		*qs = nil
+		return nil
+	}
+	var questions []ScreeningQuestion
+	if err := bson.UnmarshalValue(t, data, &questions); err != nil {
+		return err
+	}
+	*qs = questions
+	return nil
+}
+
+func (qs *ScreeningQuestions) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*qs = LegacyQuestions(text)
+		return nil
+	}
+	var questions []ScreeningQuestion
+	if err := json.Unmarshal(data, &questions); err != nil {
+		return err
+	}
+	*qs = questions
+	return nil
+}
+
+// Validate checks what binding rules cannot, and gives new questions an ID.
+func (qs ScreeningQuestions) Validate() error {
+	if len(qs) > 50 {
+		return errors.New("a job can have at most 50 questions")
+	}
+	seen := map[string]bool{}
+	for i := range qs {
+		q := &qs[i]
+		if q.ID == "" {
+			q.ID = primitive.NewObjectID().Hex()
+		}
+		if seen[q.ID] {
+			return fmt.Errorf("question ID %s is used twice", q.ID)
+		}
+		seen[q.ID] = true
+		if err := q.validate(); err != nil {
+			return fmt.Errorf("question %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (q *ScreeningQuestion) validate() error {
+	q.Prompt = strings.TrimSpace(q.Prompt)
+	if q.Prompt == "" || utf8.RuneCountInString(q.Prompt) > 500 {
+		return errors.New("prompt must have 1 to 500 characters")
+	}
+
+	switch q.Type {
+	case QuestionSingleChoice, QuestionMultiChoice:
+		if len(q.Options) < 2 {
+			return errors.New("choice questions need at least 2 options")
+		}
+		for i, option := range q.Options {
+			if strings.TrimSpace(option) == "" {
+				return errors.New("options cannot be empty")
+			}
+			if slices.Contains(q.Options[:i], option) {
+				return fmt.Errorf("option %q is listed twice", option)
+			}
+		}
+	case QuestionShortText, QuestionLongText, QuestionYesNo, QuestionNumber:
+		if len(q.Options) > 0 {
+			return fmt.Errorf("%s questions have no options", q.Type)
+		}
+	default:
+		return fmt.Errorf("unknown question type %q", q.Type)
+	}
+
+	if limit, ok := maxLength[q.Type]; ok {
+		if q.MaxLength == 0 || q.MaxLength > limit {
+			q.MaxLength = limit
+		}
+		if q.MinLength < 0 || q.MinLength > q.MaxLength {
+			return fmt.Errorf("minLength must be between 0 and maxLength (%d)", q.MaxLength)
+		}
+	} else {
+		q.MinLength, q.MaxLength = 0, 0
+	}
+
+	if q.Type != QuestionNumber {
+		q.Min, q.Max = nil, nil
+	} else if q.Min != nil && q.Max != nil && *q.Min > *q.Max {
+		return errors.New("min cannot be greater than max")
+	}
+	return nil
+}
+
+// ScreeningAnswer is the answer of an applicant to one ScreeningQuestion.
+// Only the field of the type of the question is set.
+type ScreeningAnswer struct {
+	QuestionID string `bson:"questionID" json:"questionID" binding:"required,max=50"`
+	// Prompt and Type are copied from the question when the application is sent,
+	// so that editing the questions of the job later does not change what the answer meant.
+	Prompt  string       `bson:"prompt" json:"prompt"`
+	Type    QuestionType `bson:"type" json:"type"`
+	Text    string       `bson:"text,omitempty" json:"text,omitempty" binding:"omitempty,max=5000"`
+	Choices []string     `bson:"choices,omitempty" json:"choices,omitempty" binding:"omitempty,max=50,dive,max=200"`
+	Yes     *bool        `bson:"yes,omitempty" json:"yes,omitempty"`
+	Number  *float64     `bson:"number,omitempty" json:"number,omitempty"`
+}
+
+// Answer checks the answers of an applicant to qs, and returns them in the order of the questions.
+// Every required question must be answered, and every answer must answer one of qs.
+func (qs ScreeningQuestions) Answer(answers []ScreeningAnswer) ([]ScreeningAnswer, error) {
+	byQuestion := map[string]ScreeningAnswer{}
+	for _, answer := range answers {
+		if !slices.ContainsFunc(qs, func(q ScreeningQuestion) bool { return q.ID == answer.QuestionID }) {
+			return nil, fmt.Errorf("question %s is not a question of this job", answer.QuestionID)
+		}
+		if _, ok := byQuestion[answer.QuestionID]; ok {
+			return nil, fmt.Errorf("question %s is answered twice", answer.QuestionID)
+		}
+		byQuestion[answer.QuestionID] = answer
+	}
+
+	checked := []ScreeningAnswer{}
+	for _, q := range qs {
+		answer, ok := byQuestion[q.ID]
+		if ok {
+			answer, ok = q.check(answer)
+		}
+		if !ok {
+			if q.Required {
+				return nil, fmt.Errorf("%q must be answered", q.Prompt)
+			}
+			continue
+		}
+		if err := q.validateAnswer(answer); err != nil {
+			return nil, fmt.Errorf("%q: %w", q.Prompt, err)
+		}
+		checked = append(checked, answer)
+	}
+	return checked, nil
+}
+
+// check returns answer with only the field for the type of q, and whether that is answered at all.
+func (q ScreeningQuestion) check(answer ScreeningAnswer) (ScreeningAnswer, bool) {
+	kept := ScreeningAnswer{QuestionID: q.ID, Prompt: q.Prompt, Type: q.Type}
+	switch q.Type {
+	case QuestionShortText, QuestionLongText:
+		kept.Text = strings.TrimSpace(answer.Text)
+		return kept, kept.Text != ""
+	case QuestionSingleChoice, QuestionMultiChoice:
+		kept.Choices = answer.Choices
+		return kept, len(kept.Choices) > 0
+	case QuestionYesNo:
+		kept.Yes = answer.Yes
+		return kept, kept.Yes != nil
+	case QuestionNumber:
+		kept.Number = answer.Number
+		return kept, kept.Number != nil
+	}
+	return kept, false
+}
+
+func (q ScreeningQuestion) validateAnswer(answer ScreeningAnswer) error {
+	switch q.Type {
+	case QuestionShortText, QuestionLongText:
+		length := utf8.RuneCountInString(answer.Text)
+		limit := q.MaxLength
+		if limit == 0 {
+			limit = maxLength[q.Type]
+		}
+		if length < q.MinLength || length > limit {
+			return fmt.Errorf("answer must have %d to %d characters", q.MinLength, limit)
+		}
+	case QuestionSingleChoice, QuestionMultiChoice:
+		if q.Type == QuestionSingleChoice && len(answer.Choices) != 1 {
+			return errors.New("choose one option")
+		}
+		for i, choice := range answer.Choices {
+			if !slices.Contains(q.Options, choice) {
+				return fmt.Errorf("%q is not an option", choice)
+			}
+			if slices.Contains(answer.Choices[:i], choice) {
+				return fmt.Errorf("%q is chosen twice", choice)
+			}
+		}
+	case QuestionNumber:
+		n := *answer.Number
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return errors.New("answer must be a number")
+		}
+		if q.Min != nil && n < *q.Min {
+			return fmt.Errorf("answer must be at least %g", *q.Min)
+		}
+		if q.Max != nil && n > *q.Max {
+			return fmt.Errorf("answer must be at most %g", *q.Max)
+		}
+	}
+	return nil
+}
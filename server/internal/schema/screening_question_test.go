@@ -0,0 +1,165 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func testQuestions() ScreeningQuestions {
+	return ScreeningQuestions{
+		{ID: "name", Prompt: "Preferred name", Type: QuestionShortText, MaxLength: 20},
+		{ID: "why", Prompt: "Why us?", Type: QuestionLongText, Required: true, MinLength: 10},
+		{ID: "shift", Prompt: "Shift", Type: QuestionSingleChoice, Required: true, Options: []string{"day", "night"}},
+		{ID: "langs", Prompt: "Languages", Type: QuestionMultiChoice, Options: []string{"Go", "Rust", "TypeScript"}},
+		{ID: "visa", Prompt: "Need a visa?", Type: QuestionYesNo, Required: true},
+		{ID: "years", Prompt: "Years of Go", Type: QuestionNumber, Min: ptr(0.0), Max: ptr(40.0)},
+	}
+}
+
+func validAnswers() []ScreeningAnswer {
+	return []ScreeningAnswer{
+		{QuestionID: "visa", Yes: ptr(false)},
+		{QuestionID: "why", Text: "  I like the product a lot  "},
+		{QuestionID: "shift", Choices: []string{"night"}},
+		{QuestionID: "years", Number: ptr(3.0)},
+	}
+}
+
+func TestScreeningQuestionsValidate(t *testing.T) {
+	questions := testQuestions()
+	questions = append(questions, ScreeningQuestion{Prompt: " New question ", Type: QuestionShortText})
+	assert.NoError(t, questions.Validate())
+
+	added := questions[len(questions)-1]
+	assert.NotEmpty(t, added.ID, "new questions get an ID")
+	assert.Equal(t, "New question", added.Prompt)
+	assert.Equal(t, 200, added.MaxLength, "text questions get the default limit")
+	assert.Equal(t, "name", questions[0].ID, "existing questions keep theirs")
+}
+
+func TestScreeningQuestionsValidateInvalid(t *testing.T) {
+	cases := map[string]ScreeningQuestions{
+		"duplicate ID":      {{ID: "a", Prompt: "A", Type: QuestionYesNo}, {ID: "a", Prompt: "B", Type: QuestionYesNo}},
+		"empty prompt":      {{Prompt: " ", Type: QuestionYesNo}},
+		"unknown type":      {{Prompt: "A", Type: "essay"}},
+		"one option":        {{Prompt: "A", Type: QuestionSingleChoice, Options: []string{"only"}}},
+		"duplicate option":  {{Prompt: "A", Type: QuestionMultiChoice, Options: []string{"x", "x"}}},
+		"options on text":   {{Prompt: "A", Type: QuestionShortText, Options: []string{"x", "y"}}},
+		"min above max len": {{Prompt: "A", Type: QuestionShortText, MinLength: 50, MaxLength: 10}},
+		"min above max":     {{Prompt: "A", Type: QuestionNumber, Min: ptr(5.0), Max: ptr(1.0)}},
+	}
+	for name, questions := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, questions.Validate())
+		})
+	}
+}
+
+func TestScreeningQuestionsAnswer(t *testing.T) {
+	answers, err := testQuestions().Answer(validAnswers())
+	assert.NoError(t, err)
+
+	// in the order of the questions, with what the question was when answered
+	if assert.Len(t, answers, 4) {
+		assert.Equal(t, "why", answers[0].QuestionID)
+		assert.Equal(t, "I like the product a lot", answers[0].Text)
+		assert.Equal(t, "Why us?", answers[0].Prompt)
+		assert.Equal(t, QuestionLongText, answers[0].Type)
+		assert.Equal(t, "visa", answers[2].QuestionID)
+		assert.False(t, *answers[2].Yes)
+	}
+}
+
+func TestScreeningQuestionsAnswerInvalid(t *testing.T) {
+	cases := map[string]func([]ScreeningAnswer) []ScreeningAnswer{
+		"missing required": func(a []ScreeningAnswer) []ScreeningAnswer { return a[1:] },
+		"blank required": func(a []ScreeningAnswer) []ScreeningAnswer {
+			a[1].Text = "   "
+			return a
+		},
+		"unknown question": func(a []ScreeningAnswer) []ScreeningAnswer {
+			return append(a, ScreeningAnswer{QuestionID: "other", Text: "hi"})
+		},
+		"answered twice": func(a []ScreeningAnswer) []ScreeningAnswer { return append(a, a[0]) },
+		"too short": func(a []ScreeningAnswer) []ScreeningAnswer {
+			a[1].Text = "short"
+			return a
+		},
+		"too long": func(a []ScreeningAnswer) []ScreeningAnswer {
+			return append(a, ScreeningAnswer{QuestionID: "name", Text: "a name far longer than twenty characters"})
+		},
+		"not an option": func(a []ScreeningAnswer) []ScreeningAnswer {
+			a[2].Choices = []string{"evening"}
+			return a
+		},
+		"two single choices": func(a []ScreeningAnswer) []ScreeningAnswer {
+			a[2].Choices = []string{"day", "night"}
+			return a
+		},
+		"chosen twice": func(a []ScreeningAnswer) []ScreeningAnswer {
+			return append(a, ScreeningAnswer{QuestionID: "langs", Choices: []string{"Go", "Go"}})
+		},
+		"above max": func(a []ScreeningAnswer) []ScreeningAnswer {
+			a[3].Number = ptr(41.0)
+			return a
+		},
+		"wrong type": func(a []ScreeningAnswer) []ScreeningAnswer {
+			a[0] = ScreeningAnswer{QuestionID: "visa", Text: "no"}
+			return a
+		},
+	}
+	for name, edit := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := testQuestions().Answer(edit(validAnswers()))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestScreeningQuestionsAnswerWithoutQuestions(t *testing.T) {
+	answers, err := ScreeningQuestions(nil).Answer(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, answers)
+}
+
+func TestLegacyQuestions(t *testing.T) {
+	legacy := "Why do you want this job?\n\n  Are you available in June?  \n"
+	want := ScreeningQuestions{
+		{ID: "q1", Prompt: "Why do you want this job?", Type: QuestionLongText},
+		{ID: "q2", Prompt: "Are you available in June?", Type: QuestionLongText},
+	}
+	assert.Equal(t, want, LegacyQuestions(legacy))
+	assert.Nil(t, LegacyQuestions("  "))
+
+	// jobs saved with free text questions are read as typed ones
+	raw, err := bson.Marshal(bson.M{"questions": legacy})
+	assert.NoError(t, err)
+	var job Job
+	assert.NoError(t, bson.Unmarshal(raw, &job))
+	assert.Equal(t, want, job.Questions)
+
+	var fromJSON Job
+	assert.NoError(t, json.Unmarshal([]byte(`{"questions": "Why do you want this job?\nAre you available in June?"}`), &fromJSON))
+	assert.Equal(t, want, fromJSON.Questions)
+}
+
+func TestScreeningQuestionsBSON(t *testing.T) {
+	job := Job{Questions: testQuestions()}
+	raw, err := bson.Marshal(job)
+	assert.NoError(t, err)
+	var decoded Job
+	assert.NoError(t, bson.Unmarshal(raw, &decoded))
+	assert.Equal(t, job.Questions, decoded.Questions)
+
+	raw, err = bson.Marshal(bson.M{"questions": nil})
+	assert.NoError(t, err)
+	assert.NoError(t, bson.Unmarshal(raw, &decoded))
+	assert.Nil(t, decoded.Questions)
+}
@@ -0,0 +1,35 @@
+package schema
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session is one login of a user on one device.
+// Every refresh rotates its refresh token, and only the latest one is accepted:
+// presenting an older one means it was stolen, so the whole session is revoked.
+// The one replaced last is still answered shortly after the rotation, as parallel requests send it too.
+type Session struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"userID" json:"userID"`
+	UserAgent  string             `bson:"userAgent" json:"userAgent"`
+	IP         string             `bson:"ip" json:"ip"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	LastUsedAt time.Time          `bson:"lastUsedAt" json:"lastUsedAt"`
+	// ExpiresAt is when the latest refresh token expires. A TTL index removes the session then.
+	ExpiresAt time.Time  `bson:"expiresAt" json:"expiresAt"`
+	RevokedAt *time.Time `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+	// RefreshJTI is the jti of the only refresh token of this session which may be used.
+	RefreshJTI string `bson:"refreshJTI" json:"-"`
+	// PreviousRefreshJTI is the refresh token which was replaced at RotatedAt.
+	PreviousRefreshJTI string     `bson:"previousRefreshJTI,omitempty" json:"-"`
+	RotatedAt          *time.Time `bson:"rotatedAt,omitempty" json:"-"`
+	// AccessJTI is the jti of the latest access token, revoked together with the session.
+	AccessJTI       string    `bson:"accessJTI" json:"-"`
+	AccessExpiresAt time.Time `bson:"accessExpiresAt" json:"-"`
+}
+
+func (s Session) GetCollectionName() string {
+	return "sessions"
+}
@@ -0,0 +1,63 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// Skills are the skills of a job seeker.
+// Profiles saved before they were a list hold them as one comma separated string,
+// which is still read, so that those users can sign in before the user-info migration ran.
+type Skills []string
+
+// UnmarshalBSONValue reads a list of skills or a comma separated string of them.
+func (s *Skills) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	switch t {
+	case bson.TypeNull, bson.TypeUndefined:
+		*s = nil
+		return nil
+	case bson.TypeString:
+		var joined string
+		if err := bson.UnmarshalValue(t, data, &joined); err != nil {
+			return err
+		}
+		*s = SplitSkills(joined)
+		return nil
+	}
+	var list []string
+	if err := bson.UnmarshalValue(t, data, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// UnmarshalJSON reads a list of skills or a comma separated string of them,
+// which clients sent before skills were a list.
+func (s *Skills) UnmarshalJSON(data []byte) error {
+	var joined string
+	if err := json.Unmarshal(data, &joined); err == nil {
+		*s = SplitSkills(joined)
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// SplitSkills splits a comma separated string of skills, leaving out blank ones.
+func SplitSkills(joined string) Skills {
+	var skills Skills
+	for _, skill := range strings.Split(joined, ",") {
+		if skill = strings.TrimSpace(skill); skill != "" {
+			skills = append(skills, skill)
+		}
+	}
+	return skills
+}
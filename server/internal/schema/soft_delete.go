@@ -0,0 +1,31 @@
+package schema
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SoftDelete is embedded in the schemas whose documents are only marked as deleted at first,
+// so that an admin can restore them until they are purged for good.
+// The repository leaves marked documents out of every read unless the filter asks about deletedAt.
+type SoftDelete struct {
+	DeletedAt *time.Time          `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
+	DeletedBy *primitive.ObjectID `bson:"deletedBy,omitempty" json:"deletedBy,omitempty"` // the user who deleted it, if known
+}
+
+// SoftDeletable is a CollectionEntity which embeds SoftDelete.
+type SoftDeletable interface {
+	CollectionEntity
+	IsDeleted() bool
+}
+
+// IsDeleted reports whether the document is marked as deleted.
+func (s SoftDelete) IsDeleted() bool {
+	return s.DeletedAt != nil
+}
+
+// Undelete clears the mark, e.g. on documents sent by clients, which may not delete anything by creating it.
+func (s *SoftDelete) Undelete() {
+	*s = SoftDelete{}
+}
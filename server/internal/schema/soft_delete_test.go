@@ -0,0 +1,58 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSoftDeleteIsStoredInline(t *testing.T) {
+	deletedAt := time.Now().UTC().Truncate(time.Millisecond)
+	admin := primitive.NewObjectID()
+	user := User{
+		ID:         primitive.NewObjectID(),
+		Name:       "Somchai",
+		Role:       RoleJobSeeker,
+		SoftDelete: SoftDelete{DeletedAt: &deletedAt, DeletedBy: &admin},
+	}
+
+	data, err := bson.Marshal(user)
+	assert.NoError(t, err)
+	var raw bson.M
+	assert.NoError(t, bson.Unmarshal(data, &raw))
+	assert.Equal(t, admin, raw["deletedBy"])
+	assert.Contains(t, raw, "deletedAt")
+
+	var decoded User
+	assert.NoError(t, bson.Unmarshal(data, &decoded))
+	assert.True(t, decoded.IsDeleted())
+	assert.Equal(t, deletedAt, *decoded.DeletedAt)
+
+	body, err := json.Marshal(user)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"deletedBy":"`+admin.Hex()+`"`)
+}
+
+func TestSoftDeleteIsLeftOutUntilSet(t *testing.T) {
+	data, err := bson.Marshal(Note{Content: "fine"})
+	assert.NoError(t, err)
+	var raw bson.M
+	assert.NoError(t, bson.Unmarshal(data, &raw))
+	assert.NotContains(t, raw, "deletedAt")
+	assert.NotContains(t, raw, "deletedBy")
+}
+
+func TestUndelete(t *testing.T) {
+	now := time.Now()
+	var job Job
+	assert.NoError(t, json.Unmarshal([]byte(`{"title": "Backend", "deletedAt": "`+now.Format(time.RFC3339)+`"}`), &job))
+	assert.True(t, job.IsDeleted())
+
+	job.Undelete()
+	assert.False(t, job.IsDeleted())
+	assert.Nil(t, job.DeletedBy)
+}
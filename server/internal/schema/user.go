@@ -2,15 +2,12 @@ package schema
 
 import (
 	"time"
-	"go.mongodb.org/mongo-driver/bson"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // User is the struct representing user (both company and jobSeeker).
-// UserInfo field can be either CompanyInfo or JobSeekerInfo, but due to its implementation
-// requireing the use of generic, which will break everything in the code,
-// I'll leave it as any type.
-// field validation to be added (in case we support non-google oauth and things go wrong).
+// UserInfo is a CompanyInfo or a JobSeekerInfo depending on Role, see user_info.go for how it is encoded.
 type User struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	UserID    string             `bson:"userID,omitempty" json:"userID,omitempty" binding:"omitempty,max=100"`
@@ -20,10 +17,24 @@ type User struct {
 	AvatarURL string             `bson:"avatarURL,omitempty" json:"avatarURL,omitempty" binding:"omitempty,url,max=500"`
 	Role      string             `bson:"role,omitempty" json:"role,omitempty" binding:"omitempty,oneof=jobSeeker company faculty admin"`
 	Verified  bool               `bson:"verified" json:"verified"`
+	Locale    string             `bson:"locale,omitempty" json:"locale,omitempty" binding:"omitempty,oneof=en th"`
 	UpdatedAt time.Time          `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
 	CreatedAt time.Time          `bson:"createdAt,omitempty" json:"createdAt,omitempty"`
-	UserInfo  bson.M             `bson:"userInfo,omitempty" json:"userInfo,omitempty"`
+	UserInfo  UserInfo           `bson:"-" json:"-"`
 	Banned    bool               `bson:"banned,omitempty" json:"banned,omitempty"`
+	// Identities are the OAuth accounts the user can log in with.
+	Identities []Identity `bson:"identities,omitempty" json:"identities,omitempty"`
+	SoftDelete `bson:",inline"`
+}
+
+// Identity is an account at an OAuth provider which is linked to a User.
+// Subject is the provider's ID of the account.
+type Identity struct {
+	Provider      string    `bson:"provider" json:"provider"`
+	Subject       string    `bson:"subject" json:"-"`
+	Email         string    `bson:"email,omitempty" json:"email,omitempty"`
+	EmailVerified bool      `bson:"emailVerified" json:"emailVerified"`
+	LinkedAt      time.Time `bson:"linkedAt" json:"linkedAt"`
 }
 
 func (u User) GetCollectionName() string {
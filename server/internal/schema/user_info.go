@@ -0,0 +1,85 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// UserInfo is the profile of a user. Its type depends on the role of the user:
+// *CompanyInfo for companies and *JobSeekerInfo for job seekers. Other roles have none.
+type UserInfo interface {
+	// ProfileRole is the role of the users with this type of profile.
+	ProfileRole() string
+}
+
+// NewUserInfo returns an empty profile for a user with role, or nil if the role has no profile.
+func NewUserInfo(role string) UserInfo {
+	switch role {
+	case RoleCompany:
+		return &CompanyInfo{}
+	case RoleJobSeeker:
+		return &JobSeekerInfo{}
+	}
+	return nil
+}
+
+// userFields are the fields of User without its methods, so that the methods below can encode them.
+type userFields User
+
+func (u User) MarshalBSON() ([]byte, error) {
+	return bson.Marshal(struct {
+		Fields   userFields `bson:",inline"`
+		UserInfo UserInfo   `bson:"userInfo,omitempty"`
+	}{userFields(u), u.UserInfo})
+}
+
+// UnmarshalBSON decodes userInfo into the profile type of the user's role.
+func (u *User) UnmarshalBSON(data []byte) error {
+	var doc struct {
+		Fields   userFields    `bson:",inline"`
+		UserInfo bson.RawValue `bson:"userInfo,omitempty"`
+	}
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	*u = User(doc.Fields)
+	info := NewUserInfo(u.Role)
+	if info == nil || doc.UserInfo.Type != bson.TypeEmbeddedDocument {
+		return nil
+	}
+	if err := doc.UserInfo.Unmarshal(info); err != nil {
+		return fmt.Errorf("userInfo of user %s: %w", u.ID.Hex(), err)
+	}
+	u.UserInfo = info
+	return nil
+}
+
+func (u User) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		userFields
+		UserInfo UserInfo `json:"userInfo,omitempty"`
+	}{userFields(u), u.UserInfo})
+}
+
+// UnmarshalJSON decodes userInfo into the profile type of the user's role.
+func (u *User) UnmarshalJSON(data []byte) error {
+	var doc struct {
+		userFields
+		UserInfo json.RawMessage `json:"userInfo,omitempty"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	*u = User(doc.userFields)
+	info := NewUserInfo(u.Role)
+	if info == nil || len(doc.UserInfo) == 0 || string(doc.UserInfo) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(doc.UserInfo, info); err != nil {
+		return fmt.Errorf("userInfo: %w", err)
+	}
+	u.UserInfo = info
+	return nil
+}
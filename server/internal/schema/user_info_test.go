@@ -0,0 +1,159 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUserInfoBSONRoundTrip(t *testing.T) {
+	seeker := User{
+		ID:   primitive.NewObjectID(),
+		Name: "Somchai",
+		Role: RoleJobSeeker,
+		UserInfo: &JobSeekerInfo{
+			Location:    "Bangkok",
+			Phone:       "0812345678",
+			Skills:      []string{"Go", "MongoDB"},
+			Education:   []Education{{Institution: "Kasetsart University", StartYear: 2022}},
+			WorkHistory: []WorkExperience{{Company: "Acme", Title: "Intern", StartDate: "2024-06"}},
+		},
+	}
+
+	data, err := bson.Marshal(seeker)
+	assert.NoError(t, err)
+	var raw bson.M
+	assert.NoError(t, bson.Unmarshal(data, &raw))
+	assert.Equal(t, "Somchai", raw["name"])
+	assert.Equal(t, "Bangkok", raw["userInfo"].(bson.M)["location"])
+
+	var decoded User
+	assert.NoError(t, bson.Unmarshal(data, &decoded))
+	assert.Equal(t, seeker, decoded)
+}
+
+func TestUserInfoWithLegacySkills(t *testing.T) {
+	data, err := bson.Marshal(bson.M{
+		"role":     RoleJobSeeker,
+		"userInfo": bson.M{"location": "Bangkok", "skills": "Go, MongoDB,, Svelte "},
+	})
+	assert.NoError(t, err)
+	var seeker User
+	assert.NoError(t, bson.Unmarshal(data, &seeker))
+	info, ok := seeker.UserInfo.(*JobSeekerInfo)
+	if assert.True(t, ok) {
+		assert.Equal(t, Skills{"Go", "MongoDB", "Svelte"}, info.Skills)
+	}
+
+	data, err = bson.Marshal(bson.M{"role": RoleJobSeeker, "userInfo": bson.M{"skills": nil}})
+	assert.NoError(t, err)
+	assert.NoError(t, bson.Unmarshal(data, &seeker))
+	assert.Empty(t, seeker.UserInfo.(*JobSeekerInfo).Skills)
+
+	var fromJSON JobSeekerInfo
+	assert.NoError(t, json.Unmarshal([]byte(`{"skills": "Go, Svelte"}`), &fromJSON))
+	assert.Equal(t, Skills{"Go", "Svelte"}, fromJSON.Skills)
+	assert.NoError(t, json.Unmarshal([]byte(`{"skills": ["Go"]}`), &fromJSON))
+	assert.Equal(t, Skills{"Go"}, fromJSON.Skills)
+}
+
+func TestUserInfoDependsOnRole(t *testing.T) {
+	data, err := bson.Marshal(bson.M{
+		"role":     RoleCompany,
+		"userInfo": bson.M{"name": "Acme", "website": "https://acme.example.com"},
+	})
+	assert.NoError(t, err)
+	var company User
+	assert.NoError(t, bson.Unmarshal(data, &company))
+	info, ok := company.UserInfo.(*CompanyInfo)
+	assert.True(t, ok)
+	assert.Equal(t, "Acme", info.Name)
+
+	// roles without a profile ignore userInfo
+	data, err = bson.Marshal(bson.M{"role": "faculty", "userInfo": bson.M{"name": "Dr. A"}})
+	assert.NoError(t, err)
+	var faculty User
+	assert.NoError(t, bson.Unmarshal(data, &faculty))
+	assert.Nil(t, faculty.UserInfo)
+}
+
+func TestUserInfoJSON(t *testing.T) {
+	var user User
+	err := json.Unmarshal([]byte(`{"name":"Somchai","role":"jobSeeker","userInfo":{"location":"Bangkok","phone":"0812345678","skills":["Go"]}}`), &user)
+	assert.NoError(t, err)
+	info, ok := user.UserInfo.(*JobSeekerInfo)
+	assert.True(t, ok)
+	assert.Equal(t, Skills{"Go"}, info.Skills)
+
+	data, err := json.Marshal(user)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"userInfo":{"location":"Bangkok"`)
+	assert.Contains(t, string(data), `"name":"Somchai"`)
+
+	// users without a profile have no userInfo
+	data, err = json.Marshal(User{Name: "Admin", Role: "admin"})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "userInfo")
+}
+
+func TestJobSeekerInfoValidation(t *testing.T) {
+	payload := map[string]any{
+		"location": "Bangkok",
+		"phone":    "0812345678",
+		"skills":   []string{"Go"},
+		"education": []map[string]any{
+			{"institution": "Kasetsart University", "startYear": 2022, "endYear": 2026},
+		},
+		"workHistory": []map[string]any{
+			{"company": "Acme", "title": "Intern", "startDate": "2024-06", "endDate": "2024-08"},
+		},
+		"expectedSalary": map[string]any{"currency": "THB", "min": 30000, "max": 45000},
+	}
+	info, err := bindMockRequest[JobSeekerInfo](t, payload)
+	assert.NoError(t, err)
+	assert.NoError(t, info.Validate())
+
+	invalid := []func(p map[string]any){
+		func(p map[string]any) { delete(p, "phone") },
+		func(p map[string]any) { p["skills"] = []string{""} },
+		func(p map[string]any) { p["dateOfBirth"] = "31/12/2000" },
+		func(p map[string]any) {
+			p["education"] = []map[string]any{{"institution": "KU", "startYear": 2022, "endYear": 2020}}
+		},
+		func(p map[string]any) {
+			p["workHistory"] = []map[string]any{{"company": "Acme", "startDate": "2024-06"}}
+		},
+		func(p map[string]any) {
+			p["expectedSalary"] = map[string]any{"currency": "THB", "min": 50000, "max": 40000}
+		},
+	}
+	for i, change := range invalid {
+		p := map[string]any{"location": "Bangkok", "phone": "0812345678"}
+		change(p)
+		_, err := bindMockRequest[JobSeekerInfo](t, p)
+		assert.Error(t, err, "case %d", i)
+	}
+
+	info.WorkHistory[0].EndDate = "2024-01"
+	assert.Error(t, info.Validate())
+}
+
+func TestCompanyInfoValidation(t *testing.T) {
+	payload := map[string]any{
+		"name":     "Acme",
+		"aboutUs":  "We make everything.",
+		"industry": "Manufacturing",
+		"size":     "51-200",
+		"website":  "https://acme.example.com",
+		"logo":     "data:image/png;base64,iVBORw0KGgo=",
+	}
+	_, err := bindMockRequest[CompanyInfo](t, payload)
+	assert.NoError(t, err)
+
+	payload["website"] = "not a url"
+	_, err = bindMockRequest[CompanyInfo](t, payload)
+	assert.Error(t, err)
+}
@@ -0,0 +1,162 @@
+// Package search holds helpers for presenting full-text search results.
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// snippetRadius is how many characters of context are kept on each side of a match.
+	snippetRadius = 40
+	// maxSnippets is the most snippets Highlight returns for one text.
+	maxSnippets = 3
+)
+
+// Terms splits a search query into the lower-cased word stems that MongoDB's
+// $text search would match on. Negated words ("-java") are left out
+// and quoted phrases are split into their words.
+func Terms(query string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, field := range strings.Fields(query) {
+		if strings.HasPrefix(field, "-") {
+			continue
+		}
+		words := strings.FieldsFunc(strings.ToLower(field), func(r rune) bool {
+			return !isWordRune(r)
+		})
+		for _, w := range words {
+			w = stem(w)
+			if utf8.RuneCountInString(w) < 2 || seen[w] {
+				continue
+			}
+			seen[w] = true
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
+
+// Highlight returns short snippets of text around the words that start with one of terms.
+// Matched words are wrapped in <mark></mark> and everything else is HTML-escaped,
+// so a snippet is safe to render as HTML. It returns nil when nothing matches.
+func Highlight(text string, terms []string) []string {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var hits []span
+	for _, w := range wordSpans(text) {
+		word := strings.ToLower(text[w.start:w.end])
+		for _, t := range terms {
+			if strings.HasPrefix(word, t) {
+				hits = append(hits, w)
+				break
+			}
+		}
+	}
+
+	var snippets []string
+	for i := 0; i < len(hits) && len(snippets) < maxSnippets; {
+		start := moveRunes(text, hits[i].start, -snippetRadius)
+		end := moveRunes(text, hits[i].end, snippetRadius)
+
+		var b strings.Builder
+		if start > 0 {
+			b.WriteString("…")
+		}
+		pos := start
+		// matches close to each other share one snippet
+		for ; i < len(hits) && hits[i].start < end; i++ {
+			b.WriteString(clean(text[pos:hits[i].start]))
+			b.WriteString("<mark>")
+			b.WriteString(clean(text[hits[i].start:hits[i].end]))
+			b.WriteString("</mark>")
+			pos = hits[i].end
+			if end < hits[i].end {
+				end = moveRunes(text, hits[i].end, snippetRadius)
+			}
+		}
+		b.WriteString(clean(text[pos:end]))
+		if end < len(text) {
+			b.WriteString("…")
+		}
+		snippets = append(snippets, b.String())
+	}
+	return snippets
+}
+
+// span is the byte range of one word in a text.
+type span struct {
+	start, end int
+}
+
+func wordSpans(text string) []span {
+	var spans []span
+	start := -1
+	for i, r := range text {
+		switch {
+		case isWordRune(r) && start < 0:
+			start = i
+		case !isWordRune(r) && start >= 0:
+			spans = append(spans, span{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, span{start, len(text)})
+	}
+	return spans
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// stem strips the most common English suffixes, so that "developers"
+// still highlights "developer" and "developing" like $text matches them.
+func stem(word string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		if trimmed, ok := strings.CutSuffix(word, suffix); ok && utf8.RuneCountInString(trimmed) >= 3 {
+			return trimmed
+		}
+	}
+	return word
+}
+
+// moveRunes moves the byte offset pos by n runes, staying inside text.
+func moveRunes(text string, pos int, n int) int {
+	for ; n < 0 && pos > 0; n++ {
+		_, size := utf8.DecodeLastRuneInString(text[:pos])
+		pos -= size
+	}
+	for ; n > 0 && pos < len(text); n-- {
+		_, size := utf8.DecodeRuneInString(text[pos:])
+		pos += size
+	}
+	return pos
+}
+
+// clean collapses line breaks and runs of spaces, then escapes s for HTML.
+func clean(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return html.EscapeString(b.String())
+}
@@ -0,0 +1,46 @@
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerms(t *testing.T) {
+	terms := Terms(`Go developers "remote work" -java go`)
+	assert.Equal(t, []string{"go", "developer", "remote", "work"}, terms)
+}
+
+func TestTermsEmpty(t *testing.T) {
+	assert.Empty(t, Terms("  - a "))
+}
+
+func TestHighlightMarksMatches(t *testing.T) {
+	snippets := Highlight("Senior Go Developer", Terms("developers"))
+	assert.Equal(t, []string{"Senior Go <mark>Developer</mark>"}, snippets)
+}
+
+func TestHighlightNoMatch(t *testing.T) {
+	assert.Nil(t, Highlight("Senior Go Developer", Terms("rust")))
+}
+
+func TestHighlightEscapesHTML(t *testing.T) {
+	snippets := Highlight("<b>Go</b> & friends", Terms("go"))
+	assert.Equal(t, []string{"&lt;b&gt;<mark>Go</mark>&lt;/b&gt; &amp; friends"}, snippets)
+}
+
+func TestHighlightTrimsLongText(t *testing.T) {
+	text := strings.Repeat("filler ", 30) + "kubernetes\n\nexperience " + strings.Repeat("filler ", 30)
+	snippets := Highlight(text, Terms("kubernetes"))
+	if assert.Len(t, snippets, 1) {
+		assert.True(t, strings.HasPrefix(snippets[0], "…"))
+		assert.True(t, strings.HasSuffix(snippets[0], "…"))
+		assert.Contains(t, snippets[0], "<mark>kubernetes</mark> experience")
+	}
+}
+
+func TestHighlightLimitsSnippets(t *testing.T) {
+	text := strings.Repeat("go "+strings.Repeat("filler ", 20), 10)
+	assert.Len(t, Highlight(text, Terms("go")), maxSnippets)
+}
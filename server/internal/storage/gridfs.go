@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const gridFSBucketName = "file_contents"
+
+// GridFSStore stores blobs in a MongoDB GridFS bucket, split into chunks,
+// so that no single document has to hold a whole file.
+type GridFSStore struct {
+	bucket *gridfs.Bucket
+}
+
+// NewGridFSStore creates a GridFSStore backed by the given database.
+func NewGridFSStore(db *mongo.Database) (*GridFSStore, error) {
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(gridFSBucketName))
+	if err != nil {
+		return nil, err
+	}
+	return &GridFSStore{bucket: bucket}, nil
+}
+
+// Put uploads r to GridFS with key as the GridFS file ID.
+// GridFS files cannot be overwritten, so a file with the same ID,
+// or the chunks of an upload which was cut off, are deleted first.
+func (s *GridFSStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	if err := s.Delete(ctx, key); err != nil {
+		return 0, err
+	}
+	counter := &countingReader{r: r}
+	stream, err := s.bucket.OpenUploadStreamWithID(key, key)
+	if err != nil {
+		return 0, err
+	}
+	if err := setStreamDeadline(ctx, stream.SetWriteDeadline); err != nil {
+		stream.Abort()
+		return 0, err
+	}
+	if _, err := io.Copy(stream, counter); err != nil {
+		stream.Abort()
+		return 0, err
+	}
+	if err := stream.Close(); err != nil {
+		return 0, err
+	}
+	return counter.n, nil
+}
+
+// Get opens a download stream for key.
+func (s *GridFSStore) Get(ctx context.Context, key string) (io.ReadCloser, int64, error) {
+	stream, err := s.bucket.OpenDownloadStream(key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, 0, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := setStreamDeadline(ctx, stream.SetReadDeadline); err != nil {
+		stream.Close()
+		return nil, 0, err
+	}
+	return stream, stream.GetFile().Length, nil
+}
+
+// Delete removes the GridFS file and its chunks.
+func (s *GridFSStore) Delete(ctx context.Context, key string) error {
+	err := s.bucket.DeleteContext(ctx, key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil
+	}
+	return err
+}
+
+// setStreamDeadline copies the context deadline (if any) onto a GridFS stream,
+// because GridFS streams do not take a context.
+func setStreamDeadline(ctx context.Context, set func(t time.Time) error) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		return set(deadline)
+	}
+	return nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
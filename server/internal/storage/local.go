@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// validLocalKey only allows keys that cannot escape the storage directory.
+var validLocalKey = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
+
+// LocalStore stores each blob as a file in a directory on the local filesystem.
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore creates a LocalStore rooted at dir, creating dir if needed.
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if dir == "" || dir == "false" {
+		return nil, errors.New("STORAGE_LOCAL_DIR is not set")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &LocalStore{dir: dir}, nil
+}
+
+// Put writes r to a temporary file and renames it into place,
+// so readers never see a partially written blob.
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, key+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, contextReader{ctx: ctx, r: r})
+	if err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Get opens the file stored under key.
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, 0, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+// Delete removes the file stored under key.
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *LocalStore) path(key string) (string, error) {
+	if !validLocalKey.MatchString(key) {
+		return "", errors.New("invalid blob key")
+	}
+	return filepath.Join(s.dir, key), nil
+}
+
+// contextReader stops reading once ctx is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
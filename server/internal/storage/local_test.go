@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStorePutGetDelete(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	content := []byte("%PDF-1.4\n%Test PDF Content\n%%EOF")
+	n, err := store.Put(ctx, "64f3a2b7e1d3a8c1b0f9d2aa", bytes.NewReader(content))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), n)
+
+	r, size, err := store.Get(ctx, "64f3a2b7e1d3a8c1b0f9d2aa")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), size)
+	got, err := io.ReadAll(r)
+	r.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	// putting the same key again replaces the blob
+	replaced := []byte("%PDF-1.4\n%%EOF")
+	_, err = store.Put(ctx, "64f3a2b7e1d3a8c1b0f9d2aa", bytes.NewReader(replaced))
+	assert.NoError(t, err)
+	r, _, err = store.Get(ctx, "64f3a2b7e1d3a8c1b0f9d2aa")
+	assert.NoError(t, err)
+	got, _ = io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, replaced, got)
+
+	assert.NoError(t, store.Delete(ctx, "64f3a2b7e1d3a8c1b0f9d2aa"))
+	_, _, err = store.Get(ctx, "64f3a2b7e1d3a8c1b0f9d2aa")
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+
+	// deleting twice is fine
+	assert.NoError(t, store.Delete(ctx, "64f3a2b7e1d3a8c1b0f9d2aa"))
+}
+
+func TestLocalStoreRejectsPathTraversal(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = store.Put(ctx, "../escape", bytes.NewReader([]byte("x")))
+	assert.Error(t, err)
+	_, _, err = store.Get(ctx, "../../etc/passwd")
+	assert.Error(t, err)
+}
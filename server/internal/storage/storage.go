@@ -0,0 +1,64 @@
+// Package storage keeps the binary content of uploaded files outside of
+// the documents that describe them.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/lnwdevelopers007/job-applier-3000/server/config"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/database"
+)
+
+// ErrBlobNotFound is returned when no blob is stored under the requested key.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores and streams file content by key.
+// Keys are chosen by the caller (we use the hex ID of schema.File).
+type BlobStore interface {
+	// Put streams r into the store under key and returns the number of bytes written.
+	// A blob already stored under key is replaced.
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Get opens the blob stored under key. The caller must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, int64, error)
+	// Delete removes the blob stored under key. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Supported values of the STORAGE_BACKEND environment variable.
+const (
+	BackendGridFS = "gridfs"
+	BackendLocal  = "local"
+)
+
+var (
+	instance BlobStore
+	once     sync.Once
+)
+
+// GetBlobStore returns the blob store selected by STORAGE_BACKEND.
+func GetBlobStore() BlobStore {
+	once.Do(func() {
+		store, err := newBlobStore(config.LoadEnv("STORAGE_BACKEND"))
+		if err != nil {
+			log.Fatal("Could not initialize blob storage: " + err.Error())
+		}
+		instance = store
+	})
+	return instance
+}
+
+func newBlobStore(backend string) (BlobStore, error) {
+	switch backend {
+	case BackendLocal:
+		return NewLocalStore(config.LoadEnv("STORAGE_LOCAL_DIR"))
+	case BackendGridFS, "", "false":
+		// "false" is what config.LoadEnv returns when there is no .env file.
+		return NewGridFSStore(database.GetDatabase())
+	default:
+		return nil, errors.New("unknown storage backend: " + backend)
+	}
+}
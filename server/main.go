@@ -6,11 +6,16 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"log/slog"
 	"os"
 
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/auth"
 	"github.com/lnwdevelopers007/job-applier-3000/server/internal/controller"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/migration"
+	"github.com/lnwdevelopers007/job-applier-3000/server/internal/outbox"
 )
 
 func main() {
@@ -21,8 +26,24 @@ func main() {
 	defer f.Close()
 	log.SetOutput(f)
 	slog.SetLogLoggerLevel(slog.LevelInfo)
+
+	migrationName := flag.String("migrate", "", "run a one-off data migration and exit")
+	flag.Parse()
+	if *migrationName != "" {
+		if err := migration.Run(context.Background(), *migrationName); err != nil {
+			log.Fatalf("migration failed: %v", err)
+		}
+		return
+	}
+
 	slog.Info("Server started")
 
+	go controller.RunSavedSearchDigests(context.Background())
+	go controller.RunJobLifecycle(context.Background())
+	go controller.RunDeletedPurger(context.Background())
+	go outbox.RunWorker(context.Background())
+	go auth.RunKeyReloader(context.Background())
+
 	router := controller.NewRouter()
 	router.Run(os.Getenv("SERVER_ADDR"))
 }